@@ -0,0 +1,167 @@
+package cmdx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/jsonschemax"
+)
+
+// CompletionFunc completes the value of a flag or argument. It can be passed to
+// cobra.Command.RegisterFlagCompletionFunc or used as cobra.Command.ValidArgsFunction.
+type CompletionFunc = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+const flagNoDescriptions = "no-descriptions"
+
+// NewCompletionCmd returns the `completion` command which generates the shell completion scripts of the
+// root command for bash, zsh, fish, and powershell.
+func NewCompletionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion",
+		Short: "Generate the shell completion script",
+		Long: `Generate the completion script for the specified shell.
+
+To load the completions in the current shell session, run for example:
+
+	$ source <(<command> completion bash)
+
+To load them for every new session, add the output to your shell's configuration, see the help of each
+shell's command.`,
+		Args: cobra.NoArgs,
+	}
+	cmd.PersistentFlags().Bool(flagNoDescriptions, false, "Disable completion descriptions.")
+
+	for _, shell := range []struct {
+		name, help string
+		gen        func(cmd *cobra.Command, descriptions bool) error
+	}{
+		{
+			name: "bash",
+			help: "Add `source <(<command> completion bash)` to ~/.bashrc. Requires the bash-completion package.",
+			gen: func(cmd *cobra.Command, descriptions bool) error {
+				return cmd.Root().GenBashCompletionV2(cmd.OutOrStdout(), descriptions)
+			},
+		},
+		{
+			name: "zsh",
+			help: "Add `source <(<command> completion zsh)` to ~/.zshrc. Completions must be enabled using `autoload -U compinit; compinit`.",
+			gen: func(cmd *cobra.Command, descriptions bool) error {
+				if descriptions {
+					return cmd.Root().GenZshCompletion(cmd.OutOrStdout())
+				}
+				return cmd.Root().GenZshCompletionNoDesc(cmd.OutOrStdout())
+			},
+		},
+		{
+			name: "fish",
+			help: "Add `<command> completion fish | source` to ~/.config/fish/config.fish.",
+			gen: func(cmd *cobra.Command, descriptions bool) error {
+				return cmd.Root().GenFishCompletion(cmd.OutOrStdout(), descriptions)
+			},
+		},
+		{
+			name: "powershell",
+			help: "Add `<command> completion powershell | Out-String | Invoke-Expression` to your PowerShell profile.",
+			gen: func(cmd *cobra.Command, descriptions bool) error {
+				if descriptions {
+					return cmd.Root().GenPowerShellCompletionWithDesc(cmd.OutOrStdout())
+				}
+				return cmd.Root().GenPowerShellCompletion(cmd.OutOrStdout())
+			},
+		},
+	} {
+		gen := shell.gen
+		cmd.AddCommand(&cobra.Command{
+			Use:               shell.name,
+			Short:             fmt.Sprintf("Generate the completion script for %s", shell.name),
+			Long:              fmt.Sprintf("Generate the completion script for %s.\n\n%s", shell.name, shell.help),
+			Args:              cobra.NoArgs,
+			ValidArgsFunction: cobra.NoFileCompletions,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				noDesc, err := cmd.Flags().GetBool(flagNoDescriptions)
+				if err != nil {
+					return err
+				}
+				return errors.WithStack(gen(cmd, !noDesc))
+			},
+		})
+	}
+
+	return cmd
+}
+
+// CompleteValues completes the given values.
+func CompleteValues(values ...string) CompletionFunc {
+	return func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return filterCompletions(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// CompleteSchemaEnum completes the values of the `enum` of the configuration key path, e.g. `log.level`,
+// in the JSON Schema. Use it for flags overriding configuration values.
+func CompleteSchemaEnum(schema []byte, path string) CompletionFunc {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		paths, err := jsonschemax.ListPathsBytes(commandContext(cmd), schema, 0)
+		if err != nil {
+			cobra.CompErrorln(fmt.Sprintf("Unable to list the paths of the configuration schema: %s", err))
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		for _, p := range paths {
+			if p.Name != path {
+				continue
+			}
+			values := make([]string, len(p.Enum))
+			for i, v := range p.Enum {
+				values[i] = fmt.Sprintf("%v", v)
+			}
+			return filterCompletions(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		cobra.CompErrorln(fmt.Sprintf("The configuration schema does not contain the key %s.", path))
+		return nil, cobra.ShellCompDirectiveError
+	}
+}
+
+// CompleteFunc completes the values returned by fetch, e.g. the IDs of resources loaded from an API.
+// Errors are reported to the completion debug log and result in no completions.
+func CompleteFunc(fetch func(ctx context.Context, cmd *cobra.Command, toComplete string) ([]string, error)) CompletionFunc {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		values, err := fetch(commandContext(cmd), cmd, toComplete)
+		if err != nil {
+			cobra.CompErrorln(fmt.Sprintf("Unable to load completions: %s", err))
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return filterCompletions(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// RegisterFlagCompletion registers the completion of the flag, which must be defined on cmd, and panics
+// otherwise.
+func RegisterFlagCompletion(cmd *cobra.Command, flag string, f CompletionFunc) {
+	if err := cmd.RegisterFlagCompletionFunc(flag, f); err != nil {
+		panic(fmt.Sprintf("unable to register the completion of flag --%s: %s", flag, err))
+	}
+}
+
+// commandContext returns the context of cmd, which is nil if the command was not executed with a context.
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+func filterCompletions(values []string, toComplete string) []string {
+	filtered := make([]string, 0, len(values))
+	for _, v := range values {
+		if strings.HasPrefix(v, toComplete) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,95 @@
+package cmdx
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func complete(t *testing.T, root *cobra.Command, args ...string) []string {
+	out := new(bytes.Buffer)
+	root.SetOut(out)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+	require.NoError(t, root.Execute())
+
+	// The last line contains the completion directive.
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	return lines[:len(lines)-1]
+}
+
+func TestNewCompletionCmd(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run("shell="+shell, func(t *testing.T) {
+			root := &cobra.Command{Use: "completion-test"}
+			root.AddCommand(NewCompletionCmd())
+
+			out := new(bytes.Buffer)
+			root.SetOut(out)
+			root.SetArgs([]string{"completion", shell})
+			require.NoError(t, root.Execute())
+
+			assert.Contains(t, out.String(), "completion-test")
+		})
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	schema := []byte(`{
+  "type": "object",
+  "properties": {
+    "log": {
+      "type": "object",
+      "properties": {
+        "level": {"type": "string", "enum": ["trace", "debug", "info"]}
+      }
+    }
+  }
+}`)
+
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "x"}
+		cmd := &cobra.Command{Use: "get", Run: func(*cobra.Command, []string) {}}
+		cmd.Flags().String("color", "", "")
+		cmd.Flags().String("level", "", "")
+		cmd.Flags().String("id", "", "")
+		cmd.Flags().String("broken", "", "")
+		RegisterFlagCompletion(cmd, "color", CompleteValues("red", "green", "blue"))
+		RegisterFlagCompletion(cmd, "level", CompleteSchemaEnum(schema, "log.level"))
+		RegisterFlagCompletion(cmd, "id", CompleteFunc(func(ctx context.Context, _ *cobra.Command, _ string) ([]string, error) {
+			return []string{"id-1", "id-2", "other"}, nil
+		}))
+		RegisterFlagCompletion(cmd, "broken", CompleteFunc(func(ctx context.Context, _ *cobra.Command, _ string) ([]string, error) {
+			return nil, errors.New("the API is not reachable")
+		}))
+		root.AddCommand(cmd)
+		return root
+	}
+
+	for _, tc := range []struct {
+		args     []string
+		expected []string
+	}{
+		{args: []string{"get", "--color", ""}, expected: []string{"red", "green", "blue"}},
+		{args: []string{"get", "--color", "gr"}, expected: []string{"green"}},
+		{args: []string{"get", "--level", "d"}, expected: []string{"debug"}},
+		{args: []string{"get", "--id", "id"}, expected: []string{"id-1", "id-2"}},
+		{args: []string{"get", "--broken", ""}, expected: []string{}},
+	} {
+		t.Run("args="+strings.Join(tc.args, " "), func(t *testing.T) {
+			assert.Equal(t, tc.expected, complete(t, newRoot(), tc.args...))
+		})
+	}
+
+	t.Run("case=panics on unknown flag", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterFlagCompletion(&cobra.Command{Use: "x"}, "unknown", CompleteValues())
+		})
+	})
+}
@@ -0,0 +1,292 @@
+package cmdx
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/jsonschema/v3"
+
+	"github.com/ory/x/errorsx"
+	"github.com/ory/x/jsonschemax"
+)
+
+// The exit codes of commands failing with PrintErr or Fatal. They are stable, so scripts can rely on them.
+const (
+	// ExitCodeError is used for errors without a more specific exit code.
+	ExitCodeError = 1
+	// ExitCodeUsage is used if the flags could not be parsed.
+	ExitCodeUsage = 2
+	// ExitCodeValidation is used if the input or the configuration is invalid.
+	ExitCodeValidation = 3
+	// ExitCodeNotFound is used if a resource does not exist.
+	ExitCodeNotFound = 4
+	// ExitCodeConflict is used if a resource already exists or was modified concurrently.
+	ExitCodeConflict = 5
+	// ExitCodeConnectivity is used if a server could not be reached.
+	ExitCodeConnectivity = 6
+)
+
+type (
+	errorKind struct {
+		code int
+		name string
+		hint string
+	}
+
+	// errorOutput is the JSON representation of an error.
+	errorOutput struct {
+		Error errorOutputBody `json:"error"`
+	}
+	errorOutputBody struct {
+		Code      int      `json:"code"`
+		Type      string   `json:"type"`
+		Message   string   `json:"message"`
+		Details   []string `json:"details,omitempty"`
+		Hint      string   `json:"hint,omitempty"`
+		RequestID string   `json:"request_id,omitempty"`
+	}
+
+	// usageError marks errors caused by invalid flags.
+	usageError struct {
+		error
+	}
+)
+
+var (
+	errorKindGeneric = errorKind{
+		code: ExitCodeError,
+		name: "error",
+	}
+	errorKindUsage = errorKind{
+		code: ExitCodeUsage,
+		name: "usage",
+		hint: "Run the command with --help to see its usage.",
+	}
+	errorKindValidation = errorKind{
+		code: ExitCodeValidation,
+		name: "validation",
+		hint: "Fix the invalid values and try again.",
+	}
+	errorKindNotFound = errorKind{
+		code: ExitCodeNotFound,
+		name: "not_found",
+		hint: "Check that the ID is correct and that the resource was not deleted.",
+	}
+	errorKindConflict = errorKind{
+		code: ExitCodeConflict,
+		name: "conflict",
+		hint: "The resource already exists or was changed in the meantime. Fetch its current state and try again.",
+	}
+	errorKindConnectivity = errorKind{
+		code: ExitCodeConnectivity,
+		name: "connectivity",
+		hint: "Check that the server is running and that the endpoint is reachable from this machine.",
+	}
+)
+
+// classifyError maps errors to their kind. Validation errors are JSON Schema validation errors, e.g. of
+// the configuration, or errors with status code 400 or 422. Not-found and conflict errors are errors with
+// status code 404 and 409, e.g. herodot errors returned by an API client. Connectivity errors are network
+// errors such as refused connections, failed DNS lookups, and timeouts.
+func classifyError(err error) errorKind {
+	if e := new(usageError); errors.As(err, &e) {
+		return errorKindUsage
+	}
+
+	if e := new(jsonschema.ValidationError); errors.As(err, &e) {
+		return errorKindValidation
+	}
+
+	if c := errorsx.StatusCodeCarrier(nil); errors.As(err, &c) {
+		switch c.StatusCode() {
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			return errorKindValidation
+		case http.StatusNotFound:
+			return errorKindNotFound
+		case http.StatusConflict:
+			return errorKindConflict
+		}
+	}
+
+	if e := net.Error(nil); errors.As(err, &e) {
+		return errorKindConnectivity
+	}
+
+	return errorKindGeneric
+}
+
+// ExitCode returns the exit code for the error, see the ExitCode constants.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return classifyError(err).code
+}
+
+func (e *usageError) Unwrap() error {
+	return e.error
+}
+
+// errorDetails returns the invalid values of validation errors and the reason and details of API errors.
+func errorDetails(err error) []string {
+	var details []string
+	if c := errorsx.ReasonCarrier(nil); errors.As(err, &c) && c.Reason() != "" {
+		details = append(details, c.Reason())
+	}
+	if c := errorsx.DetailsCarrier(nil); errors.As(err, &c) && len(c.Details()) > 0 {
+		keys := make([]string, 0, len(c.Details()))
+		for k := range c.Details() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			details = append(details, fmt.Sprintf("%s: %v", k, c.Details()[k]))
+		}
+	}
+	if e := new(jsonschema.ValidationError); errors.As(err, &e) {
+		details = append(details, validationErrorDetails(e)...)
+	}
+	return details
+}
+
+func validationErrorDetails(e *jsonschema.ValidationError) []string {
+	if len(e.Causes) > 0 {
+		var details []string
+		for _, cause := range e.Causes {
+			details = append(details, validationErrorDetails(cause)...)
+		}
+		return details
+	}
+
+	pointer, message := jsonschemax.FormatError(e)
+	if pointer == "#" || pointer == "" {
+		pointer = "(root)"
+	}
+	return []string{fmt.Sprintf("%s: %s", pointer, message)}
+}
+
+// PrintErr prints the error and a hint on how to resolve it to cmd.ErrOrStderr. If the --format flag is
+// set to a JSON format, the error is printed as JSON object instead, so that scripts can parse it:
+//
+//	{"error":{"code":4,"type":"not_found","message":"...","details":["..."],"hint":"...","request_id":"..."}}
+//
+// The code is the exit code of the error, the request ID is set for errors returned by an API.
+// Errors wrapping ErrNoPrintButFail are not printed, as they were already reported.
+func PrintErr(cmd *cobra.Command, err error) {
+	if err == nil || errors.Is(err, ErrNoPrintButFail) {
+		return
+	}
+
+	switch f := getFormatIfRegistered(cmd); f {
+	case FormatJSON, FormatJSONPretty:
+		printJSON(cmd.ErrOrStderr(), newErrorOutput(err), f == FormatJSONPretty)
+	default:
+		printHumanError(cmd.ErrOrStderr(), err)
+	}
+}
+
+// Fatal prints the error and a hint on how to resolve it to os.Stderr and exits with the exit code of the
+// error. Use PrintErr and ExitCode to respect the --format flag.
+func Fatal(err error) {
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, ErrNoPrintButFail) {
+		printHumanError(os.Stderr, err)
+	}
+	os.Exit(ExitCode(err))
+}
+
+func newErrorOutput(err error) *errorOutput {
+	kind := classifyError(err)
+	return &errorOutput{Error: errorOutputBody{
+		Code:      kind.code,
+		Type:      kind.name,
+		Message:   errorMessage(err),
+		Details:   errorDetails(err),
+		Hint:      kind.hint,
+		RequestID: errorRequestID(err),
+	}}
+}
+
+func errorRequestID(err error) string {
+	if c := errorsx.RequestIDCarrier(nil); errors.As(err, &c) {
+		return c.RequestID()
+	}
+	return ""
+}
+
+// errorMessage returns the message of the error. The message of JSON Schema validation errors spans multiple
+// lines, so it is replaced by a summary, and the invalid values are listed in the details instead.
+func errorMessage(err error) string {
+	if e := new(jsonschema.ValidationError); errors.As(err, &e) {
+		return strings.Replace(err.Error(), e.Error(), "the input contains invalid values or keys", 1)
+	}
+	return err.Error()
+}
+
+func printHumanError(w io.Writer, err error) {
+	out := newErrorOutput(err).Error
+	_, _ = fmt.Fprintf(w, "Error: %s\n", out.Message)
+	if len(out.Details) > 0 {
+		_, _ = fmt.Fprintf(w, "\n  %s\n", strings.Join(out.Details, "\n  "))
+	}
+	if out.RequestID != "" {
+		_, _ = fmt.Fprintf(w, "\nRequest ID: %s\n", out.RequestID)
+	}
+	if out.Hint != "" {
+		_, _ = fmt.Fprintf(w, "\nHint: %s\n", out.Hint)
+	}
+}
+
+// Execute executes the root command and returns the exit code, which is 0 on success:
+//
+//	func main() {
+//		os.Exit(cmdx.Execute(cmd.NewRootCmd()))
+//	}
+//
+// Failures are printed using PrintErr instead of cobra's error output, so they are printed as JSON if the
+// failing command's --format flag is set to a JSON format. The usage is only printed in the default
+// format and only if flags could not be parsed.
+func Execute(root *cobra.Command) int {
+	silenceUsage := root.SilenceUsage
+	root.SilenceErrors, root.SilenceUsage = true, true
+
+	flagErrorFunc := root.FlagErrorFunc()
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		if err := flagErrorFunc(cmd, err); err != nil {
+			return &usageError{error: err}
+		}
+		return nil
+	})
+
+	cmd, err := root.ExecuteC()
+	if err == nil {
+		return 0
+	}
+
+	PrintErr(cmd, err)
+	if e := new(usageError); errors.As(err, &e) && !silenceUsage && !cmd.SilenceUsage {
+		if f := getFormatIfRegistered(cmd); f != FormatJSON && f != FormatJSONPretty {
+			cmd.PrintErrln()
+			cmd.PrintErr(cmd.UsageString())
+		}
+	}
+	return ExitCode(err)
+}
+
+// getFormatIfRegistered is like getFormat but returns FormatDefault if the format flag is not registered.
+func getFormatIfRegistered(cmd *cobra.Command) format {
+	if cmd.Flags().Lookup(FlagFormat) == nil {
+		return FormatDefault
+	}
+	return getFormat(cmd)
+}
@@ -0,0 +1,223 @@
+package cmdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+	"github.com/ory/jsonschema/v3"
+)
+
+func validationError(t *testing.T) error {
+	c := jsonschema.NewCompiler()
+	require.NoError(t, c.AddResource("schema.json", strings.NewReader(`{
+  "type": "object",
+  "properties": {
+    "serve": {
+      "type": "object",
+      "properties": {"port": {"type": "integer"}}
+    }
+  },
+  "required": ["dsn"]
+}`)))
+	s, err := c.Compile(context.Background(), "schema.json")
+	require.NoError(t, err)
+
+	err = s.Validate(strings.NewReader(`{"serve": {"port": "foo"}}`))
+	require.Error(t, err)
+	return errors.Wrap(err, "unable to load the configuration")
+}
+
+func TestPrintErr(t *testing.T) {
+	_, connErr := (&net.Dialer{}).DialContext(context.Background(), "tcp", "127.0.0.1:0")
+	require.Error(t, connErr)
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		code     int
+		kind     string
+		details  []string
+		contains []string
+	}{
+		{
+			name: "generic",
+			err:  errors.New("something went wrong"),
+			code: ExitCodeError,
+			kind: "error",
+		},
+		{
+			name:    "not found",
+			err:     errors.WithStack(herodot.ErrNotFound.WithReason("The identity does not exist.")),
+			code:    ExitCodeNotFound,
+			kind:    "not_found",
+			details: []string{"The identity does not exist."},
+		},
+		{
+			name: "conflict",
+			err:  errors.WithStack(herodot.ErrConflict),
+			code: ExitCodeConflict,
+			kind: "conflict",
+		},
+		{
+			name: "bad request",
+			err:  errors.WithStack(herodot.ErrBadRequest),
+			code: ExitCodeValidation,
+			kind: "validation",
+		},
+		{
+			name: "connectivity",
+			err:  errors.Wrap(connErr, "unable to reach the server"),
+			code: ExitCodeConnectivity,
+			kind: "connectivity",
+		},
+		{
+			name:    "configuration validation",
+			err:     validationError(t),
+			code:    ExitCodeValidation,
+			kind:    "validation",
+			details: []string{"dsn: one or more required properties are missing", "serve.port: expected integer, but got string"},
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.code, ExitCode(tc.err))
+
+			t.Run("format=human", func(t *testing.T) {
+				cmd := &cobra.Command{Use: "x"}
+				RegisterFormatFlags(cmd.Flags())
+				stderr := new(bytes.Buffer)
+				cmd.SetErr(stderr)
+
+				PrintErr(cmd, tc.err)
+
+				assert.True(t, strings.HasPrefix(stderr.String(), "Error: "), stderr.String())
+				for _, d := range tc.details {
+					assert.Contains(t, stderr.String(), "\n  "+d+"\n")
+				}
+				if tc.code != ExitCodeError {
+					assert.Contains(t, stderr.String(), "\nHint: ")
+				}
+			})
+
+			t.Run("format=json", func(t *testing.T) {
+				cmd := &cobra.Command{Use: "x"}
+				RegisterFormatFlags(cmd.Flags())
+				require.NoError(t, cmd.Flags().Parse([]string{"--" + FlagFormat, string(FormatJSON)}))
+				stderr := new(bytes.Buffer)
+				cmd.SetErr(stderr)
+
+				PrintErr(cmd, tc.err)
+
+				var out errorOutput
+				require.NoError(t, json.Unmarshal(stderr.Bytes(), &out), stderr.String())
+				assert.Equal(t, tc.code, out.Error.Code)
+				assert.Equal(t, tc.kind, out.Error.Type)
+				assert.NotContains(t, out.Error.Message, "\n")
+				assert.ElementsMatch(t, tc.details, out.Error.Details)
+			})
+		})
+	}
+
+	t.Run("case=does not print ErrNoPrintButFail", func(t *testing.T) {
+		cmd := &cobra.Command{Use: "x"}
+		stderr := new(bytes.Buffer)
+		cmd.SetErr(stderr)
+
+		PrintErr(cmd, FailSilently(cmd))
+
+		assert.Empty(t, stderr.String())
+		assert.Equal(t, ExitCodeError, ExitCode(ErrNoPrintButFail))
+	})
+
+	t.Run("case=status code without kind", func(t *testing.T) {
+		assert.Equal(t, ExitCodeError, ExitCode(errors.WithStack(herodot.ErrInternalServerError)))
+	})
+}
+
+func TestExecute(t *testing.T) {
+	newRoot := func(err error) (*cobra.Command, *bytes.Buffer, *bytes.Buffer) {
+		root := &cobra.Command{Use: "x"}
+		cmd := &cobra.Command{
+			Use: "get",
+			RunE: func(*cobra.Command, []string) error {
+				return err
+			},
+		}
+		RegisterFormatFlags(cmd.Flags())
+		root.AddCommand(cmd)
+
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		root.SetOut(stdout)
+		root.SetErr(stderr)
+		return root, stdout, stderr
+	}
+
+	apiErr := herodot.ErrNotFound.WithReason("The identity does not exist.").WithDetail("id", "foo")
+	apiErr.RIDField = "request-id"
+
+	t.Run("case=success", func(t *testing.T) {
+		root, _, stderr := newRoot(nil)
+		root.SetArgs([]string{"get"})
+
+		assert.Equal(t, 0, Execute(root))
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("case=prints JSON failure", func(t *testing.T) {
+		root, stdout, stderr := newRoot(errors.WithStack(apiErr))
+		root.SetArgs([]string{"get", "--" + FlagFormat, string(FormatJSON)})
+
+		assert.Equal(t, ExitCodeNotFound, Execute(root))
+		assert.Empty(t, stdout.String())
+
+		var out errorOutput
+		require.NoError(t, json.Unmarshal(stderr.Bytes(), &out), stderr.String())
+		assert.Equal(t, errorOutputBody{
+			Code:      ExitCodeNotFound,
+			Type:      "not_found",
+			Message:   apiErr.Error(),
+			Details:   []string{"The identity does not exist.", "id: foo"},
+			Hint:      errorKindNotFound.hint,
+			RequestID: "request-id",
+		}, out.Error)
+	})
+
+	t.Run("case=prints human failure", func(t *testing.T) {
+		root, _, stderr := newRoot(errors.WithStack(apiErr))
+		root.SetArgs([]string{"get"})
+
+		assert.Equal(t, ExitCodeNotFound, Execute(root))
+		assert.Contains(t, stderr.String(), "Request ID: request-id")
+		assert.NotContains(t, stderr.String(), "Usage:")
+	})
+
+	t.Run("case=prints usage on flag errors", func(t *testing.T) {
+		root, _, stderr := newRoot(nil)
+		root.SetArgs([]string{"get", "--unknown"})
+
+		assert.Equal(t, ExitCodeUsage, Execute(root))
+		assert.True(t, strings.HasPrefix(stderr.String(), "Error: unknown flag: --unknown\n"), stderr.String())
+		assert.Contains(t, stderr.String(), "Usage:")
+	})
+
+	t.Run("case=prints flag errors as JSON", func(t *testing.T) {
+		root, _, stderr := newRoot(nil)
+		root.SetArgs([]string{"get", "--" + FlagFormat, string(FormatJSON), "--unknown"})
+
+		assert.Equal(t, ExitCodeUsage, Execute(root))
+
+		var out errorOutput
+		require.NoError(t, json.Unmarshal(stderr.Bytes(), &out), stderr.String())
+		assert.Equal(t, "usage", out.Error.Type)
+		assert.Equal(t, "unknown flag: --unknown", out.Error.Message)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -28,6 +29,22 @@ type (
 	}
 	Nil struct{}
 
+	// Printer prints the results of a command in the output format selected by the --format and --quiet
+	// flags, so that all commands built on this package produce consistent and scriptable output.
+	// Register the flags using RegisterFormatFlags or RegisterJSONFormatFlags.
+	Printer interface {
+		// PrintRow prints a single resource, as key-value table or as document.
+		PrintRow(row TableRow)
+		// PrintTable prints a list of resources.
+		PrintTable(table Table)
+		// PrintJSONAble prints a value which has no tabular representation.
+		PrintJSONAble(d interface{ String() string })
+	}
+
+	cmdPrinter struct {
+		cmd *cobra.Command
+	}
+
 	format string
 )
 
@@ -36,6 +53,7 @@ const (
 	FormatTable      format = "table"
 	FormatJSON       format = "json"
 	FormatJSONPretty format = "json-pretty"
+	FormatYAML       format = "yaml"
 	FormatDefault    format = "default"
 
 	FlagFormat = "format"
@@ -51,6 +69,23 @@ func (Nil) Interface() interface{} {
 	return nil
 }
 
+// NewPrinter returns a Printer writing to cmd.OutOrStdout in the format selected by the flags of cmd.
+func NewPrinter(cmd *cobra.Command) Printer {
+	return &cmdPrinter{cmd: cmd}
+}
+
+func (p *cmdPrinter) PrintRow(row TableRow) {
+	PrintRow(p.cmd, row)
+}
+
+func (p *cmdPrinter) PrintTable(table Table) {
+	PrintTable(p.cmd, table)
+}
+
+func (p *cmdPrinter) PrintJSONAble(d interface{ String() string }) {
+	PrintJSONAble(p.cmd, d)
+}
+
 func PrintErrors(cmd *cobra.Command, errs map[string]error) {
 	for src, err := range errs {
 		fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", src, err.Error())
@@ -71,6 +106,8 @@ func PrintRow(cmd *cobra.Command, row TableRow) {
 		printJSON(cmd.OutOrStdout(), row.Interface(), false)
 	case FormatJSONPretty:
 		printJSON(cmd.OutOrStdout(), row.Interface(), true)
+	case FormatYAML:
+		printYAML(cmd.OutOrStdout(), row.Interface())
 	case FormatTable, FormatDefault:
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 1, '\t', 0)
 
@@ -106,6 +143,8 @@ func PrintTable(cmd *cobra.Command, table Table) {
 		printJSON(cmd.OutOrStdout(), table.Interface(), false)
 	case FormatJSONPretty:
 		printJSON(cmd.OutOrStdout(), table.Interface(), true)
+	case FormatYAML:
+		printYAML(cmd.OutOrStdout(), table.Interface())
 	default:
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 1, '\t', 0)
 
@@ -141,6 +180,12 @@ func PrintJSONAble(cmd *cobra.Command, d interface{ String() string }) {
 			v = i
 		}
 		printJSON(cmd.OutOrStdout(), v, true)
+	case FormatYAML:
+		var v interface{} = d
+		if i, ok := d.(interface{ Interface() interface{} }); ok {
+			v = i.Interface()
+		}
+		printYAML(cmd.OutOrStdout(), v)
 	}
 }
 
@@ -171,6 +216,8 @@ func getFormat(cmd *cobra.Command) format {
 		return FormatJSON
 	case string(FormatJSONPretty):
 		return FormatJSONPretty
+	case string(FormatYAML):
+		return FormatYAML
 	default:
 		return FormatDefault
 	}
@@ -186,11 +233,18 @@ func printJSON(w io.Writer, v interface{}, pretty bool) {
 	Must(err, "Error encoding JSON: %s", err)
 }
 
+func printYAML(w io.Writer, v interface{}) {
+	out, err := yaml.Marshal(v)
+	// unexpected error
+	Must(err, "Error encoding YAML: %s", err)
+	_, _ = w.Write(out)
+}
+
 func RegisterJSONFormatFlags(flags *pflag.FlagSet) {
-	flags.StringP(FlagFormat, FlagFormat[:1], string(FormatDefault), fmt.Sprintf("Set the output format. One of %s, %s, and %s.", FormatDefault, FormatJSON, FormatJSONPretty))
+	flags.StringP(FlagFormat, FlagFormat[:1], string(FormatDefault), fmt.Sprintf("Set the output format. One of %s, %s, %s, and %s.", FormatDefault, FormatJSON, FormatJSONPretty, FormatYAML))
 }
 
 func RegisterFormatFlags(flags *pflag.FlagSet) {
 	RegisterNoiseFlags(flags)
-	flags.StringP(FlagFormat, FlagFormat[:1], string(FormatDefault), fmt.Sprintf("Set the output format. One of %s, %s, and %s.", FormatTable, FormatJSON, FormatJSONPretty))
+	flags.StringP(FlagFormat, FlagFormat[:1], string(FormatDefault), fmt.Sprintf("Set the output format. One of %s, %s, %s, and %s.", FormatTable, FormatJSON, FormatJSONPretty, FormatYAML))
 }
@@ -5,9 +5,49 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+const (
+	FlagYes = "yes"
 )
 
+// ErrNoTerminal is returned by the prompts if they need an answer from the user but stdin is not a terminal.
+var ErrNoTerminal = errors.New("unable to prompt for input because stdin is not a terminal")
+
+// isTerminal returns true if r is a character device, such as a terminal. It is a variable so tests can
+// simulate terminals.
+var isTerminal = func(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// RegisterYesFlag registers the --yes flag which confirms all prompts of Confirm. Register it on commands
+// which ask for confirmation, so that they can be used in scripts.
+func RegisterYesFlag(flags *pflag.FlagSet) {
+	flags.BoolP(FlagYes, FlagYes[:1], false, "Confirm all prompts. Required to run this command non-interactively.")
+}
+
+func getYes(cmd *cobra.Command) bool {
+	yes, err := cmd.Flags().GetBool(FlagYes)
+	// ignore the error here as the flag might not be registered
+	if err != nil {
+		return false
+	}
+	return yes
+}
+
 // asks for confirmation with the question string s and reads the answer
 // pass nil to use os.Stdin and os.Stdout
 func AskForConfirmation(s string, stdin io.Reader, stdout io.Writer) bool {
@@ -18,20 +58,135 @@ func AskForConfirmation(s string, stdin io.Reader, stdout io.Writer) bool {
 		stdout = os.Stdout
 	}
 
-	reader := bufio.NewReader(stdin)
+	ok, err := askForConfirmation(s, bufio.NewReader(stdin), stdout)
+	Must(err, "%s", err)
+	return ok
+}
 
+func askForConfirmation(s string, reader *bufio.Reader, stdout io.Writer) (bool, error) {
 	for {
-		_, err := fmt.Fprintf(stdout, "%s [y/n]: ", s)
-		Must(err, "%s", err)
+		if _, err := fmt.Fprintf(stdout, "%s [y/n]: ", s); err != nil {
+			return false, errors.WithStack(err)
+		}
 
-		response, err := reader.ReadString('\n')
-		Must(err, "%s", err)
+		response, err := readLine(reader)
+		if err != nil {
+			return false, err
+		}
 
 		response = strings.ToLower(strings.TrimSpace(response))
 		if response == "y" || response == "yes" {
-			return true
+			return true, nil
 		} else if response == "n" || response == "no" {
-			return false
+			return false, nil
 		}
 	}
 }
+
+// Confirm asks the user to confirm the question, e.g. before a destructive operation:
+//
+//	if ok, err := cmdx.Confirm(cmd, "Do you want to delete all identities?"); err != nil {
+//		return err
+//	} else if !ok {
+//		return cmdx.FailSilently(cmd)
+//	}
+//
+// It returns true without asking if the --yes flag is set, and ErrNoTerminal if stdin is not a terminal,
+// so that scripts have to pass --yes explicitly. The prompt is written to stderr to keep stdout scriptable.
+func Confirm(cmd *cobra.Command, question string) (bool, error) {
+	if getYes(cmd) {
+		return true, nil
+	}
+	if !isTerminal(cmd.InOrStdin()) {
+		return false, errors.Wrapf(ErrNoTerminal, "pass --%s to confirm", FlagYes)
+	}
+	return askForConfirmation(question, bufio.NewReader(cmd.InOrStdin()), cmd.ErrOrStderr())
+}
+
+// Select asks the user to choose one of the options and returns its index. It asks again until the user
+// enters a valid number, and returns ErrNoTerminal if stdin is not a terminal.
+func Select(cmd *cobra.Command, question string, options []string) (int, error) {
+	if len(options) == 0 {
+		return 0, errors.New("unable to prompt for a selection without options")
+	}
+	if !isTerminal(cmd.InOrStdin()) {
+		return 0, errors.WithStack(ErrNoTerminal)
+	}
+
+	reader, out := bufio.NewReader(cmd.InOrStdin()), cmd.ErrOrStderr()
+	for {
+		if _, err := fmt.Fprintln(out, question); err != nil {
+			return 0, errors.WithStack(err)
+		}
+		for i, o := range options {
+			_, _ = fmt.Fprintf(out, "  %d) %s\n", i+1, o)
+		}
+		_, _ = fmt.Fprintf(out, "Enter a number [1-%d]: ", len(options))
+
+		response, err := readLine(reader)
+		if err != nil {
+			return 0, err
+		}
+
+		if i, err := strconv.Atoi(strings.TrimSpace(response)); err == nil && i >= 1 && i <= len(options) {
+			return i - 1, nil
+		}
+	}
+}
+
+// AskForSecret asks the user for a secret, e.g. a password, without echoing the input on the terminal.
+//
+// If stdin is not a terminal, the first line of stdin is read instead, so that secrets can be piped
+// into the command.
+func AskForSecret(cmd *cobra.Command, question string) (string, error) {
+	stdin, out := cmd.InOrStdin(), cmd.ErrOrStderr()
+	if _, err := fmt.Fprintf(out, "%s: ", question); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	f, ok := stdin.(*os.File)
+	if !ok || !isTerminal(stdin) {
+		return readLine(bufio.NewReader(stdin))
+	}
+
+	fd := int(f.Fd())
+	state, err := term.GetState(fd)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to configure the terminal")
+	}
+
+	// Restore the echo if the user aborts, otherwise the terminal stays unusable.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer func() {
+		signal.Stop(interrupt)
+		close(interrupt)
+	}()
+	go func() {
+		if _, ok := <-interrupt; ok {
+			_ = term.Restore(fd, state)
+			_, _ = fmt.Fprintln(out)
+			os.Exit(130)
+		}
+	}()
+
+	secret, err := term.ReadPassword(fd)
+	// The newline typed by the user was not echoed.
+	_, _ = fmt.Fprintln(out)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to read the answer")
+	}
+	return string(secret), nil
+}
+
+// readLine reads a line without the line break. The last line does not need to end with a line break.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "unable to read the answer")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
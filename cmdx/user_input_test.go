@@ -2,9 +2,13 @@ package cmdx
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
+	"strings"
 	"testing"
 
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -76,3 +80,104 @@ func TestAskForConfirmation(t *testing.T) {
 		}
 	})
 }
+
+func newPromptCmd(t *testing.T, stdin string, terminal bool) (*cobra.Command, *bytes.Buffer) {
+	original := isTerminal
+	isTerminal = func(io.Reader) bool { return terminal }
+	t.Cleanup(func() { isTerminal = original })
+
+	cmd := &cobra.Command{Use: "x"}
+	RegisterYesFlag(cmd.Flags())
+	stderr := new(bytes.Buffer)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetErr(stderr)
+	return cmd, stderr
+}
+
+func TestConfirm(t *testing.T) {
+	t.Run("case=asks on terminal", func(t *testing.T) {
+		cmd, stderr := newPromptCmd(t, "foo\nyes\n", true)
+
+		ok, err := Confirm(cmd, "Delete?")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 2, strings.Count(stderr.String(), "Delete? [y/n]: "))
+	})
+
+	t.Run("case=rejects on terminal", func(t *testing.T) {
+		cmd, _ := newPromptCmd(t, "n\n", true)
+
+		ok, err := Confirm(cmd, "Delete?")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("case=fails without terminal", func(t *testing.T) {
+		cmd, _ := newPromptCmd(t, "y\n", false)
+
+		_, err := Confirm(cmd, "Delete?")
+		assert.True(t, errors.Is(err, ErrNoTerminal), "%+v", err)
+	})
+
+	t.Run("case=fails on end of input", func(t *testing.T) {
+		cmd, _ := newPromptCmd(t, "", true)
+
+		_, err := Confirm(cmd, "Delete?")
+		assert.True(t, errors.Is(err, io.EOF), "%+v", err)
+	})
+
+	t.Run("case=yes flag skips the prompt", func(t *testing.T) {
+		cmd, stderr := newPromptCmd(t, "", false)
+		require.NoError(t, cmd.Flags().Parse([]string{"--" + FlagYes}))
+
+		ok, err := Confirm(cmd, "Delete?")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, stderr.String())
+	})
+}
+
+func TestSelect(t *testing.T) {
+	t.Run("case=selects option", func(t *testing.T) {
+		cmd, stderr := newPromptCmd(t, "0\nfoo\n2\n", true)
+
+		i, err := Select(cmd, "Which one?", []string{"a", "b"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, i)
+		assert.Equal(t, 3, strings.Count(stderr.String(), "Which one?"))
+		assert.Contains(t, stderr.String(), "  2) b\n")
+	})
+
+	t.Run("case=fails without terminal", func(t *testing.T) {
+		cmd, _ := newPromptCmd(t, "1\n", false)
+
+		_, err := Select(cmd, "Which one?", []string{"a"})
+		assert.True(t, errors.Is(err, ErrNoTerminal), "%+v", err)
+	})
+
+	t.Run("case=fails without options", func(t *testing.T) {
+		cmd, _ := newPromptCmd(t, "1\n", true)
+
+		_, err := Select(cmd, "Which one?", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestAskForSecret(t *testing.T) {
+	t.Run("case=reads piped secret", func(t *testing.T) {
+		cmd, stderr := newPromptCmd(t, "s3cr3t\r\nignored\n", false)
+
+		secret, err := AskForSecret(cmd, "Password")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cr3t", secret)
+		assert.Equal(t, "Password: ", stderr.String())
+	})
+
+	t.Run("case=reads secret without line break", func(t *testing.T) {
+		cmd, _ := newPromptCmd(t, "s3cr3t", false)
+
+		secret, err := AskForSecret(cmd, "Password")
+		require.NoError(t, err)
+		assert.Equal(t, "s3cr3t", secret)
+	})
+}
@@ -0,0 +1,173 @@
+package configx
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/knadh/koanf/maps"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ory/x/cmdx"
+)
+
+type (
+	validationResult struct {
+		Valid bool `json:"valid"`
+	}
+	configDump map[string]interface{}
+)
+
+func (validationResult) String() string {
+	return "The configuration is valid.\n"
+}
+
+func (d configDump) String() string {
+	out, err := yaml.Marshal(map[string]interface{}(d))
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// NewConfigCmd returns the `config` command with the `validate` and `dump` subcommands.
+func NewConfigCmd(schema []byte, modifiers ...OptionModifier) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+	cmd.AddCommand(NewValidateCmd(schema, modifiers...), NewDumpCmd(schema, modifiers...))
+	return cmd
+}
+
+// NewValidateCmd returns the `validate` command which loads the configuration like the server does, from the
+// files passed using --config, the environment, and the options stored in the command's context using
+// ContextWithConfigOptions, and validates it against the JSON Schema. Validation errors are returned, so
+// cmdx.Execute prints them using cmdx.PrintErr, as JSON if --format is set to a JSON format, and exits with
+// cmdx.ExitCodeValidation.
+func NewValidateCmd(schema []byte, modifiers ...OptionModifier) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the configuration",
+		Long: `Validate the configuration files and environment variables against the configuration schema.
+
+The configuration is loaded exactly like the server loads it. The command fails if the configuration is invalid.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if _, err := newProviderForCmd(cmd, schema, modifiers); err != nil {
+				return err
+			}
+
+			cmdx.PrintJSONAble(cmd, validationResult{Valid: true})
+			return nil
+		},
+	}
+	RegisterFlags(cmd.Flags())
+	cmdx.RegisterJSONFormatFlags(cmd.Flags())
+	return cmd
+}
+
+// NewDumpCmd returns the `dump` command which loads and validates the configuration like NewValidateCmd and
+// prints the effective configuration, including the defaults of the JSON Schema. Values of keys containing
+// `dsn`, `secret`, `password`, or `key`, or the keys set using OmitKeysFromTracing, are redacted.
+func NewDumpCmd(schema []byte, modifiers ...OptionModifier) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Print the effective configuration",
+		Long: `Print the effective configuration after merging the defaults, configuration files, and environment variables.
+
+Secrets are redacted. The configuration is printed as YAML unless another format is selected.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			p, err := newProviderForCmd(cmd, schema, modifiers)
+			if err != nil {
+				return err
+			}
+
+			cmdx.PrintJSONAble(cmd, p.redactedDump())
+			return nil
+		},
+	}
+	RegisterFlags(cmd.Flags())
+	cmdx.RegisterJSONFormatFlags(cmd.Flags())
+	return cmd
+}
+
+func newProviderForCmd(cmd *cobra.Command, schema []byte, modifiers []OptionModifier) (*Provider, error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// The output and help flags are not part of the configuration.
+	flags := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name != cmdx.FlagFormat && f.Name != "help" {
+			flags.AddFlag(f)
+		}
+	})
+
+	// WithContext applies the options stored in the context.
+	p, err := New(ctx, schema, append([]OptionModifier{WithContext(ctx), WithFlags(flags)}, modifiers...)...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to load the configuration")
+	}
+	return p, nil
+}
+
+func (p *Provider) redactedDump() configDump {
+	dump, _ := p.redact("", maps.Unflatten(p.All(), Delimiter)).(map[string]interface{})
+	return dump
+}
+
+// redact replaces the values of all keys matched by isRedacted with a placeholder. It walks the
+// whole value, so secrets in objects nested in arrays are redacted as well. The key is the full path
+// of the value, e.g. `providers.0.client_secret`, like the keys checked when tracing the configuration.
+func (p *Provider) redact(key string, value interface{}) interface{} {
+	if key != "" && p.isRedacted(key) {
+		return "[redacted]"
+	}
+
+	child := func(k string) string {
+		if key == "" {
+			return k
+		}
+		return key + Delimiter + k
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			redacted[k] = p.redact(child(k), e)
+		}
+		return redacted
+	case map[interface{}]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			redacted[fmt.Sprintf("%v", k)] = p.redact(child(fmt.Sprintf("%v", k)), e)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for k, e := range v {
+			redacted[k] = p.redact(child(strconv.Itoa(k)), e)
+		}
+		return redacted
+	}
+	return value
+}
+
+// isRedacted returns true if the value of the key must not be logged or printed.
+func (p *Provider) isRedacted(key string) bool {
+	for _, e := range p.excludeFieldsFromTracing {
+		if strings.Contains(key, e) {
+			return true
+		}
+	}
+	return false
+}
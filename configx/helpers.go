@@ -12,9 +12,9 @@ func RegisterFlags(flags *pflag.FlagSet) {
 	flags.StringSliceP("config", "c", []string{}, "Path to one or more .json, .yaml, .yml, .toml config files. Values are loaded in the order provided, meaning that the last config file overwrites values from the previous config file.")
 }
 
-// host = unix:/path/to/socket => port is discarded, otherwise format as host:port
+// host = unix:/path/to/socket or systemd:<name> => port is discarded, otherwise format as host:port
 func GetAddress(host string, port int) string {
-	if strings.HasPrefix(host, "unix:") {
+	if strings.HasPrefix(host, "unix:") || strings.HasPrefix(host, "systemd:") {
 		return host
 	}
 	return fmt.Sprintf("%s:%d", host, port)
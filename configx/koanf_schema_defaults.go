@@ -11,7 +11,8 @@ import (
 )
 
 type KoanfSchemaDefaults struct {
-	keys []jsonschemax.Path
+	keys   []jsonschemax.Path
+	schema *jsonschema.Schema
 }
 
 func NewKoanfSchemaDefaults(rawSchema []byte, schema *jsonschema.Schema) (*KoanfSchemaDefaults, error) {
@@ -20,7 +21,7 @@ func NewKoanfSchemaDefaults(rawSchema []byte, schema *jsonschema.Schema) (*Koanf
 		return nil, err
 	}
 
-	return &KoanfSchemaDefaults{keys: keys}, nil
+	return &KoanfSchemaDefaults{keys: keys, schema: schema}, nil
 }
 
 func (k *KoanfSchemaDefaults) ReadBytes() ([]byte, error) {
@@ -42,3 +43,14 @@ func (k *KoanfSchemaDefaults) Read() (map[string]interface{}, error) {
 
 	return maps.Unflatten(values, "."), nil
 }
+
+// Apply returns the values with the defaults of array items and of pattern and additional properties
+// applied. Unlike the defaults returned by Read, these depend on the values, so they can only be applied
+// once all values are loaded.
+func (k *KoanfSchemaDefaults) Apply(values map[string]interface{}) (map[string]interface{}, error) {
+	withDefaults, ok := jsonschemax.ApplyDefaults(k.schema, values).(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("expected the configuration with defaults to be an object but got %T", withDefaults)
+	}
+	return withDefaults, nil
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/jsonschema/v3"
@@ -35,3 +36,27 @@ func TestKoanfSchemaDefaults(t *testing.T) {
 
 	snapshotx.SnapshotTExcept(t, k.All(), nil)
 }
+
+func TestKoanfSchemaDefaultsApply(t *testing.T) {
+	rawSchema := []byte(`{
+  "type": "object",
+  "properties": {
+    "providers": {
+      "type": "array",
+      "items": {"type": "object", "properties": {"scope": {"type": "string", "default": "openid"}}}
+    }
+  }
+}`)
+
+	schema, err := getSchema(context.Background(), rawSchema)
+	require.NoError(t, err)
+
+	def, err := NewKoanfSchemaDefaults(rawSchema, schema)
+	require.NoError(t, err)
+
+	actual, err := def.Apply(map[string]interface{}{"providers": []interface{}{map[string]interface{}{}}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"providers": []interface{}{map[string]interface{}{"scope": "openid"}},
+	}, actual)
+}
@@ -28,9 +28,13 @@ import (
 	"github.com/ory/x/watcherx"
 
 	"github.com/inhies/go-bytesize"
+	"github.com/knadh/koanf/providers/confmap"
 	"github.com/knadh/koanf/providers/posflag"
 	"github.com/spf13/pflag"
 
+	"github.com/ory/x/corsx"
+	"github.com/ory/x/httpx"
+	prometheus "github.com/ory/x/prometheusx"
 	"github.com/ory/x/stringsx"
 	"github.com/ory/x/tracing"
 
@@ -221,7 +225,12 @@ func (p *Provider) newKoanf() (*koanf.Koanf, error) {
 
 	k := koanf.New(Delimiter)
 
+	var defaults *KoanfSchemaDefaults
 	for _, provider := range p.providers {
+		if d, ok := provider.(*KoanfSchemaDefaults); ok {
+			defaults = d
+		}
+
 		// posflag.Posflag requires access to Koanf instance so we recreate the provider here which is a workaround
 		// for posflag.Provider's API.
 		if _, ok := provider.(*posflag.Posflag); ok {
@@ -238,6 +247,18 @@ func (p *Provider) newKoanf() (*koanf.Koanf, error) {
 		}
 	}
 
+	// The defaults of array elements and of pattern and additional properties depend on the loaded values, so
+	// they are applied last.
+	if defaults != nil {
+		withDefaults, err := defaults.Apply(k.Raw())
+		if err != nil {
+			return nil, err
+		}
+		if err := k.Load(confmap.Provider(withDefaults, ""), nil); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := p.validate(k); err != nil {
 		return nil, err
 	}
@@ -268,14 +289,7 @@ func (p *Provider) traceConfig(ctx context.Context, k *koanf.Koanf, opName strin
 
 	fields := make([]log.Field, 0, len(k.Keys()))
 	for _, key := range k.Keys() {
-		var redact bool
-		for _, e := range p.excludeFieldsFromTracing {
-			if strings.Contains(key, e) {
-				redact = true
-			}
-		}
-
-		if redact {
+		if p.isRedacted(key) {
 			fields = append(fields, log.Object(key, "[redacted]"))
 		} else {
 			fields = append(fields, log.Object(key, k.Get(key)))
@@ -285,9 +299,22 @@ func (p *Provider) traceConfig(ctx context.Context, k *koanf.Koanf, opName strin
 	span.LogFields(fields...)
 }
 
+// AddWatcher registers a function which is called whenever the configuration was reloaded or failed
+// to reload, like the watchers registered using AttachWatcher.
+func (p *Provider) AddWatcher(watcher func(event watcherx.Event, err error)) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.onChanges = append(p.onChanges, watcher)
+}
+
 func (p *Provider) runOnChanges(e watcherx.Event, err error) {
-	for k := range p.onChanges {
-		p.onChanges[k](e, err)
+	p.l.RLock()
+	onChanges := p.onChanges
+	p.l.RUnlock()
+
+	for k := range onChanges {
+		onChanges[k](e, err)
 	}
 }
 
@@ -468,6 +495,112 @@ func (p *Provider) CORS(prefix string, defaults cors.Options) (cors.Options, boo
 	}, p.Bool(prefix + "cors.enabled")
 }
 
+// CORSRoutes returns the CORS configuration at the given prefix as routes which can be passed to
+// corsx.NewRouteMiddleware. The first route has an empty path prefix and holds the configuration
+// returned by CORS, the following routes are read from `cors.routes` and inherit all settings they
+// do not set from the first route.
+func (p *Provider) CORSRoutes(prefix string, defaults cors.Options) []corsx.Route {
+	base, enabled := p.CORS(prefix, defaults)
+	if len(prefix) > 0 {
+		prefix = strings.TrimRight(prefix, ".") + "."
+	}
+
+	routes := []corsx.Route{{Enabled: enabled, Options: base}}
+	items, _ := p.GetF(prefix+"cors.routes", nil).([]interface{})
+	for _, item := range items {
+		values, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		k := koanf.New(Delimiter)
+		if err := k.Load(confmap.Provider(values, ""), nil); err != nil {
+			continue
+		}
+
+		opts := base
+		if k.Exists("allowed_origins") {
+			opts.AllowedOrigins = k.Strings("allowed_origins")
+		}
+		if k.Exists("allowed_methods") {
+			opts.AllowedMethods = k.Strings("allowed_methods")
+		}
+		if k.Exists("allowed_headers") {
+			opts.AllowedHeaders = k.Strings("allowed_headers")
+		}
+		if k.Exists("exposed_headers") {
+			opts.ExposedHeaders = k.Strings("exposed_headers")
+		}
+		if k.Exists("allow_credentials") {
+			opts.AllowCredentials = k.Bool("allow_credentials")
+		}
+		if k.Exists("options_passthrough") {
+			opts.OptionsPassthrough = k.Bool("options_passthrough")
+		}
+		if k.Exists("max_age") {
+			opts.MaxAge = k.Int("max_age")
+		}
+		if k.Exists("debug") {
+			opts.Debug = k.Bool("debug")
+		}
+
+		routeEnabled := enabled
+		if k.Exists("enabled") {
+			routeEnabled = k.Bool("enabled")
+		}
+		routes = append(routes, corsx.Route{PathPrefix: k.String("path_prefix"), Enabled: routeEnabled, Options: opts})
+	}
+	return routes
+}
+
+// CORSMiddleware returns a middleware applying the CORS routes at the given prefix, see CORSRoutes.
+// The routes are read again whenever the configuration is reloaded, so changes to the allowed origins
+// take effect without restarting the server.
+func (p *Provider) CORSMiddleware(prefix string, defaults cors.Options) *corsx.ReloadableMiddleware {
+	m := corsx.NewReloadableMiddleware(func() []corsx.Route {
+		return p.CORSRoutes(prefix, defaults)
+	})
+	p.AddWatcher(func(_ watcherx.Event, err error) {
+		if err == nil {
+			m.Reload()
+		}
+	})
+	return m
+}
+
+// RateLimit returns the rate limit configuration at the given prefix and whether rate limiting is
+// enabled.
+func (p *Provider) RateLimit(prefix string, defaults httpx.RateLimitConfig) (httpx.RateLimitConfig, bool) {
+	if len(prefix) > 0 {
+		prefix = strings.TrimRight(prefix, ".") + "."
+	}
+
+	return httpx.RateLimitConfig{
+		RequestsPerSecond: p.Float64F(prefix+"rate_limit.requests_per_second", defaults.RequestsPerSecond),
+		Burst:             p.IntF(prefix+"rate_limit.burst", defaults.Burst),
+	}, p.Bool(prefix + "rate_limit.enabled")
+}
+
+// PrometheusMetrics returns the configuration of the metrics endpoint at `<prefix>.metrics` and whether it is
+// enabled, see prometheusx.ConfigSchema.
+func (p *Provider) PrometheusMetrics(prefix string, defaults prometheus.Config) (prometheus.Config, bool) {
+	if len(prefix) > 0 {
+		prefix = strings.TrimRight(prefix, ".") + "."
+	}
+
+	c := prometheus.Config{
+		Path: p.StringF(prefix+"metrics.path", defaults.Path),
+		Auth: defaults.Auth,
+	}
+	if p.Exists(prefix + "metrics.auth") {
+		c.Auth = &prometheus.AuthConfig{
+			Username: p.String(prefix + "metrics.auth.username"),
+			Password: p.String(prefix + "metrics.auth.password"),
+		}
+	}
+	return c, p.Bool(prefix + "metrics.enabled")
+}
+
 func (p *Provider) TracingConfig(serviceName string) *tracing.Config {
 	return &tracing.Config{
 		ServiceName: p.StringF("tracing.service_name", serviceName),
@@ -11,8 +11,11 @@ import (
 
 	"github.com/knadh/koanf/parsers/json"
 
+	prometheus "github.com/ory/x/prometheusx"
 	"github.com/ory/x/urlx"
 
+	"github.com/rs/cors"
+
 	"github.com/spf13/pflag"
 
 	"github.com/stretchr/testify/assert"
@@ -207,3 +210,115 @@ func TestAdvancedConfigs(t *testing.T) {
 		})
 	}
 }
+
+func TestNestedDefaults(t *testing.T) {
+	schema := []byte(`{
+  "type": "object",
+  "properties": {
+    "providers": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "scope": {"type": "array", "items": {"type": "string"}, "default": ["openid"]}
+        }
+      }
+    },
+    "hooks": {
+      "type": "object",
+      "patternProperties": {
+        "^pre_": {"type": "object", "properties": {"timeout": {"type": "string", "default": "1s"}}}
+      },
+      "additionalProperties": {"type": "object", "properties": {"enabled": {"type": "boolean", "default": true}}}
+    }
+  }
+}`)
+
+	p, err := New(context.Background(), schema, WithValues(map[string]interface{}{
+		"providers": `[{"id":"google"},{"id":"github","scope":["email"]}]`,
+		"hooks":     `{"pre_login":{},"post_login":{"enabled":false},"post_logout":{}}`,
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "google", "scope": []interface{}{"openid"}},
+		map[string]interface{}{"id": "github", "scope": []interface{}{"email"}},
+	}, p.Get("providers"))
+	assert.Equal(t, "1s", p.String("hooks.pre_login.timeout"))
+	assert.False(t, p.Exists("hooks.pre_login.enabled"))
+	assert.False(t, p.Bool("hooks.post_login.enabled"))
+	assert.True(t, p.Bool("hooks.post_logout.enabled"))
+}
+
+func TestCORSRoutes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(config, []byte(`
+serve:
+  public:
+    cors:
+      enabled: true
+      allowed_origins:
+        - https://example.com
+      routes:
+        - path_prefix: /admin
+          allowed_origins:
+            - https://admin.example.com
+          max_age: 10
+        - path_prefix: /internal
+          enabled: false
+`), 0600))
+
+	p, err := New(ctx, []byte(`{}`), WithConfigFiles(config), WithContext(ctx))
+	require.NoError(t, err)
+
+	routes := p.CORSRoutes("serve.public", cors.Options{AllowedMethods: []string{"GET"}})
+	require.Len(t, routes, 3)
+
+	assert.Equal(t, "", routes[0].PathPrefix)
+	assert.True(t, routes[0].Enabled)
+	assert.Equal(t, []string{"https://example.com"}, routes[0].Options.AllowedOrigins)
+	assert.Equal(t, []string{"GET"}, routes[0].Options.AllowedMethods)
+
+	assert.Equal(t, "/admin", routes[1].PathPrefix)
+	assert.True(t, routes[1].Enabled)
+	assert.Equal(t, []string{"https://admin.example.com"}, routes[1].Options.AllowedOrigins)
+	assert.Equal(t, []string{"GET"}, routes[1].Options.AllowedMethods, "settings are inherited")
+	assert.Equal(t, 10, routes[1].Options.MaxAge)
+
+	assert.Equal(t, "/internal", routes[2].PathPrefix)
+	assert.False(t, routes[2].Enabled)
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(config, []byte(`
+serve:
+  admin:
+    metrics:
+      enabled: true
+      auth:
+        username: prometheus
+        password: secret
+`), 0600))
+
+	p, err := New(ctx, []byte(`{}`), WithConfigFiles(config), WithContext(ctx))
+	require.NoError(t, err)
+
+	c, enabled := p.PrometheusMetrics("serve.admin", prometheus.Config{Path: "/metrics/prometheus"})
+	assert.True(t, enabled)
+	assert.Equal(t, prometheus.Config{
+		Path: "/metrics/prometheus",
+		Auth: &prometheus.AuthConfig{Username: "prometheus", Password: "secret"},
+	}, c)
+
+	c, enabled = p.PrometheusMetrics("serve.public", prometheus.Config{})
+	assert.False(t, enabled)
+	assert.Equal(t, prometheus.Config{}, c)
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"runtime"
@@ -15,6 +17,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rs/cors"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
@@ -285,3 +288,51 @@ func TestReload(t *testing.T) {
 		assert.Equal(t, "new", dsn)
 	})
 }
+
+func TestCORSMiddlewareReload(t *testing.T) {
+	config := func(origin string) string {
+		return fmt.Sprintf("dsn: memory\ncors:\n  enabled: true\n  allowed_origins:\n    - %s\n", origin)
+	}
+
+	tdir := os.TempDir() + "/" + strconv.Itoa(time.Now().Nanosecond())
+	require.NoError(t, os.MkdirAll(tdir, os.ModePerm))
+	configFile, err := ioutil.TempFile(tdir, "config-*.yml")
+	require.NoError(t, err)
+	defer configFile.Close()
+	t.Cleanup(func() {
+		_ = os.Remove(configFile.Name())
+	})
+	_, err = io.WriteString(configFile, config("https://example.com"))
+	require.NoError(t, err)
+	require.NoError(t, configFile.Sync())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p, err := newKoanf(ctx, "./stub/watch/config.schema.json", []string{configFile.Name()}, WithContext(ctx))
+	require.NoError(t, err)
+
+	m := p.CORSMiddleware("", cors.Options{})
+
+	allowed := func(origin string) string {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(http.ResponseWriter, *http.Request) {})
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	assert.Equal(t, "https://example.com", allowed("https://example.com"))
+	assert.Empty(t, allowed("https://foo.example.org"))
+
+	_, err = configFile.Seek(0, 0)
+	require.NoError(t, err)
+	require.NoError(t, configFile.Truncate(0))
+	_, err = io.WriteString(configFile, config("https://*.example.org"))
+	require.NoError(t, err)
+	require.NoError(t, configFile.Sync())
+
+	assert.Eventually(t, func() bool {
+		return allowed("https://foo.example.org") == "https://foo.example.org"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, allowed("https://example.com"))
+}
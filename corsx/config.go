@@ -0,0 +1,20 @@
+package corsx
+
+import (
+	"bytes"
+	_ "embed"
+	"io"
+)
+
+//go:embed config.schema.json
+var ConfigSchema string
+
+const ConfigSchemaID = "ory://cors-config"
+
+// AddConfigSchema adds the CORS schema to the compiler.
+// The interface is specified instead of `jsonschema.Compiler` to allow the use of any jsonschema library fork or version.
+func AddConfigSchema(c interface {
+	AddResource(url string, r io.Reader) error
+}) error {
+	return c.AddResource(ConfigSchemaID, bytes.NewBufferString(ConfigSchema))
+}
@@ -0,0 +1,44 @@
+package corsx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ory/jsonschema/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchema(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	require.NoError(t, AddConfigSchema(c))
+	require.NoError(t, c.AddResource("config", bytes.NewBufferString(fmt.Sprintf(`{"properties":{"cors":{"$ref":"%s"}}}`, ConfigSchemaID))))
+	schema, err := c.Compile(context.Background(), "config")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		config string
+		valid  bool
+	}{
+		{config: `{"cors":{"enabled":true,"allowed_origins":["https://example.com","*"]}}`, valid: true},
+		{config: `{"cors":{"routes":[{"path_prefix":"/admin","enabled":false,"allowed_origins":["https://admin.example.com"]}]}}`, valid: true},
+		{config: `{"cors":{"routes":[{"allowed_origins":["https://admin.example.com"]}]}}`},
+		{config: `{"cors":{"routes":[{"path_prefix":"admin"}]}}`},
+		{config: `{"cors":{"allowed_origins":["https://*.preview.example.com","http://localhost:*","http://[::1]:4455"]}}`, valid: true},
+		{config: `{"cors":{"allowed_origins":["https://*.*.example.com"]}}`},
+		{config: `{"cors":{"allowed_origins":["https://example.com/path"]}}`},
+		{config: `{"cors":{"allowed_origins":["example.com"]}}`},
+		{config: `{"cors":{"foo":"bar"}}`},
+	} {
+		t.Run("case="+tc.config, func(t *testing.T) {
+			err := schema.Validate(bytes.NewBufferString(tc.config))
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
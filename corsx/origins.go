@@ -0,0 +1,168 @@
+package corsx
+
+import (
+	"strings"
+
+	"github.com/rs/cors"
+)
+
+type (
+	// OriginMatcher matches origins against a list of allowed origins which may contain patterns:
+	//
+	//   - `*` allows all origins,
+	//   - `https://*.example.com` allows all subdomains of example.com, at any depth, but not example.com itself,
+	//   - `http://localhost:*` allows any port, including the scheme's default port,
+	//   - `https://pr-*.example.com` allows a single wildcard anywhere in the host.
+	//
+	// Origins are compared case-insensitively. Origins without wildcards are looked up in a map, so only
+	// patterns add to the cost of a match.
+	OriginMatcher struct {
+		all      bool
+		exact    map[string]struct{}
+		patterns []originPattern
+	}
+
+	originPattern struct {
+		scheme     string
+		hostPrefix string
+		hostSuffix string
+		wildcard   bool
+		port       string
+		anyPort    bool
+	}
+)
+
+// NewOriginMatcher compiles the allowed origins. Patterns which can not be parsed never match.
+func NewOriginMatcher(origins []string) *OriginMatcher {
+	m := &OriginMatcher{exact: make(map[string]struct{}, len(origins))}
+	for _, o := range origins {
+		o = strings.ToLower(strings.TrimSpace(o))
+		switch {
+		case o == "*":
+			m.all = true
+		case !strings.Contains(o, "*"):
+			m.exact[o] = struct{}{}
+		default:
+			if p, ok := compileOriginPattern(o); ok {
+				m.patterns = append(m.patterns, p)
+			}
+		}
+	}
+	return m
+}
+
+// WithOriginMatcher sets AllowOriginFunc to an OriginMatcher if the allowed origins contain patterns,
+// as the matching of github.com/rs/cors only supports a single wildcard without port wildcards. Options
+// with a custom AllowOriginFunc or AllowOriginRequestFunc are returned unchanged.
+func WithOriginMatcher(opts cors.Options) cors.Options {
+	if opts.AllowOriginFunc != nil || opts.AllowOriginRequestFunc != nil || !hasPatterns(opts.AllowedOrigins) {
+		return opts
+	}
+	opts.AllowOriginFunc = NewOriginMatcher(opts.AllowedOrigins).Matches
+	return opts
+}
+
+func hasPatterns(origins []string) bool {
+	for _, o := range origins {
+		if o != "*" && strings.Contains(o, "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the origin is allowed. It can be used as cors.Options.AllowOriginFunc.
+func (m *OriginMatcher) Matches(origin string) bool {
+	if m.all {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if _, ok := m.exact[origin]; ok {
+		return true
+	}
+	if len(m.patterns) == 0 {
+		return false
+	}
+
+	scheme, host, port, ok := splitOrigin(origin)
+	if !ok {
+		return false
+	}
+	for _, p := range m.patterns {
+		if p.matches(scheme, host, port) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileOriginPattern(pattern string) (originPattern, bool) {
+	var p originPattern
+	if strings.HasSuffix(pattern, ":*") {
+		p.anyPort = true
+		pattern = strings.TrimSuffix(pattern, ":*")
+	}
+
+	scheme, host, port, ok := splitOrigin(pattern)
+	if !ok {
+		return p, false
+	}
+	p.scheme, p.port = scheme, port
+
+	if strings.Count(host, "*") > 1 {
+		return p, false
+	} else if i := strings.Index(host, "*"); i >= 0 {
+		p.hostPrefix, p.hostSuffix, p.wildcard = host[:i], host[i+1:], true
+	} else {
+		p.hostPrefix = host
+	}
+	return p, true
+}
+
+func (p originPattern) matches(scheme, host, port string) bool {
+	if scheme != p.scheme || (!p.anyPort && port != p.port) {
+		return false
+	}
+
+	if !p.wildcard {
+		return host == p.hostPrefix
+	}
+	if len(host) <= len(p.hostPrefix)+len(p.hostSuffix) ||
+		!strings.HasPrefix(host, p.hostPrefix) || !strings.HasSuffix(host, p.hostSuffix) {
+		return false
+	}
+	return isHostnameChars(host[len(p.hostPrefix) : len(host)-len(p.hostSuffix)])
+}
+
+// splitOrigin splits an origin such as `https://example.com:8080` into its scheme, host, and port.
+func splitOrigin(origin string) (scheme, host, port string, ok bool) {
+	i := strings.Index(origin, "://")
+	if i <= 0 {
+		return "", "", "", false
+	}
+	scheme, host = origin[:i], origin[i+3:]
+	if strings.ContainsAny(host, "/?#@") {
+		return "", "", "", false
+	}
+
+	// IPv6 literals contain colons, so the port follows the closing bracket.
+	if j := strings.LastIndex(host, ":"); j >= 0 && j > strings.LastIndex(host, "]") {
+		host, port = host[:j], host[j+1:]
+		if port == "" || strings.Trim(port, "0123456789") != "" {
+			return "", "", "", false
+		}
+	}
+	return scheme, host, port, host != ""
+}
+
+// isHostnameChars returns true if s only consists of characters valid in host names, so that a
+// wildcard can not match into the port or user info.
+func isHostnameChars(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package corsx
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/cors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOriginMatcher(t *testing.T) {
+	m := NewOriginMatcher([]string{
+		"https://example.com",
+		"https://*.preview.example.com",
+		"https://pr-*.example.org",
+		"http://localhost:*",
+		"http://[::1]:*",
+		"https://*.example.net:8443",
+		"https://*.*.invalid.com",
+	})
+
+	for _, tc := range []struct {
+		origin  string
+		matches bool
+	}{
+		{origin: "https://example.com", matches: true},
+		{origin: "https://EXAMPLE.com", matches: true},
+		{origin: "http://example.com"},
+		{origin: "https://example.com:8443"},
+		{origin: "https://foo.example.com"},
+
+		{origin: "https://pr-1.preview.example.com", matches: true},
+		{origin: "https://a.b.preview.example.com", matches: true},
+		{origin: "https://preview.example.com"},
+		{origin: "https://evil-preview.example.com"},
+		{origin: "https://evil.com/.preview.example.com"},
+		{origin: "https://evil.com@a.preview.example.com"},
+		{origin: "http://a.preview.example.com"},
+
+		{origin: "https://pr-123.example.org", matches: true},
+		{origin: "https://pr-.example.org"},
+		{origin: "https://foo.example.org"},
+
+		{origin: "http://localhost", matches: true},
+		{origin: "http://localhost:4455", matches: true},
+		{origin: "http://localhost.evil.com"},
+		{origin: "https://localhost:4455"},
+
+		{origin: "http://[::1]:4455", matches: true},
+
+		{origin: "https://api.example.net:8443", matches: true},
+		{origin: "https://api.example.net"},
+
+		{origin: "https://a.b.invalid.com"},
+		{origin: "null"},
+		{origin: ""},
+	} {
+		t.Run("case="+tc.origin, func(t *testing.T) {
+			assert.Equal(t, tc.matches, m.Matches(tc.origin))
+		})
+	}
+
+	t.Run("case=allow all", func(t *testing.T) {
+		assert.True(t, NewOriginMatcher([]string{"https://example.com", "*"}).Matches("https://foo.bar"))
+		assert.False(t, NewOriginMatcher(nil).Matches("https://foo.bar"))
+	})
+}
+
+func TestWithOriginMatcher(t *testing.T) {
+	assert.Nil(t, WithOriginMatcher(cors.Options{AllowedOrigins: []string{"https://example.com", "*"}}).AllowOriginFunc)
+
+	opts := WithOriginMatcher(cors.Options{AllowedOrigins: []string{"http://localhost:*"}})
+	assert.NotNil(t, opts.AllowOriginFunc)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Origin", "http://localhost:4455")
+	w := httptest.NewRecorder()
+	cors.New(opts).HandlerFunc(w, r)
+	assert.Equal(t, "http://localhost:4455", w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,44 @@
+package corsx
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ReloadableMiddleware is a RouteMiddleware whose routes are loaded again on every call to Reload,
+// so that configuration changes take effect without restarting the server.
+type ReloadableMiddleware struct {
+	load func() []Route
+
+	mu         sync.Mutex
+	routes     []Route
+	middleware atomic.Value
+}
+
+// NewReloadableMiddleware returns a middleware applying the routes returned by load, which is called
+// once immediately and again on every call to Reload.
+func NewReloadableMiddleware(load func() []Route) *ReloadableMiddleware {
+	m := &ReloadableMiddleware{load: load}
+	m.Reload()
+	return m
+}
+
+// Reload loads the routes again. The policies are only compiled again if the routes have changed.
+// Requests in flight finish with the policies they started with.
+func (m *ReloadableMiddleware) Reload() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := m.load()
+	if m.middleware.Load() != nil && reflect.DeepEqual(routes, m.routes) {
+		return
+	}
+	m.routes = routes
+	m.middleware.Store(NewRouteMiddleware(routes))
+}
+
+func (m *ReloadableMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	m.middleware.Load().(*RouteMiddleware).ServeHTTP(w, r, next)
+}
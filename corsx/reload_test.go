@@ -0,0 +1,41 @@
+package corsx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/cors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadableMiddleware(t *testing.T) {
+	var loads int
+	origins := []string{"https://example.com"}
+	m := NewReloadableMiddleware(func() []Route {
+		loads++
+		return []Route{{Enabled: true, Options: cors.Options{AllowedOrigins: origins}}}
+	})
+
+	allowed := func(origin string) string {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {})
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	assert.Equal(t, 1, loads)
+	assert.Equal(t, "https://example.com", allowed("https://example.com"))
+	assert.Empty(t, allowed("https://foo.example.com"))
+
+	compiled := m.middleware.Load()
+	m.Reload()
+	assert.Equal(t, 2, loads)
+	assert.True(t, compiled == m.middleware.Load(), "unchanged routes are not compiled again")
+
+	origins = []string{"https://*.example.com"}
+	m.Reload()
+	assert.Empty(t, allowed("https://example.com"))
+	assert.Equal(t, "https://foo.example.com", allowed("https://foo.example.com"))
+}
@@ -0,0 +1,68 @@
+package corsx
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/rs/cors"
+)
+
+type (
+	// Route is the CORS policy for requests whose path starts with PathPrefix.
+	Route struct {
+		// PathPrefix is matched against path segments, so `/admin` matches `/admin` and `/admin/clients`
+		// but not `/administrators`. The empty prefix matches all requests.
+		PathPrefix string
+		Enabled    bool
+		Options    cors.Options
+	}
+
+	// RouteMiddleware applies the CORS policy of the route with the longest matching path prefix.
+	RouteMiddleware struct {
+		routes []compiledRoute
+	}
+
+	compiledRoute struct {
+		prefix  string
+		enabled bool
+		cors    *cors.Cors
+	}
+)
+
+// NewRouteMiddleware returns a middleware selecting the CORS policy per request path, e.g. to allow
+// different origins for the public and the admin API. Requests not matching any route are passed on
+// without CORS handling. Allowed origins may contain the patterns supported by OriginMatcher.
+func NewRouteMiddleware(routes []Route) *RouteMiddleware {
+	compiled := make([]compiledRoute, len(routes))
+	for k, r := range routes {
+		compiled[k] = compiledRoute{
+			prefix:  strings.TrimSuffix(r.PathPrefix, "/"),
+			enabled: r.Enabled,
+			cors:    cors.New(WithOriginMatcher(r.Options)),
+		}
+	}
+	// Longest prefix first, keeping the configured order for equal prefixes.
+	sort.SliceStable(compiled, func(i, j int) bool {
+		return len(compiled[i].prefix) > len(compiled[j].prefix)
+	})
+	return &RouteMiddleware{routes: compiled}
+}
+
+func (m *RouteMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	for _, route := range m.routes {
+		if !matchesPrefix(r.URL.Path, route.prefix) {
+			continue
+		}
+		if !route.enabled {
+			break
+		}
+		route.cors.ServeHTTP(w, r, next)
+		return
+	}
+	next(w, r)
+}
+
+func matchesPrefix(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,53 @@
+package corsx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/cors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteMiddleware(t *testing.T) {
+	m := NewRouteMiddleware([]Route{
+		{Enabled: true, Options: cors.Options{AllowedOrigins: []string{"https://public.example.com"}}},
+		{PathPrefix: "/admin/", Enabled: true, Options: cors.Options{AllowedOrigins: []string{"https://admin.example.com"}}},
+		{PathPrefix: "/admin/internal", Enabled: false},
+	})
+
+	for _, tc := range []struct {
+		path, origin, expected string
+	}{
+		{path: "/", origin: "https://public.example.com", expected: "https://public.example.com"},
+		{path: "/", origin: "https://admin.example.com"},
+		{path: "/admin", origin: "https://admin.example.com", expected: "https://admin.example.com"},
+		{path: "/admin/clients", origin: "https://admin.example.com", expected: "https://admin.example.com"},
+		{path: "/admin/clients", origin: "https://public.example.com"},
+		{path: "/administrators", origin: "https://public.example.com", expected: "https://public.example.com"},
+		{path: "/admin/internal/keys", origin: "https://admin.example.com"},
+		{path: "/admin/internal/keys", origin: "https://public.example.com"},
+	} {
+		t.Run("case="+tc.path+" "+tc.origin, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tc.path, nil)
+			r.Header.Set("Origin", tc.origin)
+			w := httptest.NewRecorder()
+
+			var called bool
+			m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			})
+			assert.True(t, called)
+			assert.Equal(t, tc.expected, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+
+	t.Run("case=no matching route", func(t *testing.T) {
+		m := NewRouteMiddleware([]Route{{PathPrefix: "/admin", Enabled: true}})
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Origin", "https://example.com")
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {})
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
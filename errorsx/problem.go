@@ -0,0 +1,199 @@
+package errorsx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/httptrace/otelhttptrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContentTypeProblem is the content type of RFC 7807 problem details.
+const ContentTypeProblem = "application/problem+json"
+
+// Problem is an error carrying an HTTP status code, a stable ID, and details. WriteProblem renders it as
+// RFC 7807 problem details:
+//
+//	{"type":"about:blank","title":"Not Found","status":404,"detail":"The identity does not exist.","id":"identity_not_found"}
+//
+// The predefined problems, e.g. ErrNotFound, are values and can be refined using the With methods:
+//
+//	return errors.WithStack(errorsx.ErrNotFound.WithID("identity_not_found").WithReason("The identity does not exist."))
+type Problem struct {
+	// Type is a URI reference identifying the problem type. Defaults to `about:blank`.
+	Type string `json:"type"`
+	// Title is a short summary of the problem type, e.g. `Not Found`.
+	Title string `json:"title"`
+	// Code is the HTTP status code.
+	Code int `json:"status"`
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// ErrorID is a stable ID clients can rely on, e.g. `identity_not_found`.
+	ErrorID string `json:"id,omitempty"`
+	// Fields contains further details on the problem.
+	Fields map[string]interface{} `json:"details,omitempty"`
+	// RequestIDField is the ID of the request which caused the problem.
+	RequestIDField string `json:"request_id,omitempty"`
+	// TraceID is the ID of the trace of the request which caused the problem.
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+var (
+	ErrBadRequest          = Problem{Type: "about:blank", Title: "Bad Request", Code: http.StatusBadRequest}
+	ErrUnauthorized        = Problem{Type: "about:blank", Title: "Unauthorized", Code: http.StatusUnauthorized}
+	ErrForbidden           = Problem{Type: "about:blank", Title: "Forbidden", Code: http.StatusForbidden}
+	ErrNotFound            = Problem{Type: "about:blank", Title: "Not Found", Code: http.StatusNotFound}
+	ErrConflict            = Problem{Type: "about:blank", Title: "Conflict", Code: http.StatusConflict}
+	ErrInternalServerError = Problem{Type: "about:blank", Title: "Internal Server Error", Code: http.StatusInternalServerError}
+)
+
+// NewProblem returns a problem with the status code and the stable ID. The title is the status text.
+func NewProblem(code int, id string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(code), Code: code, ErrorID: id}
+}
+
+func (p Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%s: %s", p.Title, p.Detail)
+	}
+	return p.Title
+}
+
+// StatusCode implements StatusCodeCarrier.
+func (p Problem) StatusCode() int {
+	return p.Code
+}
+
+// ID implements IDCarrier.
+func (p Problem) ID() string {
+	return p.ErrorID
+}
+
+// Reason implements ReasonCarrier.
+func (p Problem) Reason() string {
+	return p.Detail
+}
+
+// Details implements DetailsCarrier.
+func (p Problem) Details() map[string]interface{} {
+	return p.Fields
+}
+
+// RequestID implements RequestIDCarrier.
+func (p Problem) RequestID() string {
+	return p.RequestIDField
+}
+
+// WithID returns a copy of the problem with the stable ID.
+func (p Problem) WithID(id string) *Problem {
+	p.ErrorID = id
+	return &p
+}
+
+// WithReason returns a copy of the problem with the detail.
+func (p Problem) WithReason(reason string) *Problem {
+	p.Detail = reason
+	return &p
+}
+
+// WithReasonf is like WithReason but formats the detail.
+func (p Problem) WithReasonf(format string, args ...interface{}) *Problem {
+	return p.WithReason(fmt.Sprintf(format, args...))
+}
+
+// WithDetail returns a copy of the problem with the detail field set.
+func (p Problem) WithDetail(key string, value interface{}) *Problem {
+	fields := make(map[string]interface{}, len(p.Fields)+1)
+	for k, v := range p.Fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	p.Fields = fields
+	return &p
+}
+
+// WithType returns a copy of the problem with the problem type URI.
+func (p Problem) WithType(uri string) *Problem {
+	p.Type = uri
+	return &p
+}
+
+// ToProblem converts the error to problem details. The status code, ID, reason, details, and request ID are
+// taken from the carrier interfaces, so errors of other packages, e.g. herodot, are converted as well. The
+// message of other errors is not exposed; they become internal server errors.
+func ToProblem(err error) *Problem {
+	var p Problem
+	if pp := (*Problem)(nil); errors.As(err, &pp) && pp != nil {
+		p = *pp
+	} else if !errors.As(err, &p) {
+		p = fromCarriers(err)
+	}
+
+	if p.Code == 0 {
+		p.Code = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Code)
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	return &p
+}
+
+func fromCarriers(err error) Problem {
+	p := ErrInternalServerError
+	if c := StatusCodeCarrier(nil); errors.As(err, &c) && c.StatusCode() >= 400 {
+		p = Problem{Code: c.StatusCode()}
+	}
+	if c := IDCarrier(nil); errors.As(err, &c) {
+		p.ErrorID = c.ID()
+	}
+	if c := ReasonCarrier(nil); errors.As(err, &c) {
+		p.Detail = c.Reason()
+	}
+	if c := DetailsCarrier(nil); errors.As(err, &c) && len(c.Details()) > 0 {
+		p.Fields = c.Details()
+	}
+	if c := RequestIDCarrier(nil); errors.As(err, &c) {
+		p.RequestIDField = c.RequestID()
+	}
+	return p
+}
+
+// WriteProblem writes the error as RFC 7807 problem details with content type application/problem+json,
+// see ToProblem. The request and trace IDs are taken from the request if the error does not carry them, so
+// clients can refer to the logs of the request.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := ToProblem(err)
+	if p.RequestIDField == "" {
+		p.RequestIDField = r.Header.Get("X-Request-Id")
+	}
+	if p.TraceID == "" {
+		p.TraceID = traceID(r)
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Code)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+var propagators = otelhttptrace.WithPropagators(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+// traceID returns the ID of the span in the request context or, like logrusx, the ID propagated by the
+// client.
+func traceID(r *http.Request) string {
+	if spanCtx := trace.SpanContextFromContext(r.Context()); spanCtx.HasTraceID() {
+		return spanCtx.TraceID().String()
+	}
+	if _, _, spanCtx := otelhttptrace.Extract(r.Context(), r, propagators); spanCtx.HasTraceID() {
+		return spanCtx.TraceID().String()
+	}
+	return ""
+}
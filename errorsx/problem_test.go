@@ -0,0 +1,129 @@
+package errorsx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type carrierError struct{}
+
+func (carrierError) Error() string                   { return "secret internal message" }
+func (carrierError) StatusCode() int                 { return http.StatusConflict }
+func (carrierError) ID() string                      { return "identity_exists" }
+func (carrierError) Reason() string                  { return "The identity already exists." }
+func (carrierError) Details() map[string]interface{} { return map[string]interface{}{"id": "foo"} }
+func (carrierError) RequestID() string               { return "request-id" }
+
+func TestProblem(t *testing.T) {
+	t.Run("case=With methods do not modify the original", func(t *testing.T) {
+		p := ErrNotFound.WithID("identity_not_found").WithReasonf("The identity %s does not exist.", "foo").WithDetail("id", "foo")
+		p2 := p.WithDetail("other", "bar")
+
+		assert.Equal(t, "Not Found: The identity foo does not exist.", p.Error())
+		assert.Equal(t, map[string]interface{}{"id": "foo"}, p.Details())
+		assert.Equal(t, map[string]interface{}{"id": "foo", "other": "bar"}, p2.Details())
+		assert.Empty(t, ErrNotFound.ID())
+		assert.Empty(t, ErrNotFound.Reason())
+		assert.Nil(t, ErrNotFound.Details())
+	})
+
+	t.Run("case=implements the carriers", func(t *testing.T) {
+		err := errors.WithStack(NewProblem(http.StatusTeapot, "teapot").WithReason("I am a teapot."))
+
+		c := StatusCodeCarrier(nil)
+		require.True(t, errors.As(err, &c))
+		assert.Equal(t, http.StatusTeapot, c.StatusCode())
+
+		id := IDCarrier(nil)
+		require.True(t, errors.As(err, &id))
+		assert.Equal(t, "teapot", id.ID())
+	})
+}
+
+func TestToProblem(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected Problem
+	}{
+		{
+			name:     "problem pointer",
+			err:      errors.WithStack(ErrNotFound.WithReason("The identity does not exist.")),
+			expected: Problem{Type: "about:blank", Title: "Not Found", Code: http.StatusNotFound, Detail: "The identity does not exist."},
+		},
+		{
+			name:     "problem value",
+			err:      errors.WithStack(ErrForbidden),
+			expected: ErrForbidden,
+		},
+		{
+			name:     "empty problem",
+			err:      &Problem{ErrorID: "foo"},
+			expected: Problem{Type: "about:blank", Title: "Internal Server Error", Code: http.StatusInternalServerError, ErrorID: "foo"},
+		},
+		{
+			name: "carriers",
+			err:  errors.Wrap(carrierError{}, "wrapped"),
+			expected: Problem{
+				Type:           "about:blank",
+				Title:          "Conflict",
+				Code:           http.StatusConflict,
+				Detail:         "The identity already exists.",
+				ErrorID:        "identity_exists",
+				Fields:         map[string]interface{}{"id": "foo"},
+				RequestIDField: "request-id",
+			},
+		},
+		{
+			name:     "other errors are not exposed",
+			err:      errors.New("secret internal message"),
+			expected: ErrInternalServerError,
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, *ToProblem(tc.err))
+		})
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	t.Run("case=writes problem details", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/identities/foo", nil)
+		r.Header.Set("X-Request-Id", "request-id")
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		w := httptest.NewRecorder()
+
+		WriteProblem(w, r, errors.WithStack(ErrNotFound.WithID("identity_not_found")))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, ContentTypeProblem, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+  "type": "about:blank",
+  "title": "Not Found",
+  "status": 404,
+  "id": "identity_not_found",
+  "request_id": "request-id",
+  "trace_id": "4bf92f3577b34da6a3ce929d0e0e4736"
+}`, w.Body.String())
+	})
+
+	t.Run("case=keeps the request ID of the error", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Request-Id", "other")
+		w := httptest.NewRecorder()
+
+		WriteProblem(w, r, carrierError{})
+
+		var p Problem
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+		assert.Equal(t, "request-id", p.RequestIDField)
+		assert.Empty(t, p.TraceID)
+		assert.NotContains(t, w.Body.String(), "secret internal message")
+	})
+}
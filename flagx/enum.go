@@ -0,0 +1,50 @@
+package flagx
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// Enum is a string flag which only accepts the allowed values, so invalid values are rejected when the
+// flags are parsed:
+//
+//	flags.Var(flagx.NewEnum("info", "debug", "info", "error"), "level", "Set the log level.")
+//
+// The value can be read using MustGetString.
+type Enum struct {
+	value   string
+	allowed []string
+}
+
+var _ pflag.Value = (*Enum)(nil)
+
+// NewEnum returns an Enum with the default value and the allowed values.
+func NewEnum(value string, allowed ...string) *Enum {
+	return &Enum{value: value, allowed: allowed}
+}
+
+// Allowed returns the allowed values, e.g. to complete them.
+func (e *Enum) Allowed() []string {
+	return e.allowed
+}
+
+func (e *Enum) String() string {
+	return e.value
+}
+
+func (e *Enum) Set(v string) error {
+	for _, a := range e.allowed {
+		if v == a {
+			e.value = v
+			return nil
+		}
+	}
+	return errors.Errorf("must be one of %s", strings.Join(e.allowed, ", "))
+}
+
+// Type returns "string" so that the value can be read using pflag.FlagSet.GetString.
+func (e *Enum) Type() string {
+	return "string"
+}
@@ -1,8 +1,10 @@
 package flagx
 
 import (
+	"net/url"
 	"time"
 
+	"github.com/inhies/go-bytesize"
 	"github.com/spf13/pflag"
 
 	"github.com/spf13/cobra"
@@ -76,3 +78,48 @@ func MustGetUint32(cmd *cobra.Command, name string) uint32 {
 	}
 	return v
 }
+
+// MustGetDurationSlice returns a []time.Duration flag or fatals if an error occurs.
+func MustGetDurationSlice(cmd *cobra.Command, name string) []time.Duration {
+	v, err := cmd.Flags().GetDurationSlice(name)
+	if err != nil {
+		cmdx.Fatalf(err.Error())
+	}
+	return v
+}
+
+// MustGetURL returns a string flag parsed as absolute URL or fatals if an error occurs. It returns nil if the
+// flag is empty.
+func MustGetURL(cmd *cobra.Command, name string) *url.URL {
+	s := MustGetString(cmd, name)
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		cmdx.Fatalf("Flag --%s must be a valid URL: %s", name, err)
+	}
+	if !u.IsAbs() {
+		cmdx.Fatalf("Flag --%s must be an absolute URL but got: %s", name, s)
+	}
+	return u
+}
+
+// MustGetByteSize returns a byte size flag, e.g. `512MB`, or fatals if an error occurs. The flag is either a
+// string flag or was registered using
+//
+//	flags.Var(new(bytesize.ByteSize), "max-size", "...")
+func MustGetByteSize(cmd *cobra.Command, name string) bytesize.ByteSize {
+	f := cmd.Flags().Lookup(name)
+	if f == nil {
+		cmdx.Fatalf("flag accessed but not defined: %s", name)
+	}
+	if v, ok := f.Value.(*bytesize.ByteSize); ok {
+		return *v
+	}
+	v, err := bytesize.Parse(f.Value.String())
+	if err != nil {
+		cmdx.Fatalf("Flag --%s must be a byte size such as 512MB: %s", name, err)
+	}
+	return v
+}
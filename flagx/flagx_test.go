@@ -1 +1,64 @@
 package flagx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/inhies/go-bytesize"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedGetters(t *testing.T) {
+	newCmd := func(t *testing.T, args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "x"}
+		cmd.Flags().String("url", "", "")
+		cmd.Flags().String("size", "1KB", "")
+		cmd.Flags().Var(new(bytesize.ByteSize), "size-var", "")
+		cmd.Flags().DurationSlice("durations", nil, "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	t.Run("case=url", func(t *testing.T) {
+		assert.Nil(t, MustGetURL(newCmd(t), "url"))
+		assert.Equal(t, "https://example.com/path", MustGetURL(newCmd(t, "--url", "https://example.com/path"), "url").String())
+	})
+
+	t.Run("case=byte size", func(t *testing.T) {
+		assert.Equal(t, bytesize.KB, MustGetByteSize(newCmd(t), "size"))
+		assert.Equal(t, 512*bytesize.MB, MustGetByteSize(newCmd(t, "--size", "512MB"), "size"))
+		assert.Equal(t, bytesize.ByteSize(1234567), MustGetByteSize(newCmd(t, "--size-var", "1234567B"), "size-var"))
+	})
+
+	t.Run("case=duration slice", func(t *testing.T) {
+		assert.Equal(t, []time.Duration{time.Second, time.Minute}, MustGetDurationSlice(newCmd(t, "--durations", "1s,1m"), "durations"))
+	})
+}
+
+func TestEnum(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "x"}
+		cmd.Flags().Var(NewEnum("info", "debug", "info", "error"), "level", "")
+		return cmd
+	}
+
+	t.Run("case=default", func(t *testing.T) {
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Parse(nil))
+		assert.Equal(t, "info", MustGetString(cmd, "level"))
+	})
+
+	t.Run("case=allowed value", func(t *testing.T) {
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Parse([]string{"--level", "debug"}))
+		assert.Equal(t, "debug", MustGetString(cmd, "level"))
+	})
+
+	t.Run("case=rejects other values", func(t *testing.T) {
+		err := newCmd().Flags().Parse([]string{"--level", "trace"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must be one of debug, info, error")
+	})
+}
@@ -0,0 +1,120 @@
+package healthx
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CacheOptions configures Cached.
+type CacheOptions struct {
+	// TTL is the duration for which the result of a check is reused.
+	TTL time.Duration
+	// Jitter shortens the TTL of each result by a random fraction of at most Jitter (0 to 1), so
+	// that checks of multiple instances or dependencies do not expire at the same time.
+	Jitter float64
+	// StaleWhileRevalidate returns the expired result immediately and refreshes it in the
+	// background instead of blocking the probe until the check completed.
+	StaleWhileRevalidate bool
+	// Timeout is the maximum duration of a single check. Defaults to DefaultCheckTimeout.
+	Timeout time.Duration
+}
+
+type cachedChecker struct {
+	c    ReadyChecker
+	opts CacheOptions
+
+	mu       sync.Mutex
+	valid    bool
+	err      error
+	expires  time.Time
+	inflight chan struct{}
+}
+
+// Cached returns a ReadyChecker which reuses the result of c for the configured TTL. This prevents
+// frequent probes, for example of multiple load balancers, from overloading dependencies such as
+// the database:
+//
+//	healthx.Register("database", healthx.Cached(checkDatabase, healthx.CacheOptions{
+//		TTL:    5 * time.Second,
+//		Jitter: 0.2,
+//	}))
+//
+// Concurrent probes share a single run of c. Because the result is shared, c is called with a
+// request whose context is not cancelled when the probe which triggered the run is aborted.
+func Cached(c ReadyChecker, opts CacheOptions) ReadyChecker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultCheckTimeout
+	}
+	if opts.Jitter < 0 {
+		opts.Jitter = 0
+	} else if opts.Jitter > 1 {
+		opts.Jitter = 1
+	}
+	return (&cachedChecker{c: c, opts: opts}).check
+}
+
+func (c *cachedChecker) check(r *http.Request) error {
+	c.mu.Lock()
+	if c.valid && (time.Now().Before(c.expires) || c.opts.StaleWhileRevalidate) {
+		if c.inflight == nil && !time.Now().Before(c.expires) {
+			c.refresh(r)
+		}
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+
+	if c.inflight == nil {
+		c.refresh(r)
+	}
+	inflight := c.inflight
+	c.mu.Unlock()
+
+	select {
+	case <-inflight:
+	case <-r.Context().Done():
+		return errors.WithStack(r.Context().Err())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// refresh runs the check in the background. c.mu must be held.
+func (c *cachedChecker) refresh(r *http.Request) {
+	done := make(chan struct{})
+	c.inflight = done
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	r = r.Clone(ctx)
+
+	go func() {
+		defer cancel()
+
+		err := func() (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = errors.Errorf("check panicked: %v", p)
+				}
+			}()
+			return c.c(r)
+		}()
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.valid, c.err, c.expires = true, err, time.Now().Add(c.ttl())
+		c.inflight = nil
+		close(done)
+	}()
+}
+
+func (c *cachedChecker) ttl() time.Duration {
+	// #nosec G404 - jitter does not need to be cryptographically secure
+	return c.opts.TTL - time.Duration(rand.Float64()*c.opts.Jitter*float64(c.opts.TTL))
+}
@@ -0,0 +1,85 @@
+package healthx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCached(t *testing.T) {
+	newChecker := func(err *atomic.Value, calls *int32, delay time.Duration) ReadyChecker {
+		return func(r *http.Request) error {
+			atomic.AddInt32(calls, 1)
+			time.Sleep(delay)
+			e, _ := err.Load().(error)
+			return e
+		}
+	}
+	req := httptest.NewRequest("GET", ReadyCheckPath, nil)
+
+	t.Run("case=reuses result until ttl expires", func(t *testing.T) {
+		var calls int32
+		var result atomic.Value
+		result.Store(errors.New("down"))
+
+		c := Cached(newChecker(&result, &calls, 0), CacheOptions{TTL: 100 * time.Millisecond})
+		assert.EqualError(t, c(req), "down")
+
+		result.Store(errors.New("still down"))
+		assert.EqualError(t, c(req), "down")
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+		time.Sleep(150 * time.Millisecond)
+		assert.EqualError(t, c(req), "still down")
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=concurrent probes share a single run", func(t *testing.T) {
+		var calls int32
+		var result atomic.Value
+		c := Cached(newChecker(&result, &calls, 50*time.Millisecond), CacheOptions{TTL: time.Minute})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, c(req))
+			}()
+		}
+		wg.Wait()
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=stale while revalidate", func(t *testing.T) {
+		var calls int32
+		var result atomic.Value
+		c := Cached(newChecker(&result, &calls, 100*time.Millisecond), CacheOptions{TTL: 50 * time.Millisecond, StaleWhileRevalidate: true})
+		require.NoError(t, c(req))
+
+		result.Store(errors.New("down"))
+		time.Sleep(60 * time.Millisecond)
+
+		start := time.Now()
+		assert.NoError(t, c(req), "the stale result is returned")
+		assert.Less(t, int64(time.Since(start)), int64(50*time.Millisecond), "the check is refreshed in the background")
+
+		assert.Eventually(t, func() bool { return c(req) != nil }, time.Second, 5*time.Millisecond)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=jitter shortens ttl", func(t *testing.T) {
+		c := &cachedChecker{opts: CacheOptions{TTL: time.Second, Jitter: 0.5}}
+		for i := 0; i < 100; i++ {
+			ttl := c.ttl()
+			assert.True(t, ttl > 500*time.Millisecond && ttl <= time.Second, "%s", ttl)
+		}
+	})
+}
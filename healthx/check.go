@@ -0,0 +1,76 @@
+package healthx
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RunChecks runs all readiness checks concurrently and returns the result of each check by name.
+//
+// Every check receives a copy of r whose context is cancelled once the check timeout or the
+// overall timeout elapses. Checks which do not return in time are reported as failed even if
+// they ignore the context, so that a single slow dependency can not delay the response beyond
+// the overall timeout and cause probes to time out.
+func (h *Handler) RunChecks(r *http.Request) map[string]error {
+	return h.runChecks(r, h.Checks())
+}
+
+func (h *Handler) runChecks(r *http.Request, checks ReadyCheckers) map[string]error {
+	checkTimeout, timeout := h.checkTimeout, h.timeout
+	if checkTimeout <= 0 {
+		checkTimeout = DefaultCheckTimeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(checks))
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check ReadyChecker) {
+			defer wg.Done()
+			start := time.Now()
+			err := runCheck(ctx, r, check, checkTimeout)
+			h.record(name, err, start, time.Since(start))
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = err
+		}(name, check)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func runCheck(ctx context.Context, r *http.Request, check ReadyChecker, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- check(r.WithContext(ctx))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "check did not complete in time")
+	}
+}
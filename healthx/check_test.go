@@ -0,0 +1,59 @@
+package healthx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+)
+
+func TestRunChecks(t *testing.T) {
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+
+	handler := NewHandler(herodot.NewJSONWriter(nil), "", ReadyCheckers{
+		"ok":   func(r *http.Request) error { return nil },
+		"fail": func(r *http.Request) error { return errors.New("fail") },
+		"slow": func(r *http.Request) error {
+			<-r.Context().Done()
+			return r.Context().Err()
+		},
+		"ignores context": func(r *http.Request) error {
+			<-block
+			return nil
+		},
+		"panics": func(r *http.Request) error { panic("oh no") },
+	}, WithCheckTimeout(50*time.Millisecond), WithTimeout(time.Second))
+
+	start := time.Now()
+	results := handler.RunChecks(httptest.NewRequest("GET", ReadyCheckPath, nil))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "checks must run concurrently and time out individually")
+
+	require.Len(t, results, 5)
+	assert.NoError(t, results["ok"])
+	assert.EqualError(t, results["fail"], "fail")
+	assert.ErrorIs(t, results["slow"], context.DeadlineExceeded)
+	assert.ErrorIs(t, results["ignores context"], context.DeadlineExceeded)
+	assert.EqualError(t, results["panics"], "check panicked: oh no")
+
+	t.Run("case=overall timeout", func(t *testing.T) {
+		handler := NewHandler(herodot.NewJSONWriter(nil), "", ReadyCheckers{
+			"slow": func(r *http.Request) error {
+				<-block
+				return nil
+			},
+		}, WithCheckTimeout(time.Minute), WithTimeout(50*time.Millisecond))
+
+		start := time.Now()
+		results := handler.RunChecks(httptest.NewRequest("GET", ReadyCheckPath, nil))
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		assert.ErrorIs(t, results["slow"], context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,258 @@
+package healthx
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SQLConn is implemented by *sql.DB and *sqlx.DB.
+type SQLConn interface {
+	PingContext(ctx context.Context) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SQLChecker returns a ReadyChecker which pings the database and runs a simple query, which also
+// detects databases accepting connections but failing to execute statements.
+func SQLChecker(db SQLConn) ReadyChecker {
+	return func(r *http.Request) error {
+		if err := db.PingContext(r.Context()); err != nil {
+			return errors.Wrap(err, "unable to ping database")
+		}
+		if _, err := db.ExecContext(r.Context(), "SELECT 1"); err != nil {
+			return errors.Wrap(err, "unable to query database")
+		}
+		return nil
+	}
+}
+
+// HTTPChecker returns a ReadyChecker which sends a GET request to u and expects the given status
+// code, or any 2xx status code if expectedStatus is zero. If c is nil, http.DefaultClient is used.
+func HTTPChecker(c *http.Client, u string, expectedStatus int) ReadyChecker {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return func(r *http.Request) error {
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, u, nil)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		res, err := c.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "unable to reach %s", u)
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+
+		if expectedStatus == 0 && res.StatusCode >= 200 && res.StatusCode < 300 ||
+			res.StatusCode == expectedStatus {
+			return nil
+		}
+		return errors.Errorf("expected %s to respond with status %s but got %d", u, expectedStatusText(expectedStatus), res.StatusCode)
+	}
+}
+
+func expectedStatusText(status int) string {
+	if status == 0 {
+		return "2xx"
+	}
+	return fmt.Sprintf("%d", status)
+}
+
+// TCPChecker returns a ReadyChecker which succeeds if a TCP connection to address can be opened.
+func TCPChecker(address string) ReadyChecker {
+	return func(r *http.Request) error {
+		var d net.Dialer
+		conn, err := d.DialContext(r.Context(), "tcp", address)
+		if err != nil {
+			return errors.Wrapf(err, "unable to connect to %s", address)
+		}
+		return errors.WithStack(conn.Close())
+	}
+}
+
+// DiskChecker returns a ReadyChecker which fails if less than minFreeBytes are available to
+// unprivileged users on the file system containing path.
+func DiskChecker(path string, minFreeBytes uint64) ReadyChecker {
+	return func(r *http.Request) error {
+		free, err := diskFree(path)
+		if err != nil {
+			return errors.Wrapf(err, "unable to determine free disk space of %s", path)
+		}
+		if free < minFreeBytes {
+			return errors.Errorf("only %d bytes of disk space are available at %s but at least %d bytes are required", free, path, minFreeBytes)
+		}
+		return nil
+	}
+}
+
+// RedisChecker returns a ReadyChecker which sends a PING command to the Redis server at the given
+// URL, for example "redis://:password@localhost:6379" or "rediss://localhost:6380" for TLS.
+func RedisChecker(redisURL string) (ReadyChecker, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, errors.Errorf("expected redis URL to use the redis or rediss scheme but got %q", u.Scheme)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	var auth []string
+	if password, ok := u.User.Password(); ok {
+		auth = []string{"AUTH", password}
+		if name := u.User.Username(); name != "" {
+			auth = []string{"AUTH", name, password}
+		}
+	}
+
+	return func(r *http.Request) error {
+		var d net.Dialer
+		var conn net.Conn
+		var err error
+		if u.Scheme == "rediss" {
+			conn, err = (&tls.Dialer{NetDialer: &d, Config: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}}).DialContext(r.Context(), "tcp", address)
+		} else {
+			conn, err = d.DialContext(r.Context(), "tcp", address)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to connect to redis at %s", address)
+		}
+		defer conn.Close()
+
+		if deadline, ok := r.Context().Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		rd := bufio.NewReader(conn)
+		if auth != nil {
+			if _, err := redisCommand(conn, rd, auth...); err != nil {
+				return errors.Wrap(err, "unable to authenticate with redis")
+			}
+		}
+
+		reply, err := redisCommand(conn, rd, "PING")
+		if err != nil {
+			return errors.Wrap(err, "unable to ping redis")
+		}
+		if reply != "PONG" {
+			return errors.Errorf("expected redis to reply to PING with PONG but got %q", reply)
+		}
+		return nil
+	}, nil
+}
+
+// redisCommand sends a command using the Redis serialization protocol and returns the simple
+// string reply.
+func redisCommand(w io.Writer, rd *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return line[1:], nil
+	case strings.HasPrefix(line, "-"):
+		return "", errors.New(line[1:])
+	}
+	return "", errors.Errorf("unexpected redis reply %q", line)
+}
+
+// CheckerConfig configures a built-in ReadyChecker, see NewCheckers.
+type CheckerConfig struct {
+	// Name is the name of the check.
+	Name string `json:"name"`
+	// Type is one of "sql", "http", "tcp", "disk", or "redis".
+	Type string `json:"type"`
+
+	// Driver is the database/sql driver name of "sql" checks.
+	Driver string `json:"driver,omitempty"`
+	// DSN is the data source name of "sql" checks.
+	DSN string `json:"dsn,omitempty"`
+	// URL is the URL of "http" and "redis" checks.
+	URL string `json:"url,omitempty"`
+	// ExpectedStatus is the expected status code of "http" checks. Defaults to any 2xx status code.
+	ExpectedStatus int `json:"expected_status,omitempty"`
+	// Address is the host and port of "tcp" checks.
+	Address string `json:"address,omitempty"`
+	// Path is a path on the file system of "disk" checks.
+	Path string `json:"path,omitempty"`
+	// MinFreeBytes is the minimum amount of free space required by "disk" checks.
+	MinFreeBytes uint64 `json:"min_free_bytes,omitempty"`
+}
+
+// NewCheckers constructs the built-in checkers described by the given configuration. The "sql"
+// checks open a connection pool of at most one connection which is never closed.
+func NewCheckers(configs []CheckerConfig) (ReadyCheckers, error) {
+	checks := make(ReadyCheckers, len(configs))
+	for _, c := range configs {
+		if c.Name == "" {
+			return nil, errors.Errorf("health check of type %q has no name", c.Type)
+		} else if _, ok := checks[c.Name]; ok {
+			return nil, errors.Errorf("health check %q is configured more than once", c.Name)
+		}
+
+		var check ReadyChecker
+		switch c.Type {
+		case "sql":
+			db, err := sql.Open(c.Driver, c.DSN)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to configure health check %q", c.Name)
+			}
+			db.SetMaxOpenConns(1)
+			db.SetConnMaxIdleTime(time.Minute)
+			check = SQLChecker(db)
+		case "http":
+			if c.URL == "" {
+				return nil, errors.Errorf("health check %q requires an url", c.Name)
+			}
+			check = HTTPChecker(nil, c.URL, c.ExpectedStatus)
+		case "tcp":
+			if c.Address == "" {
+				return nil, errors.Errorf("health check %q requires an address", c.Name)
+			}
+			check = TCPChecker(c.Address)
+		case "disk":
+			if c.Path == "" {
+				return nil, errors.Errorf("health check %q requires a path", c.Name)
+			}
+			check = DiskChecker(c.Path, c.MinFreeBytes)
+		case "redis":
+			var err error
+			if check, err = RedisChecker(c.URL); err != nil {
+				return nil, errors.WithMessagef(err, "unable to configure health check %q", c.Name)
+			}
+		default:
+			return nil, errors.Errorf("health check %q has unknown type %q", c.Name, c.Type)
+		}
+		checks[c.Name] = check
+	}
+	return checks, nil
+}
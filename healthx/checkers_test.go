@@ -0,0 +1,165 @@
+package healthx
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSQLConn struct {
+	pingErr, execErr error
+	queries          []string
+}
+
+func (c *fakeSQLConn) PingContext(context.Context) error {
+	return c.pingErr
+}
+
+func (c *fakeSQLConn) ExecContext(_ context.Context, query string, _ ...interface{}) (sql.Result, error) {
+	c.queries = append(c.queries, query)
+	return nil, c.execErr
+}
+
+// fakeRedis accepts a single connection and replies to each command with the next reply.
+func fakeRedis(t *testing.T, replies ...string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rd := bufio.NewReader(conn)
+		for _, reply := range replies {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				return
+			}
+			var n int
+			_, _ = fmt.Sscanf(line, "*%d", &n)
+			for i := 0; i < 2*n; i++ {
+				_, _ = rd.ReadString('\n')
+			}
+			_, _ = conn.Write([]byte(reply + "\r\n"))
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestCheckers(t *testing.T) {
+	req := httptest.NewRequest("GET", ReadyCheckPath, nil)
+
+	t.Run("checker=sql", func(t *testing.T) {
+		db := new(fakeSQLConn)
+		require.NoError(t, SQLChecker(db)(req))
+		assert.Equal(t, []string{"SELECT 1"}, db.queries)
+
+		db.execErr = errors.New("read-only")
+		assert.EqualError(t, SQLChecker(db)(req), "unable to query database: read-only")
+
+		db.pingErr = errors.New("refused")
+		assert.EqualError(t, SQLChecker(db)(req), "unable to ping database: refused")
+	})
+
+	t.Run("checker=http", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/teapot" {
+				w.WriteHeader(http.StatusTeapot)
+			}
+		}))
+		t.Cleanup(ts.Close)
+
+		assert.NoError(t, HTTPChecker(nil, ts.URL, 0)(req))
+		assert.NoError(t, HTTPChecker(ts.Client(), ts.URL+"/teapot", http.StatusTeapot)(req))
+		assert.EqualError(t, HTTPChecker(nil, ts.URL+"/teapot", 0)(req), "expected "+ts.URL+"/teapot to respond with status 2xx but got 418")
+		assert.EqualError(t, HTTPChecker(nil, ts.URL, http.StatusNoContent)(req), "expected "+ts.URL+" to respond with status 204 but got 200")
+	})
+
+	t.Run("checker=tcp", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+
+		assert.NoError(t, TCPChecker(addr)(req))
+		require.NoError(t, l.Close())
+		assert.Error(t, TCPChecker(addr)(req))
+	})
+
+	t.Run("checker=disk", func(t *testing.T) {
+		assert.NoError(t, DiskChecker(t.TempDir(), 1)(req))
+		err := DiskChecker(t.TempDir(), 1<<62)(req)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "bytes of disk space are available")
+	})
+
+	t.Run("checker=redis", func(t *testing.T) {
+		for k, tc := range []struct {
+			url     string
+			replies []string
+			err     string
+		}{
+			{url: "redis://%s", replies: []string{"+PONG"}},
+			{url: "redis://:secret@%s", replies: []string{"+OK", "+PONG"}},
+			{url: "redis://:wrong@%s", replies: []string{"-WRONGPASS invalid password"}, err: "unable to authenticate with redis: WRONGPASS invalid password"},
+			{url: "redis://%s", replies: []string{"-LOADING Redis is loading the dataset in memory"}, err: "unable to ping redis: LOADING Redis is loading the dataset in memory"},
+		} {
+			t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+				check, err := RedisChecker(fmt.Sprintf(tc.url, fakeRedis(t, tc.replies...)))
+				require.NoError(t, err)
+				err = check(req)
+				if tc.err == "" {
+					assert.NoError(t, err)
+				} else {
+					assert.EqualError(t, err, tc.err)
+				}
+			})
+		}
+
+		_, err := RedisChecker("http://localhost")
+		assert.Error(t, err)
+	})
+}
+
+func TestNewCheckers(t *testing.T) {
+	checks, err := NewCheckers([]CheckerConfig{
+		{Name: "api", Type: "http", URL: "http://localhost"},
+		{Name: "port", Type: "tcp", Address: "localhost:80"},
+		{Name: "disk", Type: "disk", Path: "/", MinFreeBytes: 1024},
+		{Name: "cache", Type: "redis", URL: "redis://localhost"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, checks, 4)
+
+	for k, tc := range []struct {
+		configs []CheckerConfig
+		err     string
+	}{
+		{configs: []CheckerConfig{{Type: "tcp", Address: "localhost:80"}}, err: "has no name"},
+		{configs: []CheckerConfig{{Name: "a", Type: "tcp", Address: "localhost:80"}, {Name: "a", Type: "tcp", Address: "localhost:81"}}, err: "configured more than once"},
+		{configs: []CheckerConfig{{Name: "a", Type: "ftp"}}, err: "unknown type"},
+		{configs: []CheckerConfig{{Name: "a", Type: "http"}}, err: "requires an url"},
+		{configs: []CheckerConfig{{Name: "a", Type: "sql", Driver: "unknown"}}, err: "unknown driver"},
+		{configs: []CheckerConfig{{Name: "a", Type: "redis", URL: "tcp://localhost"}}, err: "scheme"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			_, err := NewCheckers(tc.configs)
+			require.Error(t, err)
+			assert.True(t, strings.Contains(err.Error(), tc.err), "%s", err)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package healthx
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+)
+
+// Authorizer returns an error if the request is not allowed to access the detailed health report.
+type Authorizer func(r *http.Request) error
+
+type checkState struct {
+	latency       time.Duration
+	checkedAt     time.Time
+	err           error
+	lastError     error
+	lastErrorAt   time.Time
+	lastSuccessAt time.Time
+}
+
+func (h *Handler) record(name string, err error, start time.Time, latency time.Duration) {
+	h.statesMu.Lock()
+	defer h.statesMu.Unlock()
+
+	if h.states == nil {
+		h.states = map[string]*checkState{}
+	}
+	s, ok := h.states[name]
+	if !ok {
+		s = new(checkState)
+		h.states[name] = s
+	}
+
+	s.latency, s.checkedAt, s.err = latency, start, err
+	if err != nil {
+		s.lastError, s.lastErrorAt = err, start
+	} else {
+		s.lastSuccessAt = start
+	}
+}
+
+func (h *Handler) report(names map[string]error) *swaggerDetailedStatus {
+	h.statesMu.Lock()
+	defer h.statesMu.Unlock()
+
+	report := &swaggerDetailedStatus{Status: "ok", Checks: make(map[string]swaggerCheckStatus, len(names))}
+	for name := range names {
+		s, ok := h.states[name]
+		if !ok {
+			continue
+		}
+
+		cs := swaggerCheckStatus{
+			Status:    "ok",
+			Latency:   float64(s.latency) / float64(time.Millisecond),
+			CheckedAt: s.checkedAt.UTC(),
+		}
+		if s.err != nil {
+			cs.Status = "error"
+			report.Status = "error"
+		}
+		if s.lastError != nil {
+			at := s.lastErrorAt.UTC()
+			cs.LastError, cs.LastErrorAt = s.lastError.Error(), &at
+		}
+		if !s.lastSuccessAt.IsZero() {
+			at := s.lastSuccessAt.UTC()
+			cs.LastSuccessAt = &at
+		}
+		report.Checks[name] = cs
+	}
+	return report
+}
+
+// BearerTokenAuthorizer returns an Authorizer which requires the request to carry the given token
+// in the Authorization header using the Bearer scheme.
+func BearerTokenAuthorizer(token string) Authorizer {
+	return func(r *http.Request) error {
+		scheme, given, _ := cut(r.Header.Get("Authorization"), " ")
+		if token == "" || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return errors.WithStack(herodot.ErrUnauthorized.WithReason("A valid bearer token is required to access the detailed health report."))
+		}
+		return nil
+	}
+}
+
+// SetDetailedHealthRoutes registers the detailed health report. Because the report includes error
+// messages which may contain sensitive information, every request must pass authorize. It panics if
+// authorize is nil.
+func (h *Handler) SetDetailedHealthRoutes(r *httprouter.Router, authorize Authorizer) {
+	r.GET(DetailedCheckPath, h.Detailed(authorize))
+}
+
+// Detailed runs all readiness checks and returns the status, latency, and last error and success
+// of each check. It panics if authorize is nil.
+//
+// swagger:route GET /health/detailed health getDetailedHealth
+//
+// Get detailed health report
+//
+// This endpoint returns the status, latency, last error, and time of the last success of each
+// readiness check. It returns a 200 status code if all checks pass and a 503 status code otherwise.
+//
+// Be aware that if you are running multiple nodes of this service, the health status will never
+// refer to the cluster state, only to a single instance.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: healthDetailedStatus
+//       401: genericError
+//       503: healthDetailedStatus
+func (h *Handler) Detailed(authorize Authorizer) httprouter.Handle {
+	if authorize == nil {
+		panic("healthx: the detailed health report requires an Authorizer")
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		if err := authorize(r); err != nil {
+			h.H.WriteError(rw, r, err)
+			return
+		}
+
+		report := h.report(h.RunChecks(r))
+		if report.Status != "ok" {
+			h.H.WriteCode(rw, r, http.StatusServiceUnavailable, report)
+			return
+		}
+		h.H.Write(rw, r, report)
+	}
+}
+
+// cut slices s around the first instance of sep. It can be replaced by strings.Cut once Go 1.18 is
+// the minimum supported version.
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,94 @@
+package healthx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+)
+
+func TestDetailed(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		dbError error
+	)
+	handler := NewHandler(herodot.NewJSONWriter(nil), "", ReadyCheckers{
+		"database": func(r *http.Request) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return dbError
+		},
+		"cache": func(r *http.Request) error { return nil },
+	})
+	router := httprouter.New()
+	handler.SetDetailedHealthRoutes(router, BearerTokenAuthorizer("secret"))
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	get := func(t *testing.T, authorization string) (int, swaggerDetailedStatus) {
+		req, err := http.NewRequest("GET", ts.URL+DetailedCheckPath, nil)
+		require.NoError(t, err)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var report swaggerDetailedStatus
+		if res.StatusCode != http.StatusUnauthorized {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+		}
+		return res.StatusCode, report
+	}
+
+	t.Run("case=unauthorized", func(t *testing.T) {
+		for _, authorization := range []string{"", "Bearer", "Bearer wrong", "secret", "Basic secret", "Bearersecret"} {
+			code, _ := get(t, authorization)
+			assert.Equal(t, http.StatusUnauthorized, code, authorization)
+		}
+	})
+
+	t.Run("case=rejects a nil authorizer", func(t *testing.T) {
+		assert.Panics(t, func() {
+			handler.SetDetailedHealthRoutes(httprouter.New(), nil)
+		})
+	})
+
+	t.Run("case=reports each check", func(t *testing.T) {
+		code, report := get(t, "Bearer secret")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", report.Status)
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, "ok", report.Checks["database"].Status)
+		assert.Empty(t, report.Checks["database"].LastError)
+		require.NotNil(t, report.Checks["database"].LastSuccessAt)
+		lastSuccess := *report.Checks["database"].LastSuccessAt
+
+		mu.Lock()
+		dbError = errors.New("no connection")
+		mu.Unlock()
+
+		code, report = get(t, "Bearer secret")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "error", report.Status)
+		assert.Equal(t, "ok", report.Checks["cache"].Status)
+
+		db := report.Checks["database"]
+		assert.Equal(t, "error", db.Status)
+		assert.Equal(t, "no connection", db.LastError)
+		require.NotNil(t, db.LastErrorAt)
+		assert.WithinDuration(t, time.Now(), *db.LastErrorAt, time.Minute)
+		require.NotNil(t, db.LastSuccessAt)
+		assert.True(t, lastSuccess.Equal(*db.LastSuccessAt))
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package healthx
+
+import "github.com/pkg/errors"
+
+func diskFree(string) (uint64, error) {
+	return 0, errors.New("determining free disk space is not supported on this platform")
+}
@@ -21,6 +21,8 @@
 // Package healthx providers helpers for returning health status information via HTTP.
 package healthx
 
+import "time"
+
 // swagger:model healthStatus
 type swaggerHealthStatus struct {
 	// Status always contains "ok".
@@ -33,8 +35,50 @@ type swaggerNotReadyStatus struct {
 	Errors map[string]string `json:"errors"`
 }
 
+// swagger:model healthDetailedStatus
+type swaggerDetailedStatus struct {
+	// Status is "ok" if all checks passed and "error" otherwise.
+	Status string `json:"status"`
+
+	// Checks contains the report of each readiness check by name.
+	Checks map[string]swaggerCheckStatus `json:"checks"`
+}
+
+// swagger:model healthCheckStatus
+type swaggerCheckStatus struct {
+	// Status is "ok" if the check passed and "error" otherwise.
+	Status string `json:"status"`
+
+	// Latency is the duration of the check in milliseconds.
+	Latency float64 `json:"latency_ms"`
+
+	// CheckedAt is the time the check was last run.
+	CheckedAt time.Time `json:"checked_at"`
+
+	// LastError is the error returned by the last failed run of the check.
+	LastError string `json:"last_error,omitempty"`
+
+	// LastErrorAt is the time of the last failed run of the check.
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+
+	// LastSuccessAt is the time of the last successful run of the check.
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+}
+
 // swagger:model version
 type swaggerVersion struct {
 	// Version is the service's version.
 	Version string `json:"version"`
+
+	// GitHash is the git commit the service was built from.
+	GitHash string `json:"git_hash,omitempty"`
+
+	// BuildTime is the time the service was built.
+	BuildTime string `json:"build_time,omitempty"`
+
+	// GoVersion is the Go version the service was built with.
+	GoVersion string `json:"go_version"`
+
+	// SchemaVersion is the version of the most recent applied database migration.
+	SchemaVersion string `json:"schema_version,omitempty"`
 }
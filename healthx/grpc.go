@@ -0,0 +1,113 @@
+package healthx
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultWatchInterval is the default interval in which Watch re-evaluates the readiness checks.
+const DefaultWatchInterval = 5 * time.Second
+
+type (
+	// GRPCHealthServer implements the gRPC health checking protocol (grpc.health.v1.Health) using
+	// the readiness checks of a Handler:
+	//
+	//	grpc_health_v1.RegisterHealthServer(s, healthx.NewGRPCHealthServer(handler))
+	//
+	// The empty service name refers to the overall readiness of the server. Any other service name
+	// refers to the readiness check registered under that name.
+	GRPCHealthServer struct {
+		grpc_health_v1.UnimplementedHealthServer
+
+		h             *Handler
+		watchInterval time.Duration
+	}
+
+	// GRPCOption configures a GRPCHealthServer.
+	GRPCOption func(*GRPCHealthServer)
+)
+
+var _ grpc_health_v1.HealthServer = (*GRPCHealthServer)(nil)
+
+// WithWatchInterval sets the interval in which Watch re-evaluates the readiness checks. Defaults
+// to DefaultWatchInterval.
+func WithWatchInterval(d time.Duration) GRPCOption {
+	return func(s *GRPCHealthServer) {
+		s.watchInterval = d
+	}
+}
+
+// NewGRPCHealthServer returns a gRPC health server backed by the readiness checks of h.
+func NewGRPCHealthServer(h *Handler, opts ...GRPCOption) *GRPCHealthServer {
+	s := &GRPCHealthServer{h: h, watchInterval: DefaultWatchInterval}
+	for _, o := range opts {
+		o(s)
+	}
+	if s.watchInterval <= 0 {
+		s.watchInterval = DefaultWatchInterval
+	}
+	return s
+}
+
+func (s *GRPCHealthServer) status(ctx context.Context, service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	checks := s.h.Checks()
+	if service != "" {
+		check, ok := checks[service]
+		if !ok {
+			return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		checks = ReadyCheckers{service: check}
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, ReadyCheckPath, nil)
+	if err != nil {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+
+	for _, err := range s.h.runChecks(r, checks) {
+		if err != nil {
+			return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
+
+// Check returns the serving status of the requested service. It returns a NotFound error if no
+// readiness check is registered for the service.
+func (s *GRPCHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	st := s.status(ctx, req.GetService())
+	if st == grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the serving status of the requested service and then sends updates whenever it
+// changes until the client disconnects. Unknown services are reported as SERVICE_UNKNOWN, as the
+// service may be registered later.
+func (s *GRPCHealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_ServingStatus(-1)
+	for {
+		if st := s.status(ctx, req.GetService()); st != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: st}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
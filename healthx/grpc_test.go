@@ -0,0 +1,105 @@
+package healthx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/ory/herodot"
+)
+
+type fakeWatchServer struct {
+	grpc.ServerStream
+	ctx context.Context
+
+	mu   sync.Mutex
+	sent []grpc_health_v1.HealthCheckResponse_ServingStatus
+}
+
+func (s *fakeWatchServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeWatchServer) Send(res *grpc_health_v1.HealthCheckResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, res.Status)
+	return nil
+}
+
+func (s *fakeWatchServer) statuses() []grpc_health_v1.HealthCheckResponse_ServingStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]grpc_health_v1.HealthCheckResponse_ServingStatus(nil), s.sent...)
+}
+
+func TestGRPCHealthServer(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		dbError = errors.New("no connection")
+	)
+	handler := NewHandler(herodot.NewJSONWriter(nil), "", ReadyCheckers{
+		"database": func(r *http.Request) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return dbError
+		},
+		"cache": func(r *http.Request) error { return nil },
+	})
+	s := NewGRPCHealthServer(handler, WithWatchInterval(10*time.Millisecond))
+
+	t.Run("method=Check", func(t *testing.T) {
+		for _, tc := range []struct {
+			service  string
+			expected grpc_health_v1.HealthCheckResponse_ServingStatus
+		}{
+			{service: "", expected: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+			{service: "database", expected: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+			{service: "cache", expected: grpc_health_v1.HealthCheckResponse_SERVING},
+		} {
+			t.Run("case=service="+tc.service, func(t *testing.T) {
+				res, err := s.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: tc.service})
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, res.Status)
+			})
+		}
+
+		t.Run("case=unknown service", func(t *testing.T) {
+			_, err := s.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+			assert.Equal(t, codes.NotFound, status.Code(err))
+		})
+	})
+
+	t.Run("method=Watch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream := &fakeWatchServer{ctx: ctx}
+
+		done := make(chan error)
+		go func() { done <- s.Watch(&grpc_health_v1.HealthCheckRequest{}, stream) }()
+
+		require.Eventually(t, func() bool { return len(stream.statuses()) == 1 }, time.Second, 5*time.Millisecond)
+
+		mu.Lock()
+		dbError = nil
+		mu.Unlock()
+
+		require.Eventually(t, func() bool { return len(stream.statuses()) == 2 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, []grpc_health_v1.HealthCheckResponse_ServingStatus{
+			grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+			grpc_health_v1.HealthCheckResponse_SERVING,
+		}, stream.statuses())
+
+		cancel()
+		assert.Equal(t, codes.Canceled, status.Code(<-done))
+	})
+}
@@ -21,7 +21,11 @@
 package healthx
 
 import (
+	"context"
 	"net/http"
+	"runtime"
+	"sync"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -33,8 +37,20 @@ const (
 	AliveCheckPath = "/health/alive"
 	// ReadyCheckPath is the path where information about the rady state of the instance is provided.
 	ReadyCheckPath = "/health/ready"
+	// StartupCheckPath is the path where information about the startup state of the instance is provided.
+	StartupCheckPath = "/health/startup"
+	// DetailedCheckPath is the path where a detailed report of all readiness checks is provided.
+	DetailedCheckPath = "/health/detailed"
 	// VersionPath is the path where information about the software version of the instance is provided.
 	VersionPath = "/version"
+
+	// DefaultCheckTimeout is the default time after which a single readiness check is considered failed.
+	DefaultCheckTimeout = 5 * time.Second
+	// DefaultTimeout is the default time after which all pending readiness checks are considered failed.
+	DefaultTimeout = 10 * time.Second
+	// DefaultSchemaVersionTTL is the duration for which the schema version reported by the version
+	// endpoint is reused.
+	DefaultSchemaVersionTTL = time.Minute
 )
 
 // RoutesToObserve returns a string of all the available routes of this module.
@@ -42,6 +58,8 @@ func RoutesToObserve() []string {
 	return []string{
 		AliveCheckPath,
 		ReadyCheckPath,
+		StartupCheckPath,
+		DetailedCheckPath,
 		VersionPath,
 	}
 }
@@ -62,6 +80,110 @@ type Handler struct {
 	H             herodot.Writer
 	VersionString string
 	ReadyChecks   ReadyCheckers
+
+	mu           sync.RWMutex
+	registered   ReadyCheckers
+	checkTimeout time.Duration
+	timeout      time.Duration
+
+	statesMu sync.Mutex
+	states   map[string]*checkState
+
+	gitHash       string
+	buildTime     string
+	schemaVersion func(r *http.Request) (string, error)
+
+	startupMu     sync.Mutex
+	startupTasks  map[string]bool
+	startupChecks ReadyCheckers
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithCheckTimeout sets the time after which a single readiness check is considered failed.
+// Defaults to DefaultCheckTimeout.
+func WithCheckTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.checkTimeout = d
+	}
+}
+
+// WithTimeout sets the time after which all readiness checks which did not complete yet are
+// considered failed. Defaults to DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = d
+	}
+}
+
+// WithBuildInfo sets the git commit and the build time reported by the version endpoint. Both
+// are usually set using -ldflags when building the binary.
+func WithBuildInfo(gitHash, buildTime string) Option {
+	return func(h *Handler) {
+		h.gitHash = gitHash
+		h.buildTime = buildTime
+	}
+}
+
+// WithSchemaVersion sets the function returning the database schema version reported by the
+// version endpoint, for example (*popx.Migrator).SchemaVersion. The schema version is omitted if
+// f returns an error.
+//
+// Because the version endpoint does not require authentication, the result of f is reused for
+// DefaultSchemaVersionTTL and refreshed in the background once it expired (see Cached).
+func WithSchemaVersion(f func(ctx context.Context) (string, error)) Option {
+	return func(h *Handler) {
+		var (
+			mu      sync.Mutex
+			version string
+		)
+		check := Cached(func(r *http.Request) error {
+			v, err := f(r.Context())
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			version = v
+			return nil
+		}, CacheOptions{TTL: DefaultSchemaVersionTTL, StaleWhileRevalidate: true})
+
+		h.schemaVersion = func(r *http.Request) (string, error) {
+			if err := check(r); err != nil {
+				return "", err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			return version, nil
+		}
+	}
+}
+
+var (
+	globalMu     sync.RWMutex
+	globalChecks = ReadyCheckers{}
+)
+
+// Register adds a named readiness check which is evaluated by every Handler in addition to its own
+// checks. It is meant for components which are initialized independently of the handler, e.g.
+//
+//	healthx.Register("database", func(r *http.Request) error {
+//		return db.PingContext(r.Context())
+//	})
+//
+// Registering a check with an existing name replaces it.
+func Register(name string, c ReadyChecker) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	globalChecks[name] = c
+}
+
+// Unregister removes a readiness check added with Register.
+func Unregister(name string) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	delete(globalChecks, name)
 }
 
 // NewHandler instantiates a handler.
@@ -69,12 +191,58 @@ func NewHandler(
 	h herodot.Writer,
 	version string,
 	readyChecks ReadyCheckers,
+	opts ...Option,
 ) *Handler {
-	return &Handler{
+	handler := &Handler{
 		H:             h,
 		VersionString: version,
 		ReadyChecks:   readyChecks,
 	}
+	for _, o := range opts {
+		o(handler)
+	}
+	return handler
+}
+
+// Register adds a named readiness check to this handler. It is safe to call while the handler
+// is serving requests. Registering a check with an existing name replaces it.
+func (h *Handler) Register(name string, c ReadyChecker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.registered == nil {
+		h.registered = ReadyCheckers{}
+	}
+	h.registered[name] = c
+}
+
+// Unregister removes a readiness check added with Handler.Register.
+func (h *Handler) Unregister(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.registered, name)
+}
+
+// Checks returns all readiness checks of this handler: the globally registered checks, the
+// ReadyChecks given at construction time, and the checks registered with Handler.Register, in
+// ascending order of precedence if names collide.
+func (h *Handler) Checks() ReadyCheckers {
+	checks := ReadyCheckers{}
+
+	globalMu.RLock()
+	for n, c := range globalChecks {
+		checks[n] = c
+	}
+	globalMu.RUnlock()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for n, c := range h.ReadyChecks {
+		checks[n] = c
+	}
+	for n, c := range h.registered {
+		checks[n] = c
+	}
+	return checks
 }
 
 // SetHealthRoutes registers this handler's routes for health checking.
@@ -83,7 +251,7 @@ func (h *Handler) SetHealthRoutes(r *httprouter.Router, shareErrors bool) {
 	r.GET(ReadyCheckPath, h.Ready(shareErrors))
 }
 
-// SetHealthRoutes registers this handler's routes for health checking.
+// SetVersionRoutes registers this handler's routes for reporting the version.
 func (h *Handler) SetVersionRoutes(r *httprouter.Router) {
 	r.GET(VersionPath, h.Version)
 }
@@ -142,8 +310,8 @@ func (h *Handler) Ready(shareErrors bool) httprouter.Handle {
 			Errors: map[string]string{},
 		}
 
-		for n, c := range h.ReadyChecks {
-			if err := c(r); err != nil {
+		for n, err := range h.RunChecks(r) {
+			if err != nil {
 				if shareErrors {
 					notReady.Errors[n] = err.Error()
 				} else {
@@ -169,7 +337,9 @@ func (h *Handler) Ready(shareErrors bool) httprouter.Handle {
 //
 // Get service version
 //
-// This endpoint returns the service version typically notated using semantic versioning.
+// This endpoint returns the service version typically notated using semantic versioning, as well as
+// the git commit, build time, and Go version of the build and, if available, the version of the
+// database schema.
 //
 // If the service supports TLS Edge Termination, this endpoint does not require the
 // `X-Forwarded-Proto` header to be set.
@@ -183,7 +353,19 @@ func (h *Handler) Ready(shareErrors bool) httprouter.Handle {
 //	   Responses:
 // 			200: version
 func (h *Handler) Version(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	h.H.Write(rw, r, &swaggerVersion{
-		Version: h.VersionString,
-	})
+	v := &swaggerVersion{
+		Version:   h.VersionString,
+		GitHash:   h.gitHash,
+		BuildTime: h.buildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if h.schemaVersion != nil {
+		// The version is reported even if the database is unavailable.
+		if sv, err := h.schemaVersion(r); err == nil {
+			v.SchemaVersion = sv
+		}
+	}
+
+	h.H.Write(rw, r, v)
 }
@@ -21,12 +21,16 @@
 package healthx
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
@@ -82,3 +86,115 @@ func TestHealth(t *testing.T) {
 	require.NoError(t, json.NewDecoder(response.Body).Decode(&versionBody))
 	require.EqualValues(t, versionBody.Version, handler.VersionString)
 }
+
+func TestRegister(t *testing.T) {
+	handler := NewHandler(herodot.NewJSONWriter(nil), "test version", ReadyCheckers{
+		"static": func(r *http.Request) error { return nil },
+	})
+	router := httprouter.New()
+	handler.SetHealthRoutes(router, true)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	ready := func(t *testing.T) (int, string) {
+		res, err := http.Get(ts.URL + ReadyCheckPath)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		out, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, strings.TrimSpace(string(out))
+	}
+
+	code, _ := ready(t)
+	assert.Equal(t, http.StatusOK, code)
+
+	t.Run("case=handler", func(t *testing.T) {
+		handler.Register("database", func(r *http.Request) error { return errors.New("no connection") })
+		code, body := ready(t)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, `{"errors":{"database":"no connection"}}`, body)
+
+		handler.Unregister("database")
+		code, _ = ready(t)
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("case=global", func(t *testing.T) {
+		Register("queue", func(r *http.Request) error { return errors.New("not connected") })
+		t.Cleanup(func() { Unregister("queue") })
+
+		code, body := ready(t)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, `{"errors":{"queue":"not connected"}}`, body)
+	})
+
+	t.Run("case=handler checks take precedence", func(t *testing.T) {
+		Register("static", func(r *http.Request) error { return errors.New("overridden") })
+		t.Cleanup(func() { Unregister("static") })
+
+		code, _ := ready(t)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, handler.Checks(), 1)
+	})
+}
+
+func TestVersion(t *testing.T) {
+	for k, tc := range []struct {
+		opts     []Option
+		expected swaggerVersion
+	}{
+		{
+			expected: swaggerVersion{Version: "v1.2.3", GoVersion: runtime.Version()},
+		},
+		{
+			opts: []Option{
+				WithBuildInfo("abcdef", "2021-11-05T10:00:00Z"),
+				WithSchemaVersion(func(context.Context) (string, error) { return "20211105000000", nil }),
+			},
+			expected: swaggerVersion{Version: "v1.2.3", GitHash: "abcdef", BuildTime: "2021-11-05T10:00:00Z", GoVersion: runtime.Version(), SchemaVersion: "20211105000000"},
+		},
+		{
+			opts: []Option{
+				WithSchemaVersion(func(context.Context) (string, error) { return "", errors.New("database unavailable") }),
+			},
+			expected: swaggerVersion{Version: "v1.2.3", GoVersion: runtime.Version()},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			router := httprouter.New()
+			NewHandler(herodot.NewJSONWriter(nil), "v1.2.3", nil, tc.opts...).SetVersionRoutes(router)
+			ts := httptest.NewServer(router)
+			t.Cleanup(ts.Close)
+
+			res, err := http.Get(ts.URL + VersionPath)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.Equal(t, http.StatusOK, res.StatusCode)
+
+			var actual swaggerVersion
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&actual))
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("case=schema version is cached", func(t *testing.T) {
+		var calls int32
+		router := httprouter.New()
+		NewHandler(herodot.NewJSONWriter(nil), "v1.2.3", nil, WithSchemaVersion(func(context.Context) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			return "20211105000000", nil
+		})).SetVersionRoutes(router)
+		ts := httptest.NewServer(router)
+		t.Cleanup(ts.Close)
+
+		for i := 0; i < 5; i++ {
+			res, err := http.Get(ts.URL + VersionPath)
+			require.NoError(t, err)
+			var actual swaggerVersion
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&actual))
+			require.NoError(t, res.Body.Close())
+			assert.Equal(t, "20211105000000", actual.SchemaVersion)
+		}
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+}
@@ -0,0 +1,130 @@
+package healthx
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+)
+
+var errStartupTaskPending = errors.New("startup task has not completed yet")
+
+// AddStartupTask registers a one-time initialization step, for example applying migrations or
+// warming caches. The startup endpoint reports the instance as not started until the returned
+// function was called. Calling it more than once has no effect.
+//
+//	done := h.AddStartupTask("migrations")
+//	if err := migrate(ctx); err != nil {
+//		return err
+//	}
+//	done()
+func (h *Handler) AddStartupTask(name string) (done func()) {
+	h.startupMu.Lock()
+	defer h.startupMu.Unlock()
+	if h.startupTasks == nil {
+		h.startupTasks = map[string]bool{}
+	}
+	h.startupTasks[name] = false
+
+	return func() {
+		h.startupMu.Lock()
+		defer h.startupMu.Unlock()
+		h.startupTasks[name] = true
+	}
+}
+
+// AddStartupCheck registers a check which must pass once before the instance is reported as
+// started, for example fetching a JSON Web Key Set. Once the check passed, it is not run again.
+func (h *Handler) AddStartupCheck(name string, c ReadyChecker) {
+	h.startupMu.Lock()
+	defer h.startupMu.Unlock()
+	if h.startupChecks == nil {
+		h.startupChecks = ReadyCheckers{}
+	}
+	h.startupChecks[name] = c
+}
+
+// startupErrors returns the pending startup tasks and failed startup checks.
+func (h *Handler) startupErrors(r *http.Request) map[string]error {
+	h.startupMu.Lock()
+	pending := map[string]error{}
+	for name, done := range h.startupTasks {
+		if !done {
+			pending[name] = errStartupTaskPending
+		}
+	}
+	checks := make(ReadyCheckers, len(h.startupChecks))
+	for name, c := range h.startupChecks {
+		checks[name] = c
+	}
+	h.startupMu.Unlock()
+
+	if len(checks) == 0 {
+		return pending
+	}
+
+	for name, err := range h.runChecks(r, checks) {
+		if err != nil {
+			pending[name] = err
+			continue
+		}
+
+		// Startup checks only need to pass once.
+		h.startupMu.Lock()
+		delete(h.startupChecks, name)
+		h.startupMu.Unlock()
+	}
+	return pending
+}
+
+// SetStartupRoutes registers this handler's route for reporting the startup status.
+func (h *Handler) SetStartupRoutes(r *httprouter.Router, shareErrors bool) {
+	r.GET(StartupCheckPath, h.Startup(shareErrors))
+}
+
+// Startup returns an ok status once all startup tasks completed and all startup checks passed.
+//
+// swagger:route GET /health/startup health isInstanceStarted
+//
+// Check startup status
+//
+// This endpoint returns a 200 status code once the one-time initialization of the instance (e.g.
+// applying migrations or warming caches) has completed. Unlike the readiness status, the startup
+// status never reverts.
+//
+// If the service supports TLS Edge Termination, this endpoint does not require the
+// `X-Forwarded-Proto` header to be set.
+//
+// Be aware that if you are running multiple nodes of this service, the health status will never
+// refer to the cluster state, only to a single instance.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: healthStatus
+//       503: healthNotReadyStatus
+func (h *Handler) Startup(shareErrors bool) httprouter.Handle {
+	return func(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		var notStarted = swaggerNotReadyStatus{
+			Errors: map[string]string{},
+		}
+
+		for n, err := range h.startupErrors(r) {
+			if shareErrors || err == errStartupTaskPending {
+				notStarted.Errors[n] = err.Error()
+			} else {
+				notStarted.Errors[n] = "error may contain sensitive information and was obfuscated"
+			}
+		}
+
+		if len(notStarted.Errors) > 0 {
+			h.H.WriteCode(rw, r, http.StatusServiceUnavailable, notStarted)
+			return
+		}
+
+		h.H.Write(rw, r, &swaggerHealthStatus{
+			Status: "ok",
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package healthx
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+)
+
+func TestStartup(t *testing.T) {
+	handler := NewHandler(herodot.NewJSONWriter(nil), "", nil)
+	router := httprouter.New()
+	handler.SetHealthRoutes(router, true)
+	handler.SetStartupRoutes(router, true)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	started := func(t *testing.T) (int, string) {
+		res, err := http.Get(ts.URL + StartupCheckPath)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		out, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, strings.TrimSpace(string(out))
+	}
+
+	code, _ := started(t)
+	assert.Equal(t, http.StatusOK, code, "no startup tasks means started")
+
+	migrated := handler.AddStartupTask("migrations")
+	jwksErr := errors.New("unable to fetch keys")
+	var jwksCalls int
+	handler.AddStartupCheck("jwks", func(r *http.Request) error {
+		jwksCalls++
+		return jwksErr
+	})
+
+	code, body := started(t)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, `{"errors":{"jwks":"unable to fetch keys","migrations":"startup task has not completed yet"}}`, body)
+
+	migrated()
+	jwksErr = nil
+	code, _ = started(t)
+	assert.Equal(t, http.StatusOK, code)
+
+	jwksErr = errors.New("unable to fetch keys")
+	code, _ = started(t)
+	assert.Equal(t, http.StatusOK, code, "startup checks only need to pass once")
+	assert.Equal(t, 2, jwksCalls)
+}
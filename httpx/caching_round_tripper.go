@@ -0,0 +1,369 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// CacheStore stores serialized responses of the CachingRoundTripper.
+	CacheStore interface {
+		Get(key string) ([]byte, bool)
+		Set(key string, value []byte)
+		Delete(key string)
+	}
+
+	// CachingRoundTripper caches responses to GET requests according to RFC 7234, see
+	// NewCachingRoundTripper.
+	CachingRoundTripper struct {
+		rt    http.RoundTripper
+		store CacheStore
+		now   func() time.Time
+	}
+
+	cacheEntry struct {
+		StoredAt time.Time         `json:"stored_at"`
+		Status   int               `json:"status"`
+		Header   http.Header       `json:"header"`
+		Body     []byte            `json:"body"`
+		Vary     map[string]string `json:"vary,omitempty"`
+	}
+
+	memoryCacheStore struct {
+		sync.RWMutex
+		entries map[string][]byte
+	}
+
+	diskCacheStore struct {
+		dir string
+	}
+)
+
+var _ http.RoundTripper = (*CachingRoundTripper)(nil)
+
+// NewMemoryCacheStore returns a CacheStore keeping responses in memory.
+func NewMemoryCacheStore() CacheStore {
+	return &memoryCacheStore{entries: map[string][]byte{}}
+}
+
+func (s *memoryCacheStore) Get(key string) ([]byte, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	v, ok := s.entries[key]
+	return v, ok
+}
+
+func (s *memoryCacheStore) Set(key string, value []byte) {
+	s.Lock()
+	defer s.Unlock()
+	s.entries[key] = value
+}
+
+func (s *memoryCacheStore) Delete(key string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.entries, key)
+}
+
+// NewDiskCacheStore returns a CacheStore keeping responses in files in dir, which is created if it
+// does not exist, so that cached responses survive restarts.
+func NewDiskCacheStore(dir string) (CacheStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &diskCacheStore{dir: dir}, nil
+}
+
+func (s *diskCacheStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+func (s *diskCacheStore) Get(key string) ([]byte, bool) {
+	v, err := ioutil.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func (s *diskCacheStore) Set(key string, value []byte) {
+	f, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return
+	}
+	// Renaming is atomic, so concurrent readers never observe partially written entries.
+	if err := os.Rename(f.Name(), s.path(key)); err != nil {
+		_ = os.Remove(f.Name())
+	}
+}
+
+func (s *diskCacheStore) Delete(key string) {
+	_ = os.Remove(s.path(key))
+}
+
+// NewCachingRoundTripper returns a RoundTripper which caches responses to GET requests in store,
+// which is useful for documents which are fetched repeatedly such as OpenID Connect discovery
+// documents, JSON Web Key Sets, and remote JSON Schemas.
+//
+// Responses are cached as long as they are fresh according to the Cache-Control max-age directive
+// or the Expires header. Stale responses with an ETag or Last-Modified header are revalidated
+// using a conditional request. Responses with Cache-Control: no-store are never cached, and
+// requests with Cache-Control: no-cache always revalidate. Cached responses carry an Age header.
+//
+// The cache is shared by all callers of the round tripper, so responses with Cache-Control: private
+// are never cached (RFC 7234, section 3). Requests with an Authorization header are not answered from
+// the cache, and their responses are only cached if they are marked as cacheable by shared caches
+// using the public, s-maxage, or must-revalidate directives (RFC 7234, section 3.2).
+//
+// If rt is nil, http.DefaultTransport is used.
+func NewCachingRoundTripper(rt http.RoundTripper, store CacheStore) *CachingRoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &CachingRoundTripper{rt: rt, store: store, now: time.Now}
+}
+
+func (c *CachingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqCC := parseCacheControl(req.Header)
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return c.rt.RoundTrip(req)
+	}
+	if _, ok := reqCC["no-store"]; ok {
+		return c.rt.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	if req.Header.Get("Authorization") != "" {
+		// Responses to other callers must not be served to this one, and vice versa.
+		return c.fetch(key, req)
+	}
+
+	entry := c.load(key, req)
+	if entry == nil {
+		return c.fetch(key, req)
+	}
+
+	if _, noCache := reqCC["no-cache"]; !noCache && c.age(entry) < entry.freshness() {
+		return c.response(entry, req), nil
+	}
+
+	etag, lastModified := entry.Header.Get("ETag"), entry.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return c.fetch(key, req)
+	}
+
+	conditional := req.Clone(req.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	res, err := c.fetch(key, conditional)
+	if err != nil || res.StatusCode != http.StatusNotModified {
+		return res, err
+	}
+	_ = res.Body.Close()
+
+	// The 304 response updates the headers of the stored response.
+	for k, v := range res.Header {
+		entry.Header[k] = v
+	}
+	entry.StoredAt = c.now()
+	c.save(key, entry)
+	return c.response(entry, req), nil
+}
+
+// fetch sends the request and stores the response if it is cacheable.
+func (c *CachingRoundTripper) fetch(key string, req *http.Request) (*http.Response, error) {
+	res, err := c.rt.RoundTrip(req)
+	if err != nil || res.StatusCode == http.StatusNotModified {
+		return res, err
+	}
+
+	if !isCacheable(req, res) {
+		// Responses to authorized requests do not replace the response shared by other callers.
+		if res.StatusCode < 500 && req.Header.Get("Authorization") == "" {
+			c.store.Delete(key)
+		}
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	entry := &cacheEntry{StoredAt: c.now(), Status: res.StatusCode, Header: res.Header.Clone(), Body: body}
+	for _, v := range res.Header.Values("Vary") {
+		for _, h := range strings.Split(v, ",") {
+			if h = http.CanonicalHeaderKey(strings.TrimSpace(h)); h != "" {
+				if entry.Vary == nil {
+					entry.Vary = map[string]string{}
+				}
+				entry.Vary[h] = req.Header.Get(h)
+			}
+		}
+	}
+	c.save(key, entry)
+	return res, nil
+}
+
+func (c *CachingRoundTripper) load(key string, req *http.Request) *cacheEntry {
+	raw, ok := c.store.Get(key)
+	if !ok {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		c.store.Delete(key)
+		return nil
+	}
+
+	for h, v := range entry.Vary {
+		if req.Header.Get(h) != v {
+			return nil
+		}
+	}
+	return &entry
+}
+
+func (c *CachingRoundTripper) save(key string, entry *cacheEntry) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.store.Set(key, raw)
+}
+
+func (c *CachingRoundTripper) age(entry *cacheEntry) time.Duration {
+	age := c.now().Sub(entry.StoredAt)
+	if initial, err := strconv.Atoi(entry.Header.Get("Age")); err == nil && initial > 0 {
+		age += time.Duration(initial) * time.Second
+	}
+	return age
+}
+
+func (c *CachingRoundTripper) response(entry *cacheEntry, req *http.Request) *http.Response {
+	header := entry.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(c.age(entry).Seconds())))
+	return &http.Response{
+		Status:        strconv.Itoa(entry.Status) + " " + http.StatusText(entry.Status),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+// freshness returns the freshness lifetime of the response.
+func (e *cacheEntry) freshness() time.Duration {
+	cc := parseCacheControl(e.Header)
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	// The s-maxage directive takes precedence over max-age for shared caches.
+	if v, ok := cc["s-maxage"]; ok {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+	if v, ok := cc["max-age"]; ok {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+
+	expires, err := http.ParseTime(e.Header.Get("Expires"))
+	if err != nil {
+		return 0
+	}
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.StoredAt
+	}
+	return expires.Sub(date)
+}
+
+func isCacheable(req *http.Request, res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+
+	cc := parseCacheControl(res.Header)
+	for _, d := range []string{"no-store", "private"} {
+		if _, ok := cc[d]; ok {
+			return false
+		}
+	}
+	if req.Header.Get("Authorization") != "" {
+		_, public := cc["public"]
+		_, sMaxAge := cc["s-maxage"]
+		_, mustRevalidate := cc["must-revalidate"]
+		if !public && !sMaxAge && !mustRevalidate {
+			return false
+		}
+	}
+	for _, v := range res.Header.Values("Vary") {
+		if strings.TrimSpace(v) == "*" {
+			return false
+		}
+	}
+
+	_, maxAge := cc["max-age"]
+	_, sMaxAge := cc["s-maxage"]
+	_, noCache := cc["no-cache"]
+	return maxAge || sMaxAge || noCache || res.Header.Get("Expires") != "" ||
+		res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != ""
+}
+
+func parseCacheControl(h http.Header) map[string]string {
+	cc := map[string]string{}
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			if kv := strings.SplitN(d, "=", 2); len(kv) == 2 {
+				cc[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+			} else {
+				cc[strings.ToLower(d)] = ""
+			}
+		}
+	}
+	return cc
+}
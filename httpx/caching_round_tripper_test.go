@@ -0,0 +1,135 @@
+package httpx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingRoundTripper(t *testing.T) {
+	var hits, revalidations int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch r.URL.Path {
+		case "/max-age":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&revalidations, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/authorized":
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+			return
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	t.Cleanup(ts.Close)
+
+	dir := t.TempDir()
+	disk, err := NewDiskCacheStore(dir)
+	require.NoError(t, err)
+
+	for name, store := range map[string]CacheStore{"memory": NewMemoryCacheStore(), "disk": disk} {
+		t.Run("store="+name, func(t *testing.T) {
+			rt := NewCachingRoundTripper(nil, store)
+			now := time.Now()
+			rt.now = func() time.Time { return now }
+			c := &http.Client{Transport: rt}
+
+			get := func(t *testing.T, path string, header http.Header) string {
+				req, err := http.NewRequest("GET", ts.URL+path, nil)
+				require.NoError(t, err)
+				for k, v := range header {
+					req.Header[k] = v
+				}
+				res, err := c.Do(req)
+				require.NoError(t, err)
+				defer res.Body.Close()
+				body, err := ioutil.ReadAll(res.Body)
+				require.NoError(t, err)
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				return string(body)
+			}
+
+			t.Run("case=fresh responses are served from the cache", func(t *testing.T) {
+				atomic.StoreInt32(&hits, 0)
+				assert.Equal(t, "/max-age", get(t, "/max-age", nil))
+				assert.Equal(t, "/max-age", get(t, "/max-age", nil))
+				assert.EqualValues(t, 1, atomic.LoadInt32(&hits))
+
+				now = now.Add(61 * time.Second)
+				assert.Equal(t, "/max-age", get(t, "/max-age", nil))
+				assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+
+				get(t, "/max-age", http.Header{"Cache-Control": {"no-cache"}})
+				assert.EqualValues(t, 3, atomic.LoadInt32(&hits))
+			})
+
+			t.Run("case=stale responses are revalidated", func(t *testing.T) {
+				atomic.StoreInt32(&revalidations, 0)
+				assert.Equal(t, "/etag", get(t, "/etag", nil))
+				assert.Equal(t, "/etag", get(t, "/etag", nil))
+				assert.Equal(t, "/etag", get(t, "/etag", nil))
+				assert.EqualValues(t, 2, atomic.LoadInt32(&revalidations))
+			})
+
+			t.Run("case=no-store responses are not cached", func(t *testing.T) {
+				atomic.StoreInt32(&hits, 0)
+				get(t, "/no-store", nil)
+				get(t, "/no-store", nil)
+				assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+			})
+
+			t.Run("case=private responses are not cached", func(t *testing.T) {
+				atomic.StoreInt32(&hits, 0)
+				get(t, "/private", nil)
+				get(t, "/private", nil)
+				assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+			})
+
+			t.Run("case=authorized requests", func(t *testing.T) {
+				atomic.StoreInt32(&hits, 0)
+				assert.Equal(t, "", get(t, "/authorized", nil))
+				assert.Equal(t, "Bearer alice", get(t, "/authorized", http.Header{"Authorization": {"Bearer alice"}}))
+				assert.Equal(t, "Bearer bob", get(t, "/authorized", http.Header{"Authorization": {"Bearer bob"}}))
+				assert.Equal(t, "", get(t, "/authorized", nil), "responses to authorized requests are not shared")
+				assert.EqualValues(t, 3, atomic.LoadInt32(&hits))
+
+				atomic.StoreInt32(&hits, 0)
+				get(t, "/public", http.Header{"Authorization": {"Bearer alice"}})
+				get(t, "/public", nil)
+				assert.EqualValues(t, 1, atomic.LoadInt32(&hits), "public responses to authorized requests are cached")
+			})
+
+			t.Run("case=vary", func(t *testing.T) {
+				atomic.StoreInt32(&hits, 0)
+				assert.Equal(t, "en", get(t, "/vary", http.Header{"Accept-Language": {"en"}}))
+				assert.Equal(t, "en", get(t, "/vary", http.Header{"Accept-Language": {"en"}}))
+				assert.Equal(t, "de", get(t, "/vary", http.Header{"Accept-Language": {"de"}}))
+				assert.EqualValues(t, 2, atomic.LoadInt32(&hits))
+			})
+		})
+	}
+}
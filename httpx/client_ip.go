@@ -0,0 +1,148 @@
+package httpx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type clientIPContextKey struct{}
+
+// ParseTrustedProxies parses a list of CIDRs, e.g. `10.0.0.0/8`, or single IP addresses into networks
+// which can be passed to ClientIP.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, errors.Errorf("trusted proxy %q is neither an IP address nor a CIDR", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "trusted proxy %q is neither an IP address nor a CIDR", p)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// ClientIP returns the IP address of the client which sent the request.
+//
+// The `Forwarded` header, or if it is absent the `X-Forwarded-For` header, is only taken into account if the
+// request was received from one of the trusted proxies. The addresses in the header are then checked from right
+// to left, and the first one not belonging to a trusted proxy is returned. This prevents clients from spoofing
+// their address by sending these headers themselves.
+//
+// If the peer's address can not be parsed, for example because the request was received on a unix socket,
+// r.RemoteAddr is returned as is.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	peer := parseHop(r.RemoteAddr)
+	if peer == nil {
+		return r.RemoteAddr
+	}
+
+	client := peer
+	if !isTrustedProxy(client, trustedProxies) {
+		return client.String()
+	}
+
+	hops := forwardedFor(r.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseHop(hops[i])
+		if hop == nil {
+			// Everything left of a malformed entry can not be trusted.
+			break
+		}
+		client = hop
+		if !isTrustedProxy(client, trustedProxies) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+// ClientIPFromContext returns the client IP address resolved by the ClientIPMiddleware. The second return
+// value is false if the middleware did not handle the request.
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPContextKey{}).(string)
+	return ip, ok
+}
+
+// ClientIPMiddleware resolves the client IP address of each request using ClientIP. Handlers can retrieve
+// it using ClientIPFromContext. The RateLimiter uses it to identify clients.
+type ClientIPMiddleware struct {
+	trustedProxies []*net.IPNet
+}
+
+// NewClientIPMiddleware returns a middleware which resolves the client IP address of each request, trusting
+// the forwarding headers set by the given proxies.
+func NewClientIPMiddleware(trustedProxies []*net.IPNet) *ClientIPMiddleware {
+	return &ClientIPMiddleware{trustedProxies: trustedProxies}
+}
+
+func (m *ClientIPMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	next(w, r.WithContext(context.WithValue(r.Context(), clientIPContextKey{}, ClientIP(r, m.trustedProxies))))
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor returns the addresses of the `for` parameters of the Forwarded header (RFC 7239), or if
+// it is absent the addresses of the X-Forwarded-For header.
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	if forwarded := h.Values("Forwarded"); len(forwarded) > 0 {
+		for _, v := range forwarded {
+			for _, element := range strings.Split(v, ",") {
+				hop := ""
+				for _, pair := range strings.Split(element, ";") {
+					kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+					if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+						hop = strings.Trim(kv[1], `"`)
+					}
+				}
+				// Elements without a for parameter are kept so they are treated as malformed.
+				hops = append(hops, hop)
+			}
+		}
+		return hops
+	}
+
+	for _, v := range h.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(v, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseHop parses an address which may contain a port and may be enclosed in brackets, e.g.
+// `192.0.2.1`, `192.0.2.1:4711`, `2001:db8::1` or `[2001:db8::1]:4711`.
+func parseHop(addr string) net.IP {
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return net.ParseIP(host)
+	}
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+}
@@ -0,0 +1,134 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	nets, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	require.NoError(t, err)
+	require.Len(t, nets, 3)
+	assert.Equal(t, "10.0.0.0/8", nets[0].String())
+	assert.Equal(t, "192.168.1.1/32", nets[1].String())
+	assert.Equal(t, "::1/128", nets[2].String())
+
+	_, err = ParseTrustedProxies([]string{"foo"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		remote   string
+		header   http.Header
+		expected string
+	}{
+		{
+			name:     "no forwarding headers",
+			remote:   "203.0.113.1:1234",
+			expected: "203.0.113.1",
+		},
+		{
+			name:     "untrusted peer",
+			remote:   "203.0.113.1:1234",
+			header:   http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			expected: "203.0.113.1",
+		},
+		{
+			name:     "trusted peer",
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			expected: "198.51.100.1",
+		},
+		{
+			name:     "spoofed header behind trusted proxies",
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1", "10.0.0.2"}},
+			expected: "198.51.100.1",
+		},
+		{
+			name:     "only trusted proxies",
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			expected: "10.0.0.3",
+		},
+		{
+			name:     "malformed entry",
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-For": {"198.51.100.1, foo, 10.0.0.2"}},
+			expected: "10.0.0.2",
+		},
+		{
+			name:   "forwarded header takes precedence",
+			remote: "10.0.0.1:1234",
+			header: http.Header{
+				"Forwarded":       {`for=198.51.100.1;proto=https, For="[2001:db8:cafe::17]:4711"`},
+				"X-Forwarded-For": {"198.51.100.2"},
+			},
+			expected: "198.51.100.1",
+		},
+		{
+			name:     "forwarded header with obfuscated identifier",
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"Forwarded": {`for=_hidden, for=10.0.0.2`}},
+			expected: "10.0.0.2",
+		},
+		{
+			name:     "ipv6 peer",
+			remote:   "[2001:db8::1]:1234",
+			header:   http.Header{"X-Forwarded-For": {"198.51.100.1:4711"}},
+			expected: "198.51.100.1",
+		},
+		{
+			name:     "unix socket",
+			remote:   "@",
+			header:   http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			expected: "@",
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remote
+			r.Header = tc.header
+			if r.Header == nil {
+				r.Header = http.Header{}
+			}
+			assert.Equal(t, tc.expected, ClientIP(r, trusted))
+		})
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	_, ok := ClientIPFromContext(r.Context())
+	assert.False(t, ok)
+
+	var (
+		actual string
+		key    string
+	)
+	limiter := NewRateLimiter(RateLimitConfig{}, NewMemoryRateLimitStore())
+	NewClientIPMiddleware(trusted).ServeHTTP(httptest.NewRecorder(), r, func(w http.ResponseWriter, r *http.Request) {
+		actual, ok = ClientIPFromContext(r.Context())
+		key = limiter.KeyFunc(r)
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "198.51.100.1", actual)
+	assert.Equal(t, "ip:198.51.100.1", key, "the rate limiter uses the resolved client IP")
+}
@@ -0,0 +1,308 @@
+package httpx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// CompressionWriter is a compressing writer which can be reused by resetting it, such as
+	// *gzip.Writer or *brotli.Writer.
+	CompressionWriter interface {
+		io.WriteCloser
+		Reset(w io.Writer)
+	}
+
+	// ResponseCompressor is a middleware compressing response bodies, see NewResponseCompressor.
+	ResponseCompressor struct {
+		minSize      int
+		contentTypes []string
+		encodings    []*compressionEncoding
+		err          error
+	}
+
+	// ResponseCompressionOption configures the ResponseCompressor.
+	ResponseCompressionOption func(c *ResponseCompressor)
+
+	compressionEncoding struct {
+		name string
+		pool sync.Pool
+	}
+)
+
+// DefaultCompressibleContentTypes are the content types compressed by default. Types ending with a
+// slash match all subtypes.
+var DefaultCompressibleContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/problem+json",
+	"application/jwk-set+json",
+	"application/javascript",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+	"image/svg+xml",
+}
+
+// ResponseCompressionWithMinSize sets the minimum size of response bodies to compress. Smaller
+// bodies are not worth the overhead. Defaults to 1024 bytes.
+func ResponseCompressionWithMinSize(size int) ResponseCompressionOption {
+	return func(c *ResponseCompressor) {
+		c.minSize = size
+	}
+}
+
+// ResponseCompressionWithContentTypes sets the content types to compress. Defaults to
+// DefaultCompressibleContentTypes.
+func ResponseCompressionWithContentTypes(types ...string) ResponseCompressionOption {
+	return func(c *ResponseCompressor) {
+		c.contentTypes = types
+	}
+}
+
+// ResponseCompressionWithGzipLevel sets the gzip compression level. Defaults to gzip.DefaultCompression.
+// NewResponseCompressor returns an error if the level is invalid.
+func ResponseCompressionWithGzipLevel(level int) ResponseCompressionOption {
+	return func(c *ResponseCompressor) {
+		if _, err := gzip.NewWriterLevel(nil, level); err != nil {
+			c.err = errors.WithStack(err)
+			return
+		}
+		for k, e := range c.encodings {
+			if e.name == "gzip" {
+				c.encodings[k] = newCompressionEncoding("gzip", func() CompressionWriter {
+					w, _ := gzip.NewWriterLevel(nil, level)
+					return w
+				})
+			}
+		}
+	}
+}
+
+// ResponseCompressionWithEncoding adds a content coding which is preferred over the previously
+// added ones if the client accepts both equally. For example, to add brotli using
+// github.com/andybalholm/brotli:
+//
+//	httpx.ResponseCompressionWithEncoding("br", func() httpx.CompressionWriter {
+//		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+//	})
+func ResponseCompressionWithEncoding(name string, newWriter func() CompressionWriter) ResponseCompressionOption {
+	return func(c *ResponseCompressor) {
+		c.encodings = append([]*compressionEncoding{newCompressionEncoding(name, newWriter)}, c.encodings...)
+	}
+}
+
+func newCompressionEncoding(name string, newWriter func() CompressionWriter) *compressionEncoding {
+	return &compressionEncoding{name: name, pool: sync.Pool{New: func() interface{} { return newWriter() }}}
+}
+
+// NewResponseCompressor returns a middleware which compresses response bodies using gzip, or any
+// encoding added with ResponseCompressionWithEncoding, if the client accepts it. Only bodies of
+// compressible content types and the minimum size are compressed. Compressing writers are pooled.
+//
+// Responses which may be compressed carry a "Vary: Accept-Encoding" header, and strong ETags of
+// compressed responses are weakened as the compressed body is not byte-for-byte identical.
+func NewResponseCompressor(opts ...ResponseCompressionOption) (*ResponseCompressor, error) {
+	c := &ResponseCompressor{
+		minSize:      1024,
+		contentTypes: DefaultCompressibleContentTypes,
+		encodings: []*compressionEncoding{newCompressionEncoding("gzip", func() CompressionWriter {
+			return gzip.NewWriter(nil)
+		})},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c, nil
+}
+
+func (c *ResponseCompressor) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	cw := &compressResponseWriter{ResponseWriter: w, c: c, encoding: c.negotiate(r), head: r.Method == http.MethodHead}
+	defer cw.close()
+	next(cw, r)
+}
+
+// Wrap returns a handler compressing the responses of h.
+func (c *ResponseCompressor) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.ServeHTTP(w, r, h.ServeHTTP)
+	})
+}
+
+// negotiate returns the accepted encoding with the highest quality, or nil.
+func (c *ResponseCompressor) negotiate(r *http.Request) *compressionEncoding {
+	accepted := map[string]float64{}
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(v, ",") {
+			params := strings.Split(part, ";")
+			name := strings.ToLower(strings.TrimSpace(params[0]))
+			q := 1.0
+			for _, p := range params[1:] {
+				if p = strings.TrimSpace(p); strings.HasPrefix(p, "q=") {
+					if parsed, err := strconv.ParseFloat(p[2:], 64); err == nil {
+						q = parsed
+					}
+				}
+			}
+			if name != "" {
+				accepted[name] = q
+			}
+		}
+	}
+
+	var best *compressionEncoding
+	var bestQ float64
+	for _, e := range c.encodings {
+		q, ok := accepted[e.name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best
+}
+
+func (c *ResponseCompressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range c.contentTypes {
+		if mediaType == t || strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	c        *ResponseCompressor
+	encoding *compressionEncoding
+	head     bool
+
+	status  int
+	buf     []byte
+	decided bool
+	w       CompressionWriter
+}
+
+var (
+	_ http.Flusher  = (*compressResponseWriter)(nil)
+	_ http.Hijacker = (*compressResponseWriter)(nil)
+)
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	w.status = status
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.w != nil {
+			return w.w.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.c.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide sends the headers and the buffered body, compressing it if possible.
+func (w *compressResponseWriter) decide(compress bool) error {
+	w.decided = true
+	h := w.Header()
+
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	eligible := h.Get("Content-Encoding") == "" && w.status != http.StatusPartialContent && w.c.compressible(h.Get("Content-Type"))
+	if eligible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	if compress && eligible && w.encoding != nil && !w.head {
+		h.Set("Content-Encoding", w.encoding.name)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.w = w.encoding.pool.Get().(CompressionWriter)
+		w.w.Reset(w.ResponseWriter)
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.w != nil {
+		_, err := w.w.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressResponseWriter) close() {
+	if !w.decided {
+		_ = w.decide(len(w.buf) >= w.c.minSize)
+	}
+	if w.w != nil {
+		_ = w.w.Close()
+		w.w.Reset(nil)
+		w.encoding.pool.Put(w.w)
+		w.w = nil
+	}
+}
+
+// Flush sends the buffered body, compressing it if eligible because the final size is unknown.
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if f, ok := w.w.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+	w.decided = true
+	return h.Hijack()
+}
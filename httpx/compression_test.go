@@ -0,0 +1,155 @@
+package httpx
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flateWriter is used as a stand-in for a second encoding such as brotli.
+type flateWriter struct {
+	*flate.Writer
+}
+
+func newFlateWriter() CompressionWriter {
+	w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+	return &flateWriter{Writer: w}
+}
+
+func TestResponseCompressor(t *testing.T) {
+	large := strings.Repeat(`{"hello":"world"}`, 100)
+
+	c, err := NewResponseCompressor(ResponseCompressionWithEncoding("deflate", newFlateWriter))
+	require.NoError(t, err)
+	handler := c.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte(large))
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("ETag", `"abc"`)
+			w.WriteHeader(http.StatusCreated)
+			for i := 0; i < 100; i++ {
+				_, _ = w.Write([]byte(`{"hello":"world"}`))
+			}
+		}
+	}))
+
+	do := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		var r io.Reader = w.Body
+		switch w.Header().Get("Content-Encoding") {
+		case "gzip":
+			gr, err := gzip.NewReader(w.Body)
+			require.NoError(t, err)
+			r = gr
+		case "deflate":
+			r = flate.NewReader(w.Body)
+		}
+		out, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	for _, tc := range []struct {
+		d, path, accept, encoding string
+		vary                      bool
+	}{
+		{d: "gzip", path: "/", accept: "gzip", encoding: "gzip", vary: true},
+		{d: "preferred encoding", path: "/", accept: "gzip, deflate", encoding: "deflate", vary: true},
+		{d: "quality values", path: "/", accept: "gzip;q=1.0, deflate;q=0.5", encoding: "gzip", vary: true},
+		{d: "rejected encoding", path: "/", accept: "gzip;q=0", encoding: "", vary: true},
+		{d: "wildcard", path: "/", accept: "*", encoding: "deflate", vary: true},
+		{d: "not accepted", path: "/", accept: "", encoding: "", vary: true},
+		{d: "too small", path: "/small", accept: "gzip", encoding: "", vary: true},
+		{d: "incompressible content type", path: "/image", accept: "gzip", encoding: "", vary: false},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			w := do(tc.path, tc.accept)
+			assert.Equal(t, tc.encoding, w.Header().Get("Content-Encoding"))
+			if tc.vary {
+				assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			} else {
+				assert.Empty(t, w.Header().Get("Vary"))
+			}
+
+			body := decode(t, w)
+			switch tc.path {
+			case "/small":
+				assert.Equal(t, `{}`, body)
+			default:
+				assert.Equal(t, large, body)
+			}
+
+			if tc.path == "/" {
+				assert.Equal(t, http.StatusCreated, w.Code)
+				if tc.encoding != "" {
+					assert.Equal(t, `W/"abc"`, w.Header().Get("ETag"))
+				} else {
+					assert.Equal(t, `"abc"`, w.Header().Get("ETag"))
+				}
+			}
+		})
+	}
+
+	t.Run("case=no content", func(t *testing.T) {
+		w := do("/no-content", "gzip")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("case=writers are reused", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			w := do("/", "gzip")
+			assert.Equal(t, large, decode(t, w))
+		}
+	})
+
+	t.Run("case=flush", func(t *testing.T) {
+		h := c.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: 1\n\n"))
+			w.(http.Flusher).Flush()
+		}))
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.True(t, w.Flushed)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "data: 1\n\n", decode(t, w))
+	})
+
+	t.Run("case=gzip level", func(t *testing.T) {
+		_, err := NewResponseCompressor(ResponseCompressionWithGzipLevel(gzip.BestSpeed))
+		require.NoError(t, err)
+
+		_, err = NewResponseCompressor(ResponseCompressionWithGzipLevel(42))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,187 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+)
+
+// DefaultMaxJSONBodySize is the default maximum size of request bodies decoded by DecodeJSON.
+const DefaultMaxJSONBodySize int64 = 1 << 20
+
+type (
+	decodeJSONOptions struct {
+		maxBodySize        int64
+		contentTypes       []string
+		allowUnknownFields bool
+		allowEmptyBody     bool
+		requireContentType bool
+	}
+	// DecodeJSONOption configures DecodeJSON.
+	DecodeJSONOption func(*decodeJSONOptions)
+)
+
+// DecodeJSONWithMaxBodySize sets the maximum size of the request body in bytes. Defaults to DefaultMaxJSONBodySize.
+func DecodeJSONWithMaxBodySize(size int64) DecodeJSONOption {
+	return func(o *decodeJSONOptions) {
+		o.maxBodySize = size
+	}
+}
+
+// DecodeJSONWithContentTypes sets the accepted content types. Defaults to `application/json`.
+func DecodeJSONWithContentTypes(contentTypes ...string) DecodeJSONOption {
+	return func(o *decodeJSONOptions) {
+		o.contentTypes = contentTypes
+	}
+}
+
+// DecodeJSONAllowUnknownFields accepts fields which do not exist in the destination.
+func DecodeJSONAllowUnknownFields() DecodeJSONOption {
+	return func(o *decodeJSONOptions) {
+		o.allowUnknownFields = true
+	}
+}
+
+// DecodeJSONAllowEmptyBody leaves the destination untouched instead of failing if the body is empty.
+func DecodeJSONAllowEmptyBody() DecodeJSONOption {
+	return func(o *decodeJSONOptions) {
+		o.allowEmptyBody = true
+	}
+}
+
+// DecodeJSONAllowMissingContentType accepts requests without a Content-Type header.
+func DecodeJSONAllowMissingContentType() DecodeJSONOption {
+	return func(o *decodeJSONOptions) {
+		o.requireContentType = false
+	}
+}
+
+// DecodeJSON decodes the JSON request body into dst. It
+//
+//   - responds with 415 Unsupported Media Type if the Content-Type is not accepted,
+//   - responds with 413 Request Entity Too Large if the body exceeds the maximum body size,
+//   - responds with 400 Bad Request if the body is empty, malformed, contains fields which do not exist in
+//     dst, or contains more than one JSON value.
+//
+// The returned errors are *herodot.DefaultError values which can be passed to a herodot.Writer. Errors
+// concerning a specific field contain its path in the `field` detail, e.g. `address.street`, and errors
+// concerning the syntax contain the byte `offset` detail.
+//
+// The ResponseWriter is required to close the connection if the body is too large, see http.MaxBytesReader.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, opts ...DecodeJSONOption) error {
+	o := &decodeJSONOptions{
+		maxBodySize:        DefaultMaxJSONBodySize,
+		contentTypes:       []string{"application/json"},
+		requireContentType: true,
+	}
+	for _, f := range opts {
+		f(o)
+	}
+
+	if r.Header.Get("Content-Type") != "" || o.requireContentType {
+		if !HasContentType(r, o.contentTypes...) {
+			return errors.WithStack(herodot.ErrUnsupportedMediaType.
+				WithReasonf(`The request body must be sent with one of the content types %s but got "%s".`, strings.Join(o.contentTypes, ", "), r.Header.Get("Content-Type")))
+		}
+	}
+
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, o.maxBodySize))
+	if !o.allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(dst); err != nil {
+		if errors.Is(err, io.EOF) && o.allowEmptyBody {
+			return nil
+		}
+		return decodeJSONError(err, o.maxBodySize)
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		if err != nil {
+			return decodeJSONError(err, o.maxBodySize)
+		}
+		return errors.WithStack(herodot.ErrBadRequest.
+			WithReason("The request body must only contain a single JSON value.").
+			WithDetail("offset", dec.InputOffset()))
+	}
+
+	return nil
+}
+
+func decodeJSONError(err error, maxBodySize int64) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, io.EOF):
+		return errors.WithStack(herodot.ErrBadRequest.
+			WithReason("The request body must not be empty."))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.WithStack(herodot.ErrBadRequest.
+			WithReason("The request body contains incomplete JSON."))
+	case errors.As(err, &syntaxErr):
+		return errors.WithStack(herodot.ErrBadRequest.
+			WithReasonf("The request body contains malformed JSON at offset %d.", syntaxErr.Offset).
+			WithDetail("offset", syntaxErr.Offset).
+			WithDebug(err.Error()))
+	case errors.As(err, &typeErr):
+		e := herodot.ErrBadRequest.
+			WithDetail("offset", typeErr.Offset).
+			WithDebug(err.Error())
+		if typeErr.Field == "" {
+			return errors.WithStack(e.WithReasonf("The request body must be of type %s but got %s.", jsonType(typeErr.Type.Kind()), typeErr.Value))
+		}
+		return errors.WithStack(e.
+			WithReasonf(`Field "%s" must be of type %s but got %s.`, typeErr.Field, jsonType(typeErr.Type.Kind()), typeErr.Value).
+			WithDetail("field", typeErr.Field))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, uerr := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		if uerr != nil {
+			field = strings.TrimPrefix(err.Error(), "json: unknown field ")
+		}
+		return errors.WithStack(herodot.ErrBadRequest.
+			WithReasonf(`The request body contains the unknown field "%s".`, field).
+			WithDetail("field", field))
+	case err.Error() == "http: request body too large":
+		e := herodot.ErrBadRequest.
+			WithReasonf("The request body must not be larger than %d bytes.", maxBodySize)
+		e.CodeField = http.StatusRequestEntityTooLarge
+		e.StatusField = http.StatusText(http.StatusRequestEntityTooLarge)
+		e.ErrorField = "The request body is too large"
+		return errors.WithStack(e)
+	}
+	return errors.WithStack(herodot.ErrBadRequest.
+		WithReasonf("Unable to decode the JSON request body: %s", err).
+		WithDebug(fmt.Sprintf("%+v", err)))
+}
+
+// jsonType maps a Go kind to the name of the JSON type it is decoded from.
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	}
+	return kind.String()
+}
@@ -0,0 +1,90 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type payload struct {
+		Name    string `json:"name"`
+		Address struct {
+			Street string `json:"street"`
+		} `json:"address"`
+		Tags []string `json:"tags"`
+	}
+
+	decode := func(t *testing.T, contentType, body string, opts ...DecodeJSONOption) (*payload, *herodot.DefaultError) {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		var p payload
+		err := DecodeJSON(httptest.NewRecorder(), r, &p, opts...)
+		if err == nil {
+			return &p, nil
+		}
+		var e *herodot.DefaultError
+		require.True(t, errors.As(err, &e), "%+v", err)
+		return &p, e
+	}
+
+	t.Run("case=decodes valid body", func(t *testing.T) {
+		p, err := decode(t, "application/json; charset=utf-8", `{"name":"foo","address":{"street":"bar"},"tags":["a"]}`)
+		require.Nil(t, err)
+		assert.Equal(t, "foo", p.Name)
+		assert.Equal(t, "bar", p.Address.Street)
+		assert.Equal(t, []string{"a"}, p.Tags)
+	})
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []DecodeJSONOption
+		code        int
+		field       string
+		reason      string
+	}{
+		{name: "missing content type", body: `{}`, code: http.StatusUnsupportedMediaType},
+		{name: "wrong content type", contentType: "text/plain", body: `{}`, code: http.StatusUnsupportedMediaType},
+		{name: "empty body", contentType: "application/json", code: http.StatusBadRequest, reason: "must not be empty"},
+		{name: "incomplete body", contentType: "application/json", body: `{"name":`, code: http.StatusBadRequest, reason: "incomplete"},
+		{name: "malformed body", contentType: "application/json", body: `{"name" "foo"}`, code: http.StatusBadRequest, reason: "malformed JSON at offset"},
+		{name: "unknown field", contentType: "application/json", body: `{"foo":"bar"}`, code: http.StatusBadRequest, field: "foo"},
+		{name: "wrong type", contentType: "application/json", body: `{"address":{"street":1}}`, code: http.StatusBadRequest, field: "address.street", reason: "must be of type string but got number"},
+		{name: "wrong root type", contentType: "application/json", body: `[]`, code: http.StatusBadRequest, reason: "must be of type object but got array"},
+		{name: "multiple values", contentType: "application/json", body: `{}{}`, code: http.StatusBadRequest, reason: "single JSON value"},
+		{name: "trailing garbage", contentType: "application/json", body: `{} foo`, code: http.StatusBadRequest, reason: "malformed JSON"},
+		{name: "body too large", contentType: "application/json", body: `{"name":"` + strings.Repeat("a", 100) + `"}`, opts: []DecodeJSONOption{DecodeJSONWithMaxBodySize(10)}, code: http.StatusRequestEntityTooLarge},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			_, err := decode(t, tc.contentType, tc.body, tc.opts...)
+			require.NotNil(t, err)
+			assert.Equal(t, tc.code, err.StatusCode())
+			if tc.field != "" {
+				assert.Equal(t, tc.field, err.Details()["field"])
+			}
+			assert.Contains(t, err.Reason(), tc.reason)
+		})
+	}
+
+	t.Run("case=options relax the checks", func(t *testing.T) {
+		p, err := decode(t, "", ``, DecodeJSONAllowMissingContentType(), DecodeJSONAllowEmptyBody())
+		require.Nil(t, err)
+		assert.Equal(t, payload{}, *p)
+
+		p, err = decode(t, "application/merge-patch+json", `{"name":"foo","foo":"bar"}`,
+			DecodeJSONAllowUnknownFields(), DecodeJSONWithContentTypes("application/merge-patch+json"))
+		require.Nil(t, err)
+		assert.Equal(t, "foo", p.Name)
+	})
+}
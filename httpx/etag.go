@@ -0,0 +1,121 @@
+package httpx
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StrongETag returns a strong entity tag for content, e.g. `"n4bQgYhMfWWaL-qgxVrQFaO_TxsrC4Is0V1sFbDwCgg"`.
+// Use it if the representation is byte-for-byte identical whenever the tag is.
+func StrongETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+}
+
+// WeakETag returns a weak entity tag for content, e.g. `W/"n4bQgYhMfWWaL-qgxVrQFaO_TxsrC4Is0V1sFbDwCgg"`.
+// Use it if semantically equivalent representations may differ in their bytes, for example because of
+// key ordering.
+func WeakETag(content []byte) string {
+	return "W/" + StrongETag(content)
+}
+
+// IsWeakETag returns true if the entity tag is weak.
+func IsWeakETag(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
+
+// ETagsMatch compares two entity tags as defined in RFC 7232, section 2.3.2. The strong comparison only
+// matches if neither tag is weak, the weak comparison ignores whether the tags are weak.
+func ETagsMatch(a, b string, strong bool) bool {
+	if strong && (IsWeakETag(a) || IsWeakETag(b)) {
+		return false
+	}
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// CheckNotModified sets the ETag and Last-Modified headers, if given, and evaluates the If-None-Match and
+// If-Modified-Since preconditions of the request as defined in RFC 7232, section 6.
+//
+// If the client's representation is up to date, it writes a 304 Not Modified response for GET and HEAD
+// requests or a 412 Precondition Failed response for other methods, and returns true. The handler must
+// not write anything else in that case:
+//
+//	etag := httpx.StrongETag(body)
+//	if httpx.CheckNotModified(w, r, etag, time.Time{}) {
+//		return
+//	}
+//	w.Write(body)
+//
+// If-None-Match uses the weak comparison, so tags weakened by the ResponseCompressor still match.
+func CheckNotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	safe := r.Method == "GET" || r.Method == "HEAD"
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" || !noneMatchContains(inm, etag) {
+			return false
+		}
+		if safe {
+			writeNotModified(w)
+		} else {
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+		return true
+	}
+
+	// If-Modified-Since is ignored if If-None-Match is present, for methods other than GET and HEAD, and
+	// for resources without a modification time.
+	if !safe || lastModified.IsZero() {
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// The header only has second precision.
+	if lastModified.Truncate(time.Second).After(ims) {
+		return false
+	}
+	writeNotModified(w)
+	return true
+}
+
+// noneMatchContains returns true if the If-None-Match header value is `*` or contains etag.
+func noneMatchContains(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || ETagsMatch(candidate, etag, false) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeNotModified(w http.ResponseWriter) {
+	// RFC 7232, section 4.1: a 304 response should not contain representation metadata other than the
+	// validators and the caching headers.
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// WriteWithETag writes body with a strong entity tag computed from it, or a 304 Not Modified response
+// if the client already has the current representation. It is meant for small documents polled
+// frequently, such as configuration, JSON Web Key Sets, or metadata.
+func WriteWithETag(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	if CheckNotModified(w, r, StrongETag(body), time.Time{}) {
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(body)
+}
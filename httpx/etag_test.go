@@ -0,0 +1,105 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	strong := StrongETag([]byte("foo"))
+	weak := WeakETag([]byte("foo"))
+
+	assert.Regexp(t, `^"[A-Za-z0-9_-]{43}"$`, strong)
+	assert.Equal(t, "W/"+strong, weak)
+	assert.NotEqual(t, strong, StrongETag([]byte("bar")))
+	assert.False(t, IsWeakETag(strong))
+	assert.True(t, IsWeakETag(weak))
+
+	assert.True(t, ETagsMatch(strong, strong, true))
+	assert.False(t, ETagsMatch(strong, weak, true))
+	assert.False(t, ETagsMatch(weak, weak, true))
+	assert.True(t, ETagsMatch(strong, weak, false))
+	assert.True(t, ETagsMatch(weak, weak, false))
+	assert.False(t, ETagsMatch(strong, StrongETag([]byte("bar")), false))
+}
+
+func TestCheckNotModified(t *testing.T) {
+	etag := StrongETag([]byte("foo"))
+	modified := time.Date(2021, 10, 1, 12, 0, 0, 500, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		method   string
+		header   http.Header
+		etag     string
+		modified time.Time
+		handled  bool
+		code     int
+	}{
+		{name: "no preconditions", etag: etag, modified: modified},
+		{name: "matching etag", header: http.Header{"If-None-Match": {etag}}, etag: etag, handled: true, code: http.StatusNotModified},
+		{name: "matching weakened etag", header: http.Header{"If-None-Match": {`"bar", W/` + etag}}, etag: etag, handled: true, code: http.StatusNotModified},
+		{name: "wildcard", header: http.Header{"If-None-Match": {"*"}}, etag: etag, handled: true, code: http.StatusNotModified},
+		{name: "different etag", header: http.Header{"If-None-Match": {`"bar"`}}, etag: etag},
+		{name: "matching etag on unsafe method", method: "PUT", header: http.Header{"If-None-Match": {etag}}, etag: etag, handled: true, code: http.StatusPreconditionFailed},
+		{name: "not modified since", header: http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}}, modified: modified, handled: true, code: http.StatusNotModified},
+		{name: "modified since", header: http.Header{"If-Modified-Since": {modified.Add(-time.Second).Format(http.TimeFormat)}}, modified: modified},
+		{name: "malformed date", header: http.Header{"If-Modified-Since": {"foo"}}, modified: modified},
+		{name: "if-none-match takes precedence", header: http.Header{
+			"If-None-Match":     {`"bar"`},
+			"If-Modified-Since": {modified.Format(http.TimeFormat)},
+		}, etag: etag, modified: modified},
+		{name: "if-modified-since ignored on unsafe method", method: "POST", header: http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}}, modified: modified},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = "GET"
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			for k, v := range tc.header {
+				r.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+			w.Header().Set("Content-Type", "application/json")
+
+			assert.Equal(t, tc.handled, CheckNotModified(w, r, tc.etag, tc.modified))
+			assert.Equal(t, tc.etag, w.Header().Get("ETag"))
+			if !tc.modified.IsZero() {
+				assert.Equal(t, "Fri, 01 Oct 2021 12:00:00 GMT", w.Header().Get("Last-Modified"))
+			}
+			if tc.handled {
+				assert.Equal(t, tc.code, w.Code)
+				if tc.code == http.StatusNotModified {
+					assert.Empty(t, w.Header().Get("Content-Type"))
+				}
+			}
+		})
+	}
+}
+
+func TestWriteWithETag(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteWithETag(w, r, "application/json", []byte(`{"keys":[]}`))
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"keys":[]}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+}
@@ -0,0 +1,206 @@
+package httpx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/logrusx"
+)
+
+// ErrShuttingDown is returned by GracefulServer.ReadyCheck once the server is shutting down.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+type (
+	// GracefulServer wraps an http.Server and shuts it down gracefully, see NewGracefulServer.
+	GracefulServer struct {
+		srv         *http.Server
+		l           *logrusx.Logger
+		gracePeriod time.Duration
+		drainDelay  time.Duration
+		signals     []os.Signal
+		hooks       []shutdownHook
+
+		shuttingDown int32
+		connections  int64
+	}
+
+	// GracefulServerOption configures a GracefulServer.
+	GracefulServerOption func(s *GracefulServer)
+
+	shutdownHook struct {
+		name string
+		f    func(ctx context.Context) error
+	}
+)
+
+// GracefulServerWithGracePeriod sets the time in-flight requests and shutdown hooks are given to
+// complete each. Defaults to 30 seconds.
+func GracefulServerWithGracePeriod(d time.Duration) GracefulServerOption {
+	return func(s *GracefulServer) {
+		s.gracePeriod = d
+	}
+}
+
+// GracefulServerWithDrainDelay sets the time between reporting the server as not ready and closing
+// the listeners, which gives load balancers time to stop routing new requests to the server.
+// Defaults to zero.
+func GracefulServerWithDrainDelay(d time.Duration) GracefulServerOption {
+	return func(s *GracefulServer) {
+		s.drainDelay = d
+	}
+}
+
+// GracefulServerWithSignals sets the signals which trigger the shutdown. Defaults to SIGINT and
+// SIGTERM.
+func GracefulServerWithSignals(signals ...os.Signal) GracefulServerOption {
+	return func(s *GracefulServer) {
+		s.signals = signals
+	}
+}
+
+// GracefulServerWithLogger sets the logger.
+func GracefulServerWithLogger(l *logrusx.Logger) GracefulServerOption {
+	return func(s *GracefulServer) {
+		s.l = l
+	}
+}
+
+// GracefulServerWithShutdownHook adds a function, for example stopping a background worker,
+// which is called after the server stopped accepting requests. Hooks are called in the order
+// they were added.
+func GracefulServerWithShutdownHook(name string, f func(ctx context.Context) error) GracefulServerOption {
+	return func(s *GracefulServer) {
+		s.hooks = append(s.hooks, shutdownHook{name: name, f: f})
+	}
+}
+
+// NewGracefulServer returns a server which, once a signal is received or the context passed to
+// Serve is cancelled,
+//
+//  1. reports the server as not ready through ReadyCheck,
+//  2. waits for the drain delay,
+//  3. stops accepting connections and waits for in-flight requests for up to the grace period,
+//     after which remaining connections are closed,
+//  4. calls the shutdown hooks in order.
+//
+// The ReadyCheck can be registered as a readiness check:
+//
+//	srv := httpx.NewGracefulServer(&http.Server{Handler: router}, httpx.GracefulServerWithDrainDelay(5*time.Second))
+//	healthx.Register("server", srv.ReadyCheck)
+//	return srv.ListenAndServe(ctx)
+func NewGracefulServer(srv *http.Server, opts ...GracefulServerOption) *GracefulServer {
+	s := &GracefulServer{
+		srv:         srv,
+		gracePeriod: 30 * time.Second,
+		signals:     []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	if s.l == nil {
+		s.l = logrusx.New("", "")
+	}
+
+	connState := srv.ConnState
+	srv.ConnState = func(c net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			atomic.AddInt64(&s.connections, 1)
+		case http.StateHijacked, http.StateClosed:
+			atomic.AddInt64(&s.connections, -1)
+		}
+		if connState != nil {
+			connState(c, state)
+		}
+	}
+
+	return s
+}
+
+// ReadyCheck returns ErrShuttingDown once the server is shutting down.
+func (s *GracefulServer) ReadyCheck(*http.Request) error {
+	if atomic.LoadInt32(&s.shuttingDown) == 1 {
+		return errors.WithStack(ErrShuttingDown)
+	}
+	return nil
+}
+
+// ActiveConnections returns the number of open connections.
+func (s *GracefulServer) ActiveConnections() int64 {
+	return atomic.LoadInt64(&s.connections)
+}
+
+// ListenAndServe listens on the server's address and serves requests until the server was shut
+// down.
+func (s *GracefulServer) ListenAndServe(ctx context.Context) error {
+	addr := s.srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve serves requests on l until the server was shut down. It returns nil if the server was
+// shut down gracefully.
+func (s *GracefulServer) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := signal.NotifyContext(ctx, s.signals...)
+	defer cancel()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.srv.Serve(l)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return errors.WithStack(err)
+	case <-ctx.Done():
+	}
+
+	return s.shutdown()
+}
+
+func (s *GracefulServer) shutdown() error {
+	atomic.StoreInt32(&s.shuttingDown, 1)
+	s.l.WithField("drain_delay", s.drainDelay).Info("Shutting down server, reporting not ready.")
+	time.Sleep(s.drainDelay)
+
+	s.l.WithField("active_connections", s.ActiveConnections()).WithField("grace_period", s.gracePeriod).Info("Waiting for in-flight requests to complete.")
+	ctx, cancel := context.WithTimeout(context.Background(), s.gracePeriod)
+	err := s.srv.Shutdown(ctx)
+	cancel()
+	if err != nil {
+		s.l.WithError(err).WithField("active_connections", s.ActiveConnections()).Warn("Grace period exceeded, closing remaining connections.")
+		_ = s.srv.Close()
+	}
+
+	var hookErr error
+	for _, h := range s.hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), s.gracePeriod)
+		if err := h.f(ctx); err != nil {
+			s.l.WithError(err).WithField("hook", h.name).Error("Shutdown hook failed.")
+			if hookErr == nil {
+				hookErr = errors.WithMessagef(err, "shutdown hook %s failed", h.name)
+			}
+		}
+		cancel()
+	}
+
+	s.l.Info("Server was shut down.")
+	return hookErr
+}
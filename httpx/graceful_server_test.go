@@ -0,0 +1,110 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGracefulServer(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+
+	var (
+		mu    sync.Mutex
+		hooks []string
+	)
+	hook := func(name string, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			hooks = append(hooks, name)
+			return err
+		}
+	}
+
+	srv := NewGracefulServer(&http.Server{Handler: handler},
+		GracefulServerWithDrainDelay(50*time.Millisecond),
+		GracefulServerWithShutdownHook("worker", hook("worker", nil)),
+		GracefulServerWithShutdownHook("queue", hook("queue", errors.New("queue not empty"))),
+	)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- srv.Serve(ctx, l) }()
+
+	require.NoError(t, srv.ReadyCheck(nil))
+
+	responses := make(chan string)
+	go func() {
+		res, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			responses <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		responses <- string(body)
+	}()
+
+	<-started
+	assert.EqualValues(t, 1, srv.ActiveConnections())
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		return errors.Is(srv.ReadyCheck(nil), ErrShuttingDown)
+	}, time.Second, 5*time.Millisecond, "the server reports not ready while draining")
+
+	close(release)
+	assert.Equal(t, "done", <-responses, "in-flight requests complete")
+
+	err = <-served
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "shutdown hook queue failed: queue not empty")
+	assert.Equal(t, []string{"worker", "queue"}, hooks, "hooks run in order")
+
+	_, err = http.Get("http://" + l.Addr().String())
+	assert.Error(t, err, "the listener is closed")
+}
+
+func TestGracefulServerGracePeriod(t *testing.T) {
+	started := make(chan struct{})
+	srv := NewGracefulServer(&http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})}, GracefulServerWithGracePeriod(50*time.Millisecond))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- srv.Serve(ctx, l) }()
+
+	go func() { _, _ = http.Get("http://" + l.Addr().String()) }()
+	<-started
+	cancel()
+
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not close the remaining connections after the grace period")
+	}
+}
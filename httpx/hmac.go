@@ -0,0 +1,227 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultHMACSignatureHeader is the header carrying the request signature.
+	DefaultHMACSignatureHeader = "X-Signature"
+	// DefaultHMACTolerance is the maximum age of a signature.
+	DefaultHMACTolerance = 5 * time.Minute
+	// DefaultHMACMaxBodySize is the maximum size of request bodies the verifier reads.
+	DefaultHMACMaxBodySize = 10 << 20
+)
+
+var (
+	// ErrHMACSignatureMissing is returned if the request has no signature.
+	ErrHMACSignatureMissing = errors.New("request signature is missing")
+	// ErrHMACSignatureInvalid is returned if no signature of the request matches any of the keys.
+	ErrHMACSignatureInvalid = errors.New("request signature is invalid")
+	// ErrHMACSignatureExpired is returned if the signature timestamp is outside of the tolerance.
+	ErrHMACSignatureExpired = errors.New("request signature has expired")
+)
+
+type (
+	hmacOptions struct {
+		header      string
+		tolerance   time.Duration
+		maxBodySize int64
+		now         func() time.Time
+	}
+
+	// HMACOption configures the HMAC signer and verifier.
+	HMACOption func(o *hmacOptions)
+
+	// HMACSigningRoundTripper signs outgoing requests, see NewHMACSigningRoundTripper.
+	HMACSigningRoundTripper struct {
+		rt  http.RoundTripper
+		key []byte
+		o   *hmacOptions
+	}
+
+	// HMACVerifier is a middleware verifying requests signed by HMACSigningRoundTripper.
+	HMACVerifier struct {
+		keys [][]byte
+		o    *hmacOptions
+
+		// ErrHandler is called if the signature can not be verified. Defaults to a plain text
+		// 401 Unauthorized response.
+		ErrHandler func(w http.ResponseWriter, r *http.Request, err error)
+	}
+)
+
+var _ http.RoundTripper = (*HMACSigningRoundTripper)(nil)
+
+// HMACWithHeader sets the header carrying the signature. Defaults to DefaultHMACSignatureHeader.
+func HMACWithHeader(name string) HMACOption {
+	return func(o *hmacOptions) {
+		o.header = name
+	}
+}
+
+// HMACWithTolerance sets the maximum age of signatures accepted by the verifier, which limits
+// replay attacks. Defaults to DefaultHMACTolerance.
+func HMACWithTolerance(d time.Duration) HMACOption {
+	return func(o *hmacOptions) {
+		o.tolerance = d
+	}
+}
+
+// HMACWithMaxBodySize sets the maximum size of request bodies read by the verifier. Defaults to
+// DefaultHMACMaxBodySize.
+func HMACWithMaxBodySize(size int64) HMACOption {
+	return func(o *hmacOptions) {
+		o.maxBodySize = size
+	}
+}
+
+func newHMACOptions(opts []HMACOption) *hmacOptions {
+	o := &hmacOptions{
+		header:      DefaultHMACSignatureHeader,
+		tolerance:   DefaultHMACTolerance,
+		maxBodySize: DefaultHMACMaxBodySize,
+		now:         time.Now,
+	}
+	for _, f := range opts {
+		f(o)
+	}
+	return o
+}
+
+// NewHMACSigningRoundTripper returns a RoundTripper which signs requests using HMAC-SHA256 with
+// the given key. The signature covers the current time, the method, the request URI, and the
+// SHA-256 digest of the body and is sent in the form
+//
+//	X-Signature: t=1636113600,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// If rt is nil, http.DefaultTransport is used.
+func NewHMACSigningRoundTripper(rt http.RoundTripper, key []byte, opts ...HMACOption) *HMACSigningRoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &HMACSigningRoundTripper{rt: rt, key: key, o: newHMACOptions(opts)}
+}
+
+func (s *HMACSigningRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	signed := req.Clone(req.Context())
+	if req.Body != nil {
+		signed.Body = ioutil.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	digest := sha256.Sum256(body)
+	ts := s.o.now().Unix()
+	signed.Header.Set(s.o.header, fmt.Sprintf("t=%d,v1=%s", ts, hmacSignature(s.key, ts, req, digest[:])))
+
+	return s.rt.RoundTrip(signed)
+}
+
+// NewHMACVerifier returns a middleware which rejects requests without a valid signature for one
+// of the keys. To rotate keys without downtime, add the new key to the verifier, then switch the
+// signer to the new key, and finally remove the old key from the verifier.
+func NewHMACVerifier(keys [][]byte, opts ...HMACOption) *HMACVerifier {
+	return &HMACVerifier{
+		keys: keys,
+		o:    newHMACOptions(opts),
+		ErrHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		},
+	}
+}
+
+func (v *HMACVerifier) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if err := v.Verify(r); err != nil {
+		v.ErrHandler(w, r, err)
+		return
+	}
+	next(w, r)
+}
+
+// Verify returns an error if the request is not signed with one of the keys. The request body is
+// read and replaced, so that it can be read again afterwards.
+func (v *HMACVerifier) Verify(r *http.Request) error {
+	header := r.Header.Get(v.o.header)
+	if header == "" {
+		return errors.WithStack(ErrHMACSignatureMissing)
+	}
+
+	var ts int64
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts, _ = strconv.ParseInt(kv[1], 10, 64)
+		case "v1":
+			if sig, err := hex.DecodeString(kv[1]); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	if ts == 0 || len(signatures) == 0 {
+		return errors.WithStack(ErrHMACSignatureInvalid)
+	}
+
+	if age := v.o.now().Sub(time.Unix(ts, 0)); age > v.o.tolerance || age < -v.o.tolerance {
+		return errors.WithStack(ErrHMACSignatureExpired)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(r.Body, v.o.maxBodySize+1))
+		_ = r.Body.Close()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if int64(len(body)) > v.o.maxBodySize {
+			return errors.Errorf("request body exceeds the maximum size of %d bytes", v.o.maxBodySize)
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	digest := sha256.Sum256(body)
+	for _, key := range v.keys {
+		expected, _ := hex.DecodeString(hmacSignature(key, ts, r, digest[:]))
+		for _, sig := range signatures {
+			if hmac.Equal(expected, sig) {
+				return nil
+			}
+		}
+	}
+	return errors.WithStack(ErrHMACSignatureInvalid)
+}
+
+func hmacSignature(key []byte, ts int64, r *http.Request, digest []byte) string {
+	m := hmac.New(sha256.New, key)
+	_, _ = fmt.Fprintf(m, "%d\n%s\n%s\n%x", ts, r.Method, r.URL.RequestURI(), digest)
+	return hex.EncodeToString(m.Sum(nil))
+}
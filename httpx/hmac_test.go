@@ -0,0 +1,111 @@
+package httpx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHMAC(t *testing.T) {
+	var (
+		oldKey = []byte("old-secret")
+		newKey = []byte("new-secret")
+	)
+
+	verifier := NewHMACVerifier([][]byte{newKey, oldKey})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifier.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			_, _ = w.Write(body)
+		})
+	}))
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, c *http.Client, method, path, body string) (int, string) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		res, err := c.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		out, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, strings.TrimSpace(string(out))
+	}
+
+	t.Run("case=valid signatures pass", func(t *testing.T) {
+		for _, key := range [][]byte{oldKey, newKey} {
+			c := &http.Client{Transport: NewHMACSigningRoundTripper(nil, key)}
+			code, body := do(t, c, "POST", "/hooks?id=1", `{"event":"created"}`)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, `{"event":"created"}`, body, "the body is readable after verification")
+		}
+	})
+
+	t.Run("case=unknown key fails", func(t *testing.T) {
+		c := &http.Client{Transport: NewHMACSigningRoundTripper(nil, []byte("other"))}
+		code, body := do(t, c, "POST", "/hooks", "{}")
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Equal(t, ErrHMACSignatureInvalid.Error(), body)
+	})
+
+	t.Run("case=missing signature fails", func(t *testing.T) {
+		code, body := do(t, http.DefaultClient, "POST", "/hooks", "{}")
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Equal(t, ErrHMACSignatureMissing.Error(), body)
+	})
+
+	t.Run("case=tampered requests fail", func(t *testing.T) {
+		for k, mutate := range []func(r *http.Request){
+			func(r *http.Request) { r.Body = ioutil.NopCloser(strings.NewReader(`{"event":"deleted"}`)) },
+			func(r *http.Request) { r.URL.Path = "/other" },
+			func(r *http.Request) { r.Method = "PUT" },
+		} {
+			req, err := http.NewRequest("POST", ts.URL+"/hooks", strings.NewReader(`{"event":"created"}`))
+			require.NoError(t, err)
+
+			var signed *http.Request
+			capture := NewHMACSigningRoundTripper(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				signed = r
+				return nil, assert.AnError
+			}), newKey)
+			_, _ = capture.RoundTrip(req)
+			require.NotNil(t, signed)
+
+			mutate(signed)
+			assert.ErrorIs(t, verifier.Verify(signed), ErrHMACSignatureInvalid, "%d", k)
+		}
+	})
+
+	t.Run("case=expired signatures fail", func(t *testing.T) {
+		signer := NewHMACSigningRoundTripper(nil, newKey)
+		signer.o.now = func() time.Time { return time.Now().Add(-time.Hour) }
+		code, body := do(t, &http.Client{Transport: signer}, "GET", "/", "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+		assert.Equal(t, ErrHMACSignatureExpired.Error(), body)
+	})
+
+	t.Run("case=custom header", func(t *testing.T) {
+		verifier := NewHMACVerifier([][]byte{newKey}, HMACWithHeader("X-Hook-Signature"))
+		req := httptest.NewRequest("POST", "/hooks", strings.NewReader("{}"))
+		var signed *http.Request
+		_, _ = NewHMACSigningRoundTripper(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			signed = r
+			return nil, assert.AnError
+		}), newKey, HMACWithHeader("X-Hook-Signature")).RoundTrip(req)
+
+		require.NotEmpty(t, signed.Header.Get("X-Hook-Signature"))
+		assert.NoError(t, verifier.Verify(signed))
+	})
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
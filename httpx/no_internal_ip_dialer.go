@@ -0,0 +1,64 @@
+package httpx
+
+import (
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NoInternalIPDialer returns a dialer which refuses to connect to loopback, private, link-local,
+// multicast, reserved, NAT64, and 6to4 IP addresses unless they are contained in one of the allowed
+// networks.
+//
+// In contrast to DisallowIPPrivateAddresses, which only rejects loopback and private IP addresses,
+// the dialer rejects more ranges and validates the IP address right before the connection is
+// established. This also prevents DNS rebinding attacks where a host name resolves to a public IP
+// address during validation and to an internal one when connecting.
+func NoInternalIPDialer(allowed ...*net.IPNet) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return errors.Errorf("unable to parse ip %q", host)
+			}
+			return disallowInternalIP(ip, allowed)
+		},
+	}
+}
+
+// NewNoInternalIPTransport returns a copy of http.DefaultTransport which uses NoInternalIPDialer.
+// Proxies are not used because the dialer would only validate the proxy's address.
+func NewNoInternalIPTransport(allowed ...*net.IPNet) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = NoInternalIPDialer(allowed...).DialContext
+	return t
+}
+
+// NewNoInternalIPClient returns a client for fetching user-supplied URLs such as webhooks. It uses
+// NewNoInternalIPTransport, so the target of every redirect is validated as well, and follows at
+// most ten redirects to http and https URLs.
+func NewNoInternalIPClient(allowed ...*net.IPNet) *http.Client {
+	return &http.Client{
+		Timeout:   time.Minute,
+		Transport: NewNoInternalIPTransport(allowed...),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.Errorf("refusing to follow redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
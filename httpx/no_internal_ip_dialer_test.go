@@ -0,0 +1,89 @@
+package httpx
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoInternalIPClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Hello, world!"))
+	}))
+	t.Cleanup(ts.Close)
+
+	t.Run("case=blocks internal ips", func(t *testing.T) {
+		_, err := NewNoInternalIPClient().Get(ts.URL)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is in the 127.0.0.0/8 range")
+	})
+
+	t.Run("case=allows allowlisted ips", func(t *testing.T) {
+		_, loopback, err := net.ParseCIDR("127.0.0.1/32")
+		require.NoError(t, err)
+
+		res, err := NewNoInternalIPClient(loopback).Get(ts.URL)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("case=validates redirects", func(t *testing.T) {
+		_, loopback, err := net.ParseCIDR("127.0.0.1/32")
+		require.NoError(t, err)
+
+		redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://[::1]:1/metadata", http.StatusFound)
+		}))
+		t.Cleanup(redirect.Close)
+
+		_, err = NewNoInternalIPClient(loopback).Get(redirect.URL)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is in the ::1/128 range")
+	})
+}
+
+func TestDisallowInternalIP(t *testing.T) {
+	for _, tc := range []struct {
+		ip      string
+		blocked bool
+	}{
+		{ip: "127.0.0.1", blocked: true},
+		{ip: "10.1.2.3", blocked: true},
+		{ip: "172.16.0.1", blocked: true},
+		{ip: "192.168.1.1", blocked: true},
+		{ip: "169.254.169.254", blocked: true},
+		{ip: "100.64.0.1", blocked: true},
+		{ip: "0.0.0.0", blocked: true},
+		{ip: "::1", blocked: true},
+		{ip: "fe80::1", blocked: true},
+		{ip: "fd00::1", blocked: true},
+		{ip: "::ffff:127.0.0.1", blocked: true},
+		{ip: "64:ff9b::7f00:1", blocked: true},
+		{ip: "2002:7f00:1::1", blocked: true},
+		{ip: "192.0.0.170", blocked: true},
+		{ip: "198.18.0.1", blocked: true},
+		{ip: "198.19.255.255", blocked: true},
+		{ip: "224.0.0.1", blocked: true},
+		{ip: "239.255.255.250", blocked: true},
+		{ip: "240.0.0.1", blocked: true},
+		{ip: "255.255.255.255", blocked: true},
+		{ip: "192.0.1.1", blocked: false},
+		{ip: "198.20.0.1", blocked: false},
+		{ip: "8.8.8.8", blocked: false},
+		{ip: "2001:4860:4860::8888", blocked: false},
+	} {
+		t.Run("case="+tc.ip, func(t *testing.T) {
+			err := disallowInternalIP(net.ParseIP(tc.ip), nil)
+			if tc.blocked {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -61,27 +61,78 @@ func DisallowIPPrivateAddresses(ipOrHostnameOrURL string) error {
 		ips = append(ips, ip)
 	}
 
-	for _, disabled := range []string{
-		"127.0.0.0/8",
-		"10.0.0.0/8",
-		"172.16.0.0/12",
-		"192.168.0.0/16",
-		"fd47:1ed0:805d:59f0::/64",
-		"fc00::/7",
-		"::1/128",
-	} {
-		_, cidr, err := net.ParseCIDR(disabled)
-		if err != nil {
+	for _, ip := range ips {
+		if err := disallowIPInRanges(ip, privateIPRanges, nil); err != nil {
 			return err
 		}
+	}
 
-		for _, ip := range ips {
-			if cidr.Contains(ip) {
-				return fmt.Errorf("ip %s is in the %s range", ip, disabled)
-			}
+	return nil
+}
+
+// privateIPRanges are the loopback and private IP ranges rejected by DisallowIPPrivateAddresses.
+var privateIPRanges = mustParseCIDRs(
+	"127.0.0.0/8",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fd47:1ed0:805d:59f0::/64",
+	"fc00::/7",
+	"::1/128",
+)
+
+// internalIPRanges are the loopback, private, link-local, multicast, reserved, and otherwise
+// non-public IP ranges rejected by NoInternalIPDialer. The NAT64 and 6to4 ranges are included
+// because they embed IPv4 addresses which may be internal.
+var internalIPRanges = mustParseCIDRs(
+	"0.0.0.0/8",
+	"127.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"255.255.255.255/32",
+	"fd47:1ed0:805d:59f0::/64",
+	"fc00::/7",
+	"fe80::/10",
+	"::1/128",
+	"::/128",
+	"64:ff9b::/96",
+	"2002::/16",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for k, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
 		}
+		nets[k] = n
 	}
+	return nets
+}
+
+func disallowInternalIP(ip net.IP, allowed []*net.IPNet) error {
+	return disallowIPInRanges(ip, internalIPRanges, allowed)
+}
 
+func disallowIPInRanges(ip net.IP, ranges, allowed []*net.IPNet) error {
+	for _, a := range allowed {
+		if a.Contains(ip) {
+			return nil
+		}
+	}
+	for _, n := range ranges {
+		if n.Contains(ip) {
+			return fmt.Errorf("ip %s is in the %s range", ip, n)
+		}
+	}
 	return nil
 }
 
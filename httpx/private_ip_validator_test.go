@@ -30,3 +30,16 @@ func TestDisallowLocalIPAddressesWhenSet(t *testing.T) {
 	require.NoError(t, DisallowIPPrivateAddresses(""))
 	require.Error(t, DisallowIPPrivateAddresses("127.0.0.1"))
 }
+
+func TestDisallowIPPrivateAddressesOnlyRejectsPrivateRanges(t *testing.T) {
+	// The additional ranges of NoInternalIPDialer are not rejected to keep the existing behavior.
+	for _, allowed := range []string{
+		"8.8.8.8",
+		"100.64.0.1",
+		"198.18.0.1",
+	} {
+		t.Run("case="+allowed, func(t *testing.T) {
+			require.NoError(t, DisallowIPPrivateAddresses(allowed))
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//go:embed rate_limit.schema.json
+var RateLimitConfigSchema string
+
+const RateLimitConfigSchemaID = "ory://rate-limit-config"
+
+// AddRateLimitConfigSchema adds the rate limit schema to the compiler.
+// The interface is specified instead of `jsonschema.Compiler` to allow the use of any jsonschema library fork or version.
+func AddRateLimitConfigSchema(c interface {
+	AddResource(url string, r io.Reader) error
+}) error {
+	return c.AddResource(RateLimitConfigSchemaID, bytes.NewBufferString(RateLimitConfigSchema))
+}
+
+type (
+	// RateLimitConfig configures the RateLimiter.
+	RateLimitConfig struct {
+		// RequestsPerSecond is the rate at which the bucket of each client is refilled.
+		RequestsPerSecond float64 `json:"requests_per_second"`
+		// Burst is the size of the bucket of each client. Defaults to RequestsPerSecond rounded up.
+		Burst int `json:"burst,omitempty"`
+	}
+
+	// RateLimitStore keeps the token buckets of the RateLimiter. Implementations backed by a shared
+	// database such as Redis apply the limits across all instances of a service.
+	RateLimitStore interface {
+		// Take removes a token from the bucket of key. If the bucket is empty, it returns false and
+		// the time until the next token becomes available.
+		Take(ctx context.Context, key string, rate float64, burst int) (ok bool, retryAfter time.Duration, err error)
+	}
+
+	// RateLimiter is a middleware limiting the request rate of each client using token buckets.
+	RateLimiter struct {
+		c     RateLimitConfig
+		store RateLimitStore
+
+		// KeyFunc identifies the client of a request. Defaults to the key set using WithRateLimitKey
+		// or the IP address of the client. The IP address resolved by the ClientIPMiddleware is used
+		// if the middleware runs before the rate limiter.
+		KeyFunc func(r *http.Request) string
+	}
+
+	// MemoryRateLimitStore is a RateLimitStore keeping token buckets in memory.
+	MemoryRateLimitStore struct {
+		mu        sync.Mutex
+		buckets   map[string]*tokenBucket
+		lastSweep time.Time
+		now       func() time.Time
+	}
+
+	tokenBucket struct {
+		tokens float64
+		last   time.Time
+	}
+
+	rateLimitKeyContextKey struct{}
+)
+
+var _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+// NewMemoryRateLimitStore returns a RateLimitStore keeping token buckets in memory. Buckets which
+// are full are removed periodically.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*tokenBucket{}, now: time.Now}
+}
+
+// Take implements RateLimitStore.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		s.sweep(now, rate, burst)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+func (s *MemoryRateLimitStore) sweep(now time.Time, rate float64, burst int) {
+	s.lastSweep = now
+	for k, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rate >= float64(burst) {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+// NewRateLimiter returns a middleware which responds with 429 Too Many Requests and a Retry-After
+// header once a client exceeds the configured rate. If the store fails, requests are let through.
+func NewRateLimiter(c RateLimitConfig, store RateLimitStore) *RateLimiter {
+	if c.RequestsPerSecond <= 0 {
+		c.RequestsPerSecond = 10
+	}
+	if c.Burst < 1 {
+		c.Burst = int(math.Max(1, math.Ceil(c.RequestsPerSecond)))
+	}
+
+	l := &RateLimiter{c: c, store: store}
+	l.KeyFunc = l.defaultKey
+	return l
+}
+
+// WithRateLimitKey returns a context which makes the RateLimiter identify the client by key instead of
+// its IP address. The key must be an identity the client can not choose freely, for example the ID
+// of an authenticated API key or session, so that it can not evade the limit by changing the key:
+//
+//	next(w, r.WithContext(httpx.WithRateLimitKey(r.Context(), apiKey.ID)))
+func WithRateLimitKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, rateLimitKeyContextKey{}, key)
+}
+
+func (l *RateLimiter) defaultKey(r *http.Request) string {
+	if key, _ := r.Context().Value(rateLimitKeyContextKey{}).(string); key != "" {
+		return "key:" + key
+	}
+	if ip, ok := ClientIPFromContext(r.Context()); ok {
+		return "ip:" + ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func (l *RateLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ok, retryAfter, err := l.store.Take(r.Context(), l.KeyFunc(r), l.c.RequestsPerSecond, l.c.Burst)
+	if err != nil || ok {
+		next(w, r)
+		return
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
@@ -0,0 +1,104 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/sjson"
+
+	"github.com/ory/jsonschema/v3"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	s := NewMemoryRateLimitStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		ok, _, err := s.Take(ctx, "a", 2, 3)
+		require.NoError(t, err)
+		assert.True(t, ok, "%d", i)
+	}
+
+	ok, retryAfter, err := s.Take(ctx, "a", 2, 3)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	ok, _, _ = s.Take(ctx, "b", 2, 3)
+	assert.True(t, ok, "buckets are separate per key")
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _, _ = s.Take(ctx, "a", 2, 3)
+	assert.True(t, ok, "the bucket is refilled")
+
+	now = now.Add(2 * time.Minute)
+	_, _, _ = s.Take(ctx, "c", 2, 3)
+	assert.Len(t, s.buckets, 1, "full buckets are removed")
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 0.1, Burst: 2}, NewMemoryRateLimitStore())
+	h := func(w http.ResponseWriter, r *http.Request) {
+		// Simulates an authentication middleware which only trusts known API keys.
+		if key := r.Header.Get("X-API-Key"); key == "secret" {
+			r = r.WithContext(WithRateLimitKey(r.Context(), key))
+		}
+		l.ServeHTTP(w, r, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	do := func(remoteAddr, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remoteAddr
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusNoContent, do("1.2.3.4:1234", "").Code)
+	assert.Equal(t, http.StatusNoContent, do("1.2.3.4:4321", "").Code)
+
+	w := do("1.2.3.4:1234", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusNoContent, do("5.6.7.8:1234", "").Code, "other clients are not limited")
+	assert.Equal(t, http.StatusNoContent, do("1.2.3.4:1234", "secret").Code, "clients with a key are identified by the key")
+	assert.Equal(t, http.StatusTooManyRequests, do("1.2.3.4:1234", "chosen-by-the-client").Code, "unauthenticated keys are ignored")
+}
+
+func TestNewRateLimiterDefaults(t *testing.T) {
+	l := NewRateLimiter(RateLimitConfig{}, NewMemoryRateLimitStore())
+	assert.Equal(t, RateLimitConfig{RequestsPerSecond: 10, Burst: 10}, l.c)
+
+	l = NewRateLimiter(RateLimitConfig{RequestsPerSecond: 2.5}, NewMemoryRateLimitStore())
+	assert.Equal(t, 3, l.c.Burst)
+}
+
+func TestRateLimitConfigSchema(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	require.NoError(t, AddRateLimitConfigSchema(c))
+	require.NoError(t, c.AddResource("config", bytes.NewBufferString(fmt.Sprintf(`{"properties":{"rate_limit":{"$ref":"%s"}}}`, RateLimitConfigSchemaID))))
+	schema, err := c.Compile(context.Background(), "config")
+	require.NoError(t, err)
+
+	raw, err := sjson.Set("{}", "rate_limit", &RateLimitConfig{RequestsPerSecond: 5, Burst: 10})
+	require.NoError(t, err)
+	assert.NoError(t, schema.Validate(bytes.NewBufferString(raw)))
+
+	assert.Error(t, schema.Validate(bytes.NewBufferString(`{"rate_limit":{"burst":0}}`)))
+	assert.Error(t, schema.Validate(bytes.NewBufferString(`{"rate_limit":{"key_header":"X-API-Key"}}`)))
+}
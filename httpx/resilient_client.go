@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
 
 	"github.com/ory/x/logrusx"
 )
@@ -20,6 +21,11 @@ type resilientOptions struct {
 	retryWaitMax  time.Duration
 	retryMax      int
 	noInternalIPs bool
+
+	retryNonIdempotent bool
+	budget             *retryBudget
+	breaker            *circuitBreaker
+	metrics            *TransportMetrics
 }
 
 func newResilientOptions() *resilientOptions {
@@ -64,7 +70,8 @@ func ResilientClientWithMaxRetryWait(retryWaitMax time.Duration) ResilientOption
 	}
 }
 
-// ResilientClientWithConnectionTimeout sets the connection timeout for the client.
+// ResilientClientWithConnectionTimeout sets the connection timeout for the client. The timeout
+// applies to each attempt.
 func ResilientClientWithConnectionTimeout(connTimeout time.Duration) ResilientOptions {
 	return func(o *resilientOptions) {
 		o.c.Timeout = connTimeout
@@ -78,14 +85,53 @@ func ResilientClientWithLogger(l *logrusx.Logger) ResilientOptions {
 	}
 }
 
-// ResilientClientDisallowInternalIPs disallows internal IPs from being used.
+// ResilientClientDisallowInternalIPs disallows internal IPs from being used, see NoInternalIPDialer. If
+// the client set using ResilientClientWithClient has a transport other than *http.Transport, only the
+// host of each request is validated using DisallowIPPrivateAddresses instead.
 func ResilientClientDisallowInternalIPs() ResilientOptions {
 	return func(o *resilientOptions) {
 		o.noInternalIPs = true
 	}
 }
 
+// ResilientClientRetryNonIdempotent enables retries of requests which are not idempotent, such as
+// POST requests without an Idempotency-Key header. By default, only idempotent requests are retried
+// because retrying other requests may apply them more than once.
+func ResilientClientRetryNonIdempotent() ResilientOptions {
+	return func(o *resilientOptions) {
+		o.retryNonIdempotent = true
+	}
+}
+
+// ResilientClientWithRetryBudget limits retries to the given ratio of all attempts, e.g. 0.2 for
+// 20%, with bursts of up to burst retries. This prevents retries from multiplying the load on a
+// struggling upstream.
+func ResilientClientWithRetryBudget(ratio float64, burst int) ResilientOptions {
+	return func(o *resilientOptions) {
+		o.budget = newRetryBudget(ratio, burst)
+	}
+}
+
+// ResilientClientWithCircuitBreaker fails requests to a host immediately with ErrCircuitOpen once
+// threshold consecutive attempts failed with an error or a 5xx status code. After cooldown, a
+// single request is let through and closes the circuit again if it succeeds.
+func ResilientClientWithCircuitBreaker(threshold int, cooldown time.Duration) ResilientOptions {
+	return func(o *resilientOptions) {
+		o.breaker = newCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// ResilientClientWithMetrics records the metrics of each attempt in m.
+func ResilientClientWithMetrics(m *TransportMetrics) ResilientOptions {
+	return func(o *resilientOptions) {
+		o.metrics = m
+	}
+}
+
 // NewResilientClient creates a new ResilientClient.
+//
+// Failed attempts of idempotent requests are retried with exponential backoff. The Retry-After
+// header of 429 and 503 responses is respected.
 func NewResilientClient(opts ...ResilientOptions) *retryablehttp.Client {
 	o := newResilientOptions()
 	for _, f := range opts {
@@ -93,7 +139,30 @@ func NewResilientClient(opts ...ResilientOptions) *retryablehttp.Client {
 	}
 
 	if o.noInternalIPs == true {
-		o.c.Transport = &NoInternalIPRoundTripper{RoundTripper: o.c.Transport}
+		// The IP address is validated right before connecting, so host names resolving to internal
+		// IP addresses after validation (DNS rebinding) and redirects to internal hosts are rejected.
+		switch t := o.c.Transport.(type) {
+		case nil:
+			o.c.Transport = NewNoInternalIPTransport()
+		case *http.Transport:
+			t = t.Clone()
+			t.Proxy = nil
+			t.DialContext = NoInternalIPDialer().DialContext
+			o.c.Transport = t
+		default:
+			// The dialer of other round trippers can not be replaced.
+			o.c.Transport = &NoInternalIPRoundTripper{RoundTripper: o.c.Transport}
+		}
+	}
+
+	if o.metrics != nil {
+		o.c.Transport = o.metrics.RoundTripper(o.c.Transport)
+	}
+
+	o.c.Transport = &resilientTransport{
+		RoundTripper:       o.c.Transport,
+		retryNonIdempotent: o.retryNonIdempotent,
+		breaker:            o.breaker,
 	}
 
 	return &retryablehttp.Client{
@@ -102,7 +171,27 @@ func NewResilientClient(opts ...ResilientOptions) *retryablehttp.Client {
 		RetryWaitMin: o.retryWaitMin,
 		RetryWaitMax: o.retryWaitMax,
 		RetryMax:     o.retryMax,
-		CheckRetry:   retryablehttp.DefaultRetryPolicy,
+		CheckRetry:   o.checkRetry,
 		Backoff:      retryablehttp.DefaultBackoff,
 	}
 }
+
+func (o *resilientOptions) checkRetry(ctx context.Context, res *http.Response, err error) (bool, error) {
+	if errors.Is(err, ErrCircuitOpen) || errors.As(err, new(*nonRetryableError)) {
+		return false, nil
+	}
+	if !o.retryNonIdempotent && res != nil && res.Request != nil && !isIdempotent(res.Request) {
+		return false, nil
+	}
+
+	retry, checkErr := retryablehttp.DefaultRetryPolicy(ctx, res, err)
+	if o.budget == nil {
+		return retry, checkErr
+	}
+
+	o.budget.deposit()
+	if retry && !o.budget.withdraw() {
+		return false, checkErr
+	}
+	return retry, checkErr
+}
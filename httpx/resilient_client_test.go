@@ -5,7 +5,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,6 +33,8 @@ func TestNoPrivateIPs(t *testing.T) {
 		"127.0.0.1",
 		"localhost",
 		"192.168.178.5",
+		// Only rejected by the dialer; connecting to 0.0.0.0 reaches the local server.
+		"0.0.0.0",
 	} {
 		target.Host = host + ":" + port
 		t.Logf("%s", target.String())
@@ -38,3 +43,86 @@ func TestNoPrivateIPs(t *testing.T) {
 		assert.Contains(t, err.Error(), "is in the")
 	}
 }
+
+func TestResilientClientRetries(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(ts.Close)
+
+	newRequest := func(t *testing.T, method string, header http.Header) *http.Request {
+		req, err := http.NewRequest(method, ts.URL, strings.NewReader("{}"))
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		return req
+	}
+
+	for k, tc := range []struct {
+		d        string
+		opts     []ResilientOptions
+		method   string
+		header   http.Header
+		expected int32
+	}{
+		{d: "idempotent requests are retried", method: "GET", expected: 3},
+		{d: "non-idempotent requests are not retried", method: "POST", expected: 1},
+		{d: "requests with idempotency key are retried", method: "POST", header: http.Header{"Idempotency-Key": {"abc"}}, expected: 3},
+		{d: "non-idempotent requests are retried if enabled", method: "PATCH", opts: []ResilientOptions{ResilientClientRetryNonIdempotent()}, expected: 3},
+		{d: "retries are limited by the budget", method: "GET", opts: []ResilientOptions{ResilientClientWithRetryBudget(0.1, 1)}, expected: 2},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			atomic.StoreInt32(&attempts, 0)
+			c := NewResilientClient(append([]ResilientOptions{
+				ResilientClientWithMaxRetry(2),
+				ResilientClientWithMinxRetryWait(time.Millisecond),
+				ResilientClientWithMaxRetryWait(time.Millisecond),
+			}, tc.opts...)...)
+
+			res, err := c.StandardClient().Do(newRequest(t, tc.method, tc.header))
+			if err == nil {
+				_ = res.Body.Close()
+			}
+			assert.Equal(t, tc.expected, atomic.LoadInt32(&attempts), "%+v", k)
+		})
+	}
+}
+
+func TestResilientClientCircuitBreaker(t *testing.T) {
+	var attempts int32
+	var healthy int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	c := NewResilientClient(
+		ResilientClientWithMaxRetry(5),
+		ResilientClientWithMinxRetryWait(time.Millisecond),
+		ResilientClientWithMaxRetryWait(time.Millisecond),
+		ResilientClientWithCircuitBreaker(2, 50*time.Millisecond),
+	)
+
+	_, err := c.Get(ts.URL)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts), "the circuit opens after two failures and retries stop")
+
+	_, err = c.Get(ts.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts), "requests fail fast while the circuit is open")
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+
+	res, err := c.Get(ts.URL)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.EqualValues(t, 3, atomic.LoadInt32(&attempts), "a probe closes the circuit after the cooldown")
+}
@@ -0,0 +1,166 @@
+package httpx
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen is returned by clients with a circuit breaker while requests to a host are
+// failing.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+var _ http.RoundTripper = (*resilientTransport)(nil)
+
+// resilientTransport marks errors of non-idempotent requests as not retryable and applies the
+// circuit breaker to each attempt.
+type resilientTransport struct {
+	http.RoundTripper
+	retryNonIdempotent bool
+	breaker            *circuitBreaker
+}
+
+// nonRetryableError wraps errors of requests which must not be retried.
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *nonRetryableError) Unwrap() error {
+	return e.err
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := t.RoundTripper
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	if t.breaker != nil {
+		if err := t.breaker.allow(req.URL.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := rt.RoundTrip(req)
+
+	if t.breaker != nil {
+		t.breaker.record(req.URL.Host, err == nil && res.StatusCode < http.StatusInternalServerError)
+	}
+
+	if err != nil && !t.retryNonIdempotent && !isIdempotent(req) {
+		return nil, &nonRetryableError{err: err}
+	}
+	return res, err
+}
+
+// isIdempotent follows the rules net/http uses to decide whether a request can be retried.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	_, ok := req.Header["X-Idempotency-Key"]
+	return ok
+}
+
+// retryBudget is a token bucket which is filled by every attempt and drained by every retry.
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	max    float64
+	tokens float64
+}
+
+func newRetryBudget(ratio float64, burst int) *retryBudget {
+	max := math.Max(float64(burst), 1)
+	return &retryBudget{ratio: ratio, max: max, tokens: max}
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.tokens+b.ratio, b.max)
+}
+
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type (
+	circuitBreaker struct {
+		threshold int
+		cooldown  time.Duration
+		now       func() time.Time
+
+		mu       sync.Mutex
+		circuits map[string]*circuit
+	}
+	circuit struct {
+		failures  int
+		openUntil time.Time
+		probing   bool
+	}
+)
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now, circuits: map[string]*circuit{}}
+}
+
+func (b *circuitBreaker) allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[host]
+	if !ok || c.failures < b.threshold {
+		return nil
+	}
+
+	// Once the cooldown elapsed, a single probe is let through.
+	if b.now().Before(c.openUntil) || c.probing {
+		return errors.WithStack(ErrCircuitOpen)
+	}
+	c.probing = true
+	return nil
+}
+
+func (b *circuitBreaker) record(host string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		delete(b.circuits, host)
+		return
+	}
+
+	c, ok := b.circuits[host]
+	if !ok {
+		c = new(circuit)
+		b.circuits[host] = c
+	}
+
+	c.probing = false
+
+	c.failures++
+	if c.failures >= b.threshold {
+		c.openUntil = b.now().Add(b.cooldown)
+	}
+}
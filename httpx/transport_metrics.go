@@ -0,0 +1,158 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TransportMetrics is a Prometheus collector recording per host metrics of outgoing requests:
+//
+//   - <namespace>_http_client_in_flight_requests
+//   - <namespace>_http_client_requests_total by method and status code, or "error"
+//   - <namespace>_http_client_{dns,connect,tls_handshake,first_byte,request}_duration_seconds
+//
+// Because the host is used as a label, instrument only clients which talk to a limited set of
+// hosts.
+type TransportMetrics struct {
+	inFlight  *prometheus.GaugeVec
+	requests  *prometheus.CounterVec
+	dns       *prometheus.HistogramVec
+	connect   *prometheus.HistogramVec
+	tls       *prometheus.HistogramVec
+	firstByte *prometheus.HistogramVec
+	duration  *prometheus.HistogramVec
+}
+
+var _ prometheus.Collector = (*TransportMetrics)(nil)
+
+// NewTransportMetrics returns a collector which must be registered with a Prometheus registry and
+// attached to clients using RoundTripper or ResilientClientWithMetrics.
+func NewTransportMetrics(namespace string) *TransportMetrics {
+	histogram := func(name, help string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      name,
+			Help:      help,
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"host"})
+	}
+
+	return &TransportMetrics{
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "in_flight_requests",
+			Help:      "Number of outgoing requests which have not completed yet.",
+		}, []string{"host"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Number of outgoing requests by status code, or \"error\" if no response was received.",
+		}, []string{"host", "method", "code"}),
+		dns:       histogram("dns_duration_seconds", "Duration of DNS lookups."),
+		connect:   histogram("connect_duration_seconds", "Duration of establishing TCP connections."),
+		tls:       histogram("tls_handshake_duration_seconds", "Duration of TLS handshakes."),
+		firstByte: histogram("first_byte_duration_seconds", "Duration from sending the request until the first response byte was received."),
+		duration:  histogram("request_duration_seconds", "Duration from sending the request until the response headers were received."),
+	}
+}
+
+func (m *TransportMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.inFlight, m.requests, m.dns, m.connect, m.tls, m.firstByte, m.duration}
+}
+
+// Describe implements prometheus.Collector.
+func (m *TransportMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *TransportMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// RoundTripper returns a RoundTripper recording the metrics of requests sent through rt. If rt is
+// nil, http.DefaultTransport is used.
+func (m *TransportMetrics) RoundTripper(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &metricsRoundTripper{rt: rt, m: m}
+}
+
+type metricsRoundTripper struct {
+	rt http.RoundTripper
+	m  *TransportMetrics
+}
+
+func (t *metricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	inFlight := t.m.inFlight.WithLabelValues(host)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	var (
+		mu                                   sync.Mutex
+		dnsStart, connectStart, tlsStart     time.Time
+		dnsDone, connectDone, tlsDone, first bool
+	)
+	start := time.Now()
+	observe := func(h *prometheus.HistogramVec, since time.Time, done *bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if *done || since.IsZero() {
+			return
+		}
+		*done = true
+		h.WithLabelValues(host).Observe(time.Since(since).Seconds())
+	}
+	setStart := func(t *time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		if t.IsZero() {
+			*t = time.Now()
+		}
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { setStart(&dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { observe(t.m.dns, dnsStart, &dnsDone) },
+		ConnectStart: func(string, string) {
+			setStart(&connectStart)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				observe(t.m.connect, connectStart, &connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { setStart(&tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				observe(t.m.tls, tlsStart, &tlsDone)
+			}
+		},
+		GotFirstResponseByte: func() { observe(t.m.firstByte, start, &first) },
+	}
+
+	res, err := t.rt.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		t.m.requests.WithLabelValues(host, req.Method, "error").Inc()
+		return nil, err
+	}
+
+	t.m.duration.WithLabelValues(host).Observe(time.Since(start).Seconds())
+	t.m.requests.WithLabelValues(host, req.Method, strconv.Itoa(res.StatusCode)).Inc()
+	return res, nil
+}
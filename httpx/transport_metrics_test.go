@@ -0,0 +1,50 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportMetrics(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	m := NewTransportMetrics("test")
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(m))
+
+	c := &http.Client{Transport: m.RoundTripper(ts.Client().Transport)}
+	for _, path := range []string{"/", "/", "/missing"} {
+		res, err := c.Get(ts.URL + path)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+	}
+	_, err := c.Get("http://127.0.0.1:1/")
+	require.Error(t, err)
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues(host, "GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(host, "GET", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("127.0.0.1:1", "GET", "error")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.inFlight.WithLabelValues(host)))
+
+	// The connection is reused, so connect and TLS handshake are observed once.
+	assert.Equal(t, 1, testutil.CollectAndCount(m.connect, "test_http_client_connect_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.tls, "test_http_client_tls_handshake_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.firstByte, "test_http_client_first_byte_duration_seconds"))
+
+	problems, err := testutil.GatherAndLint(reg)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
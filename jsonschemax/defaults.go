@@ -0,0 +1,91 @@
+package jsonschemax
+
+import (
+	"encoding/json"
+
+	"github.com/ory/jsonschema/v3"
+)
+
+// ApplyDefaults sets the default values of the JSON Schema for all keys missing in the objects of the
+// document and returns the document. Unlike the defaults returned by ListPaths, it also applies the defaults
+// defined in `items`, `additionalItems`, `patternProperties`, and `additionalProperties`, as they depend on
+// the existing array elements and keys. Objects without default are not created. The schema must be compiled
+// with ExtractAnnotations enabled.
+//
+// Objects and arrays of the document are modified in place.
+func ApplyDefaults(schema *jsonschema.Schema, doc interface{}) interface{} {
+	if schema == nil {
+		return doc
+	}
+	if schema.Ref != nil {
+		return ApplyDefaults(schema.Ref, doc)
+	}
+	for _, s := range schema.AllOf {
+		doc = ApplyDefaults(s, doc)
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			var matched bool
+			if s, ok := schema.Properties[key]; ok {
+				value, matched = ApplyDefaults(s, value), true
+			}
+			for pattern, s := range schema.PatternProperties {
+				if pattern.MatchString(key) {
+					value, matched = ApplyDefaults(s, value), true
+				}
+			}
+			if s, ok := schema.AdditionalProperties.(*jsonschema.Schema); ok && !matched {
+				value = ApplyDefaults(s, value)
+			}
+			v[key] = value
+		}
+
+		for key, s := range schema.Properties {
+			if _, ok := v[key]; ok {
+				continue
+			}
+			if def, ok := schemaDefault(s); ok {
+				v[key] = ApplyDefaults(s, def)
+			}
+		}
+	case []interface{}:
+		switch items := schema.Items.(type) {
+		case *jsonschema.Schema:
+			for i := range v {
+				v[i] = ApplyDefaults(items, v[i])
+			}
+		case []*jsonschema.Schema:
+			for i := range v {
+				if i < len(items) {
+					v[i] = ApplyDefaults(items[i], v[i])
+				} else if s, ok := schema.AdditionalItems.(*jsonschema.Schema); ok {
+					v[i] = ApplyDefaults(s, v[i])
+				}
+			}
+		}
+	}
+	return doc
+}
+
+// schemaDefault returns a copy of the default value of the schema, so that it is not shared between documents.
+func schemaDefault(schema *jsonschema.Schema) (interface{}, bool) {
+	for schema.Default == nil && schema.Ref != nil {
+		schema = schema.Ref
+	}
+	if schema.Default == nil {
+		return nil, false
+	}
+
+	// The compiler decodes the schema using json.Number.
+	out, err := json.Marshal(schema.Default)
+	if err != nil {
+		return nil, false
+	}
+	var def interface{}
+	if err := json.Unmarshal(out, &def); err != nil {
+		return nil, false
+	}
+	return def, true
+}
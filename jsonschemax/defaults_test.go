@@ -0,0 +1,92 @@
+package jsonschemax
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/jsonschema/v3"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.ExtractAnnotations = true
+	require.NoError(t, c.AddResource("schema.json", strings.NewReader(`{
+  "definitions": {
+    "hook": {"type": "object", "properties": {"timeout": {"type": "string", "default": "1s"}}}
+  },
+  "type": "object",
+  "properties": {
+    "port": {"type": "integer", "default": 4433},
+    "tls": {"type": "object", "properties": {"enabled": {"type": "boolean", "default": false}}},
+    "urls": {
+      "type": "array",
+      "items": {"type": "object", "properties": {"weight": {"type": "number", "default": 1}}}
+    },
+    "pair": {
+      "type": "array",
+      "items": [{"type": "object", "properties": {"first": {"default": true}}}],
+      "additionalItems": {"type": "object", "properties": {"rest": {"default": true}}}
+    },
+    "hooks": {
+      "type": "object",
+      "patternProperties": {"^pre_": {"$ref": "#/definitions/hook"}},
+      "additionalProperties": {"type": "object", "properties": {"enabled": {"default": true}}}
+    },
+    "list": {"type": "array", "default": [{"a": 1}]}
+  }
+}`)))
+	schema, err := c.Compile(context.Background(), "schema.json")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name, doc, expected string
+	}{
+		{
+			name:     "empty document",
+			doc:      `{}`,
+			expected: `{"port":4433,"list":[{"a":1}]}`,
+		},
+		{
+			name:     "existing values",
+			doc:      `{"port":80,"tls":{},"list":[]}`,
+			expected: `{"port":80,"tls":{"enabled":false},"list":[]}`,
+		},
+		{
+			name:     "array items",
+			doc:      `{"urls":[{},{"weight":2}],"pair":[{},{},{"rest":false}]}`,
+			expected: `{"port":4433,"list":[{"a":1}],"urls":[{"weight":1},{"weight":2}],"pair":[{"first":true},{"rest":true},{"rest":false}]}`,
+		},
+		{
+			name:     "pattern and additional properties",
+			doc:      `{"hooks":{"pre_login":{},"post_login":{}}}`,
+			expected: `{"port":4433,"list":[{"a":1}],"hooks":{"pre_login":{"timeout":"1s"},"post_login":{"enabled":true}}}`,
+		},
+		{
+			name:     "not an object",
+			doc:      `"foo"`,
+			expected: `"foo"`,
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			var doc interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.doc), &doc))
+
+			actual, err := json.Marshal(ApplyDefaults(schema, doc))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+
+	t.Run("case=defaults are not shared", func(t *testing.T) {
+		a := ApplyDefaults(schema, map[string]interface{}{}).(map[string]interface{})
+		a["list"].([]interface{})[0].(map[string]interface{})["a"] = 2
+
+		b := ApplyDefaults(schema, map[string]interface{}{}).(map[string]interface{})
+		assert.EqualValues(t, 1, b["list"].([]interface{})[0].(map[string]interface{})["a"])
+	})
+}
@@ -0,0 +1,219 @@
+package jsonschemax
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// DocsFormat is the output format of GenerateDocs.
+	DocsFormat string
+
+	docsOptions struct {
+		format       DocsFormat
+		title        string
+		envPrefix    string
+		maxRecursion int16
+	}
+	// DocsOption configures GenerateDocs.
+	DocsOption func(*docsOptions)
+
+	docsEntry struct {
+		Name        string
+		Title       string
+		Description string
+		Type        string
+		Default     string
+		Enum        []string
+		Examples    []string
+		EnvVar      string
+		Required    bool
+		ReadOnly    bool
+	}
+)
+
+const (
+	DocsFormatMarkdown DocsFormat = "markdown"
+	DocsFormatHTML     DocsFormat = "html"
+)
+
+// WithDocsFormat sets the output format. Defaults to DocsFormatMarkdown.
+func WithDocsFormat(format DocsFormat) DocsOption {
+	return func(o *docsOptions) {
+		o.format = format
+	}
+}
+
+// WithDocsTitle sets the title of the reference. Defaults to "Configuration Reference".
+func WithDocsTitle(title string) DocsOption {
+	return func(o *docsOptions) {
+		o.title = title
+	}
+}
+
+// WithDocsEnvPrefix sets the prefix of the environment variable names, e.g. `KRATOS_`.
+func WithDocsEnvPrefix(prefix string) DocsOption {
+	return func(o *docsOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithDocsMaxRecursion sets how often circular references are followed. Defaults to 0.
+func WithDocsMaxRecursion(maxRecursion uint8) DocsOption {
+	return func(o *docsOptions) {
+		o.maxRecursion = int16(maxRecursion)
+	}
+}
+
+// GenerateDocs writes a reference of all configuration keys of the JSON Schema to w. For each key, it lists
+// the title, description, type, default value, allowed values, examples, and the name of the environment
+// variable setting it, as loaded by configx. Arrays are denoted with `#` in key paths and `<INDEX>` in
+// environment variable names.
+func GenerateDocs(ctx context.Context, w io.Writer, schema []byte, opts ...DocsOption) error {
+	o := &docsOptions{
+		format: DocsFormatMarkdown,
+		title:  "Configuration Reference",
+	}
+	for _, f := range opts {
+		f(o)
+	}
+
+	paths, err := listPathsBytesWithArrays(ctx, schema, o.maxRecursion)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]docsEntry, len(paths))
+	for i, p := range paths {
+		entries[i] = newDocsEntry(p, o.envPrefix)
+	}
+	data := struct {
+		Title   string
+		Entries []docsEntry
+	}{Title: o.title, Entries: entries}
+
+	switch o.format {
+	case DocsFormatMarkdown:
+		return errors.WithStack(markdownDocsTemplate.Execute(w, data))
+	case DocsFormatHTML:
+		return errors.WithStack(htmlDocsTemplate.Execute(w, data))
+	}
+	return errors.Errorf("unknown docs format: %s", o.format)
+}
+
+func newDocsEntry(p Path, envPrefix string) docsEntry {
+	e := docsEntry{
+		Name:        p.Name,
+		Title:       p.Title,
+		Description: p.Description,
+		Type:        pathTypeName(p),
+		EnvVar:      EnvVarName(envPrefix, p.Name),
+		Required:    p.Required,
+		ReadOnly:    p.ReadOnly,
+	}
+	if p.Default != nil {
+		e.Default = docsValue(p.Default)
+	}
+	for _, v := range p.Enum {
+		e.Enum = append(e.Enum, docsValue(v))
+	}
+	for _, v := range p.Examples {
+		e.Examples = append(e.Examples, docsValue(v))
+	}
+	return e
+}
+
+// pathTypeName returns the JSON Schema type name of the path.
+func pathTypeName(p Path) string {
+	switch p.TypeHint {
+	case String:
+		return "string"
+	case Float:
+		return "number"
+	case Int:
+		return "integer"
+	case Bool:
+		return "boolean"
+	case Nil:
+		return "null"
+	case BoolSlice:
+		return "array of booleans"
+	case StringSlice:
+		return "array of strings"
+	case IntSlice:
+		return "array of integers"
+	case FloatSlice:
+		return "array of numbers"
+	}
+
+	switch p.Type.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "any"
+}
+
+func docsValue(v interface{}) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(out)
+}
+
+var markdownDocsTemplate = template.Must(template.New("markdown").Parse(`# {{ .Title }}
+{{ range .Entries }}
+## ` + "`{{ .Name }}`" + `
+{{ with .Title }}
+**{{ . }}**
+{{ end }}{{ with .Description }}
+{{ . }}
+{{ end }}
+- Type: ` + "`{{ .Type }}`" + `{{ if .Required }}
+- Required: yes{{ end }}{{ if .ReadOnly }}
+- Read only: yes{{ end }}{{ with .Default }}
+- Default: ` + "`{{ . }}`" + `{{ end }}{{ with .Enum }}
+- Allowed values: {{ range $i, $v := . }}{{ if $i }}, {{ end }}` + "`{{ $v }}`" + `{{ end }}{{ end }}{{ with .Examples }}
+- Examples: {{ range $i, $v := . }}{{ if $i }}, {{ end }}` + "`{{ $v }}`" + `{{ end }}{{ end }}
+- Environment variable: ` + "`{{ .EnvVar }}`" + `
+{{ end }}`))
+
+var htmlDocsTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<h1>{{ .Title }}</h1>
+{{ range .Entries }}
+<section id="{{ .Name }}">
+  <h2><code>{{ .Name }}</code></h2>
+  {{- with .Title }}
+  <p><strong>{{ . }}</strong></p>
+  {{- end }}
+  {{- with .Description }}
+  <p>{{ . }}</p>
+  {{- end }}
+  <dl>
+    <dt>Type</dt><dd><code>{{ .Type }}</code></dd>
+    {{- if .Required }}
+    <dt>Required</dt><dd>yes</dd>
+    {{- end }}
+    {{- if .ReadOnly }}
+    <dt>Read only</dt><dd>yes</dd>
+    {{- end }}
+    {{- with .Default }}
+    <dt>Default</dt><dd><code>{{ . }}</code></dd>
+    {{- end }}
+    {{- with .Enum }}
+    <dt>Allowed values</dt><dd>{{ range $i, $v := . }}{{ if $i }}, {{ end }}<code>{{ $v }}</code>{{ end }}</dd>
+    {{- end }}
+    {{- with .Examples }}
+    <dt>Examples</dt><dd>{{ range $i, $v := . }}{{ if $i }}, {{ end }}<code>{{ $v }}</code>{{ end }}</dd>
+    {{- end }}
+    <dt>Environment variable</dt><dd><code>{{ .EnvVar }}</code></dd>
+  </dl>
+</section>
+{{ end }}`))
@@ -0,0 +1,74 @@
+package jsonschemax
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const docsSchema = `{
+  "type": "object",
+  "properties": {
+    "serve": {
+      "type": "object",
+      "title": "HTTP Server",
+      "properties": {
+        "port": {
+          "type": "integer",
+          "description": "The port to listen on.",
+          "default": 4433,
+          "examples": [80, 443]
+        },
+        "host": {"type": "string"}
+      }
+    },
+    "log": {
+      "type": "object",
+      "properties": {
+        "level": {"type": "string", "enum": ["debug", "info"], "description": "Use <b>debug</b> for development."}
+      }
+    },
+    "urls": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {"url": {"type": "string"}},
+        "required": ["url"]
+      }
+    }
+  },
+  "required": ["serve"]
+}`
+
+func TestGenerateDocs(t *testing.T) {
+	t.Run("format=markdown", func(t *testing.T) {
+		var b bytes.Buffer
+		require.NoError(t, GenerateDocs(context.Background(), &b, []byte(docsSchema), WithDocsEnvPrefix("APP_"), WithDocsTitle("App Configuration")))
+		out := b.String()
+
+		assert.Contains(t, out, "# App Configuration\n")
+		assert.Contains(t, out, "## `serve`\n\n**HTTP Server**\n\n- Type: `object`\n- Required: yes\n- Environment variable: `APP_SERVE`\n")
+		assert.Contains(t, out, "## `serve.port`\n\nThe port to listen on.\n\n- Type: `integer`\n- Default: `4433`\n- Examples: `80`, `443`\n- Environment variable: `APP_SERVE_PORT`\n")
+		assert.Contains(t, out, "- Allowed values: `\"debug\"`, `\"info\"`\n")
+		assert.Contains(t, out, "## `urls.#.url`\n\n- Type: `string`\n- Required: yes\n- Environment variable: `APP_URLS_<INDEX>_URL`\n")
+	})
+
+	t.Run("format=html", func(t *testing.T) {
+		var b bytes.Buffer
+		require.NoError(t, GenerateDocs(context.Background(), &b, []byte(docsSchema), WithDocsFormat(DocsFormatHTML)))
+		out := b.String()
+
+		assert.Contains(t, out, "<h1>Configuration Reference</h1>")
+		assert.Contains(t, out, `<section id="serve.port">`)
+		assert.Contains(t, out, "<dt>Default</dt><dd><code>4433</code></dd>")
+		assert.Contains(t, out, "<p>Use &lt;b&gt;debug&lt;/b&gt; for development.</p>")
+		assert.Contains(t, out, "<dt>Environment variable</dt><dd><code>URLS_&lt;INDEX&gt;_URL</code></dd>")
+	})
+
+	t.Run("case=unknown format", func(t *testing.T) {
+		assert.Error(t, GenerateDocs(context.Background(), new(bytes.Buffer), []byte(docsSchema), WithDocsFormat("pdf")))
+	})
+}
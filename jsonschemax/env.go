@@ -0,0 +1,116 @@
+package jsonschemax
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+)
+
+// EnvPath is a JSON Schema path with the environment variable setting it.
+type EnvPath struct {
+	Path
+
+	// EnvVar is the name of the environment variable, e.g. `SERVE_PUBLIC_PORT`. Array indices are denoted
+	// with `<INDEX>`.
+	EnvVar string
+
+	// TypeName is the JSON Schema type of the path, e.g. `integer` or `array of strings`.
+	TypeName string
+}
+
+var envVarReplacer = strings.NewReplacer(".", "_", "#", "<INDEX>")
+
+// EnvVarName returns the name of the environment variable setting the path, as loaded by configx, e.g.
+// `KRATOS_SERVE_PUBLIC_PORT` for the prefix `KRATOS_` and the path `serve.public.port`.
+func EnvVarName(prefix, path string) string {
+	return prefix + strings.ToUpper(envVarReplacer.Replace(path))
+}
+
+// ListPathsWithEnv lists all leaf paths of the JSON Schema, which are the paths not containing other paths,
+// together with their environment variable name and type. Arrays are included, and circular references are
+// followed once.
+func ListPathsWithEnv(ctx context.Context, schema []byte, prefix string) ([]EnvPath, error) {
+	paths, err := listPathsBytesWithArrays(ctx, schema, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[string]bool)
+	for _, p := range paths {
+		for i := strings.LastIndex(p.Name, "."); i >= 0; i = strings.LastIndex(p.Name[:i], ".") {
+			parents[p.Name[:i]] = true
+		}
+	}
+
+	var leaves []EnvPath
+	for _, p := range paths {
+		if parents[p.Name] {
+			continue
+		}
+		leaves = append(leaves, EnvPath{Path: p, EnvVar: EnvVarName(prefix, p.Name), TypeName: pathTypeName(p)})
+	}
+	return leaves, nil
+}
+
+// FindUnknownEnvVars returns the names of the environment variables in environ, in the format of
+// os.Environ, which start with the prefix but do not set any path of the JSON Schema. Use it to warn about
+// typos in environment variables. The prefix must not be empty, as all other environment variables would
+// be reported otherwise.
+func FindUnknownEnvVars(ctx context.Context, schema []byte, prefix string, environ []string) ([]string, error) {
+	if prefix == "" {
+		return nil, errors.New("the prefix of the environment variables must not be empty")
+	}
+
+	paths, err := listPathsBytesWithArrays(ctx, schema, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make([]*regexp.Regexp, len(paths))
+	for i, p := range paths {
+		parts := strings.Split(EnvVarName(prefix, p.Name), "<INDEX>")
+		for k := range parts {
+			parts[k] = regexp.QuoteMeta(parts[k])
+		}
+		known[i] = regexp.MustCompile("^" + strings.Join(parts, "[0-9]+") + "$")
+	}
+
+	var unknown []string
+	for _, kv := range environ {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if !strings.HasPrefix(name, prefix) || matchesAny(known, name) {
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, p := range patterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// listPathsBytesWithArrays is like ListPathsBytes but includes arrays and extracts the annotations.
+func listPathsBytesWithArrays(ctx context.Context, schema []byte, maxRecursion int16) ([]Path, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.ExtractAnnotations = true
+	id := fmt.Sprintf("%x.json", sha256.Sum256(schema))
+	if err := compiler.AddResource(id, bytes.NewReader(schema)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return runPathsFromCompiler(ctx, id, compiler, maxRecursion, true)
+}
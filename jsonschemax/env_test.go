@@ -0,0 +1,50 @@
+package jsonschemax
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvVarName(t *testing.T) {
+	assert.Equal(t, "APP_SERVE_PORT", EnvVarName("APP_", "serve.port"))
+	assert.Equal(t, "URLS_<INDEX>_URL", EnvVarName("", "urls.#.url"))
+}
+
+func TestListPathsWithEnv(t *testing.T) {
+	paths, err := ListPathsWithEnv(context.Background(), []byte(docsSchema), "APP_")
+	require.NoError(t, err)
+
+	actual := make(map[string][2]string, len(paths))
+	for _, p := range paths {
+		actual[p.Name] = [2]string{p.EnvVar, p.TypeName}
+	}
+	assert.Equal(t, map[string][2]string{
+		"log.level":  {"APP_LOG_LEVEL", "string"},
+		"serve.host": {"APP_SERVE_HOST", "string"},
+		"serve.port": {"APP_SERVE_PORT", "integer"},
+		"urls.#.url": {"APP_URLS_<INDEX>_URL", "string"},
+	}, actual)
+}
+
+func TestFindUnknownEnvVars(t *testing.T) {
+	t.Run("case=reports unknown variables", func(t *testing.T) {
+		unknown, err := FindUnknownEnvVars(context.Background(), []byte(docsSchema), "APP_", []string{
+			"PATH=/usr/bin",
+			"APP_SERVE_PORT=4433",
+			"APP_SERVE={}",
+			"APP_URLS_0_URL=https://example.com",
+			"APP_URLS_X_URL=https://example.com",
+			"APP_SERVE_PROT=4433",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"APP_SERVE_PROT", "APP_URLS_X_URL"}, unknown)
+	})
+
+	t.Run("case=requires a prefix", func(t *testing.T) {
+		_, err := FindUnknownEnvVars(context.Background(), []byte(docsSchema), "", nil)
+		assert.Error(t, err)
+	})
+}
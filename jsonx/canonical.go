@@ -0,0 +1,124 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MarshalCanonical returns the canonical JSON encoding of v, which is the same for equal values regardless
+// of field order, number notation, and whitespace, so it can be hashed, signed, and diffed:
+//
+//   - object keys are sorted and there is no whitespace;
+//   - integer literals are not rounded, e.g. `12345678901234567890` stays as is;
+//   - other numbers are encoded like in JavaScript, e.g. `1.0`, `1e2`, and `0.1e-6` become `1`, `100`, and
+//     `1e-7`;
+//   - HTML characters in strings are not escaped.
+//
+// Raw JSON can be canonicalized by passing it as json.RawMessage.
+func MarshalCanonical(v interface{}) ([]byte, error) {
+	var raw bytes.Buffer
+	e := json.NewEncoder(&raw)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	decoded, err := decodeNumbers(raw.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := writeCanonical(&b, decoded); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeCanonical(b *bytes.Buffer, v interface{}) error {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeCanonical(b, k); err != nil {
+				return err
+			}
+			b.WriteByte(':')
+			if err := writeCanonical(b, vv[k]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case []interface{}:
+		b.WriteByte('[')
+		for i, item := range vv {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeCanonical(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case json.Number:
+		n, err := canonicalNumber(vv)
+		if err != nil {
+			return err
+		}
+		b.WriteString(n)
+	case string:
+		e := json.NewEncoder(b)
+		e.SetEscapeHTML(false)
+		if err := e.Encode(vv); err != nil {
+			return errors.WithStack(err)
+		}
+		// Encode terminates the value with a newline.
+		b.Truncate(b.Len() - 1)
+	case bool:
+		b.WriteString(strconv.FormatBool(vv))
+	case nil:
+		b.WriteString("null")
+	default:
+		return errors.Errorf("unable to canonicalize value of type %T", v)
+	}
+	return nil
+}
+
+func canonicalNumber(n json.Number) (string, error) {
+	if i, ok := new(big.Int).SetString(string(n), 10); ok {
+		return i.String(), nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+
+	// FormatFloat pads the exponent to two digits, e.g. `1e-07`, which JavaScript does not.
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	i := strings.IndexByte(s, 'e')
+	exp := strings.TrimLeft(s[i+2:], "0")
+	return s[:i+2] + exp, nil
+}
@@ -0,0 +1,47 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalCanonical(t *testing.T) {
+	for k, tc := range []struct {
+		in       interface{}
+		expected string
+	}{
+		{in: json.RawMessage(`{"b": 1, "a": {"d": [1, 2], "c": null}}`), expected: `{"a":{"c":null,"d":[1,2]},"b":1}`},
+		{in: json.RawMessage(`[1.0, 1e2, -0, 0.5, 1e21, 1.5e-7, 0.000001, 12345678901234567890]`), expected: `[1,100,0,0.5,1e+21,1.5e-7,0.000001,12345678901234567890]`},
+		{in: json.RawMessage(`"<a href=\"#\">&</a>"`), expected: `"<a href=\"#\">&</a>"`},
+		{in: json.RawMessage(`"ä\n"`), expected: `"ä\n"`},
+		{in: json.RawMessage(`true`), expected: `true`},
+		{in: struct {
+			Z string  `json:"z"`
+			A float64 `json:"a"`
+		}{Z: "z", A: 2}, expected: `{"a":2,"z":"z"}`},
+		{in: map[string]interface{}{"b": []string{"x"}, "a": false}, expected: `{"a":false,"b":["x"]}`},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			actual, err := MarshalCanonical(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(actual))
+		})
+	}
+
+	t.Run("case=equal values are encoded equally", func(t *testing.T) {
+		a, err := MarshalCanonical(json.RawMessage(`{"a": 1.0, "b": [true]}`))
+		require.NoError(t, err)
+		b, err := MarshalCanonical(map[string]interface{}{"b": []bool{true}, "a": 1})
+		require.NoError(t, err)
+		assert.Equal(t, a, b)
+	})
+
+	t.Run("case=invalid value", func(t *testing.T) {
+		_, err := MarshalCanonical(make(chan int))
+		assert.Error(t, err)
+	})
+}
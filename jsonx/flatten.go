@@ -2,6 +2,7 @@ package jsonx
 
 import (
 	"encoding/json"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -34,3 +35,79 @@ func flatten(parsed gjson.Result, parents []string, flattened map[string]interfa
 		flattened[strings.Join(parents, ".")] = parsed.Value()
 	}
 }
+
+// Unflatten reverses Flatten: it converts the keys in dot notation into nested objects. Dots escaped with a
+// backslash are part of the key, and objects whose keys are the indices 0 to n-1 become arrays. If a key is
+// both a value and the parent of other keys, e.g. `foo` and `foo.bar`, the nested object wins.
+func Unflatten(flattened map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(flattened))
+	for k := range flattened {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	unflattened := make(map[string]interface{})
+	for _, key := range keys {
+		parts := splitFlattenedKey(key)
+		parent := unflattened
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := parent[part].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[part] = child
+			}
+			parent = child
+		}
+		if _, ok := parent[parts[len(parts)-1]].(map[string]interface{}); !ok {
+			parent[parts[len(parts)-1]] = flattened[key]
+		}
+	}
+
+	for k, v := range unflattened {
+		unflattened[k] = restoreArrays(v)
+	}
+	return unflattened
+}
+
+// splitFlattenedKey splits the key at all dots not escaped with a backslash.
+func splitFlattenedKey(key string) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key) && key[i+1] == '.':
+			part.WriteByte('.')
+			i++
+		case key[i] == '.':
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(key[i])
+		}
+	}
+	return append(parts, part.String())
+}
+
+func restoreArrays(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	for k, vv := range m {
+		m[k] = restoreArrays(vv)
+	}
+
+	if len(m) == 0 {
+		return m
+	}
+	array := make([]interface{}, len(m))
+	for k, vv := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+		array[i] = vv
+	}
+	return array
+}
@@ -1,6 +1,7 @@
 package jsonx
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"testing"
@@ -37,3 +38,32 @@ func TestFlatten(t *testing.T) {
 		})
 	}
 }
+
+func TestUnflatten(t *testing.T) {
+	t.Run("case=reverses Flatten", func(t *testing.T) {
+		f, err := ioutil.ReadFile("./stub/random.json")
+		require.NoError(t, err)
+
+		actual, err := json.Marshal(Unflatten(Flatten(f)))
+		require.NoError(t, err)
+		assert.JSONEq(t, string(f), string(actual))
+	})
+
+	for k, tc := range []struct {
+		flattened map[string]interface{}
+		expected  map[string]interface{}
+	}{
+		{flattened: map[string]interface{}{}, expected: map[string]interface{}{}},
+		{flattened: map[string]interface{}{"foo": "bar"}, expected: map[string]interface{}{"foo": "bar"}},
+		{flattened: map[string]interface{}{"foo.0": "bar", "foo.1.foo": "bar"}, expected: map[string]interface{}{"foo": []interface{}{"bar", map[string]interface{}{"foo": "bar"}}}},
+		{flattened: map[string]interface{}{"foo.1": "bar"}, expected: map[string]interface{}{"foo": map[string]interface{}{"1": "bar"}}},
+		{flattened: map[string]interface{}{"foo.0": "bar", "foo.01": "baz"}, expected: map[string]interface{}{"foo": map[string]interface{}{"0": "bar", "01": "baz"}}},
+		{flattened: map[string]interface{}{"0": "bar"}, expected: map[string]interface{}{"0": "bar"}},
+		{flattened: map[string]interface{}{`foo\.bar.baz`: "bar"}, expected: map[string]interface{}{"foo.bar": map[string]interface{}{"baz": "bar"}}},
+		{flattened: map[string]interface{}{"foo": "bar", "foo.bar": "baz"}, expected: map[string]interface{}{"foo": map[string]interface{}{"bar": "baz"}}},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.EqualValues(t, tc.expected, Unflatten(tc.flattened))
+		})
+	}
+}
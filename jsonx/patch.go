@@ -0,0 +1,415 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/jsonschema/v3"
+)
+
+type (
+	// PatchOperation is an operation of a RFC 6902 JSON Patch.
+	PatchOperation struct {
+		// Op is one of `add`, `remove`, `replace`, `move`, `copy`, and `test`.
+		Op string `json:"op"`
+		// Path is the JSON Pointer of the target location, e.g. `/serve/port`.
+		Path string `json:"path"`
+		// From is the JSON Pointer of the source location of the `move` and `copy` operations.
+		From string `json:"from,omitempty"`
+		// Value is the value of the `add`, `replace`, and `test` operations.
+		Value json.RawMessage `json:"value,omitempty"`
+	}
+
+	// PatchError is returned if an operation of a JSON Patch can not be applied.
+	PatchError struct {
+		// Index is the index of the operation in the patch.
+		Index int
+		// Op is the operation, e.g. `replace`.
+		Op string
+		// Path is the JSON Pointer the operation failed at.
+		Path string
+		// Reason describes why the operation failed.
+		Reason string
+	}
+
+	patchOptions struct {
+		schema *jsonschema.Schema
+	}
+	// PatchOption configures ApplyJSONPatch and ApplyMergePatch.
+	PatchOption func(*patchOptions)
+
+	containerFunc func(container interface{}, token string) (interface{}, error)
+)
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("unable to apply operation %d (%s %s): %s", e.Index, e.Op, e.Path, e.Reason)
+}
+
+// WithPatchSchema validates the patched document against the JSON Schema. If the document is invalid,
+// the *jsonschema.ValidationError is returned, pointing to the invalid values.
+func WithPatchSchema(schema *jsonschema.Schema) PatchOption {
+	return func(o *patchOptions) {
+		o.schema = schema
+	}
+}
+
+// ApplyJSONPatch applies the RFC 6902 JSON Patch to the JSON document and returns the patched document.
+// The operations are applied in order; if one fails, a *PatchError is returned and the document is not
+// changed.
+func ApplyJSONPatch(doc, patch []byte, opts ...PatchOption) ([]byte, error) {
+	var ops []PatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, errors.Wrap(err, "unable to decode the JSON Patch")
+	}
+
+	v, err := decodeNumbers(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the document")
+	}
+
+	for i, op := range ops {
+		v, err = applyOperation(v, op)
+		if err != nil {
+			return nil, errors.WithStack(&PatchError{Index: i, Op: op.Op, Path: op.Path, Reason: err.Error()})
+		}
+	}
+
+	return encodePatched(v, opts)
+}
+
+// ApplyMergePatch applies the RFC 7386 JSON Merge Patch to the JSON document and returns the patched
+// document. Keys set to null in the patch are removed, objects are merged recursively, and all other
+// values are replaced.
+func ApplyMergePatch(doc, patch []byte, opts ...PatchOption) ([]byte, error) {
+	p, err := decodeNumbers(patch)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the JSON Merge Patch")
+	}
+
+	v, err := decodeNumbers(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the document")
+	}
+
+	return encodePatched(mergePatch(v, p), opts)
+}
+
+func encodePatched(v interface{}, opts []PatchOption) ([]byte, error) {
+	o := new(patchOptions)
+	for _, f := range opts {
+		f(o)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if o.schema != nil {
+		if err := o.schema.Validate(bytes.NewReader(out)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return out, nil
+}
+
+func decodeNumbers(raw []byte) (interface{}, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return v, nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{}, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New("the value is missing")
+		}
+		value, err := decodeNumbers(op.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			return addValue(doc, path, value)
+		case "replace":
+			return replaceValue(doc, path, value)
+		}
+
+		actual, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(actual, value) {
+			return nil, errors.New("the value does not match")
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err := removeValue(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			value, err := getValue(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return addValue(doc, path, deepCopy(value))
+		}
+
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.Errorf("unable to move %s into one of its children", op.From)
+		}
+		doc, value, err := removeValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	}
+	return nil, errors.Errorf("unknown operation: %s", op.Op)
+}
+
+// parsePointer splits the RFC 6901 JSON Pointer into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf("the JSON Pointer must start with a slash: %s", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateContainer(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			if token == "-" {
+				return append(c, value), nil
+			}
+			i, err := arrayIndex(token, len(c)+1)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, errors.Errorf("unable to add %s to a value which is neither an object nor an array", token)
+	})
+}
+
+func replaceValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateContainer(doc, path, func(container interface{}, token string) (interface{}, error) {
+		if _, err := child(container, token); err != nil {
+			return nil, err
+		}
+		return setChild(container, token, value), nil
+	})
+}
+
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("unable to remove the document")
+	}
+
+	var removed interface{}
+	doc, err := updateContainer(doc, path, func(container interface{}, token string) (interface{}, error) {
+		var err error
+		if removed, err = child(container, token); err != nil {
+			return nil, err
+		}
+
+		switch c := container.(type) {
+		case map[string]interface{}:
+			delete(c, token)
+			return c, nil
+		case []interface{}:
+			i, _ := arrayIndex(token, len(c))
+			return append(c[:i:i], c[i+1:]...), nil
+		}
+		return container, nil
+	})
+	return doc, removed, err
+}
+
+// updateContainer calls f with the object or array containing the last token of the path and replaces it
+// with the returned value. Arrays change their length, so they are replaced in their parent.
+func updateContainer(doc interface{}, path []string, f containerFunc) (interface{}, error) {
+	if len(path) == 1 {
+		return f(doc, path[0])
+	}
+
+	c, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := updateContainer(c, path[1:], f)
+	if err != nil {
+		return nil, err
+	}
+	return setChild(doc, path[0], updated), nil
+}
+
+func child(doc interface{}, token string) (interface{}, error) {
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		v, ok := c[token]
+		if !ok {
+			return nil, errors.Errorf("the key %s does not exist", token)
+		}
+		return v, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(c))
+		if err != nil {
+			return nil, err
+		}
+		return c[i], nil
+	}
+	return nil, errors.Errorf("unable to get %s of a value which is neither an object nor an array", token)
+}
+
+// setChild sets the existing key or index of the object or array.
+func setChild(doc interface{}, token string, value interface{}) interface{} {
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		c[token] = value
+	case []interface{}:
+		i, _ := arrayIndex(token, len(c))
+		c[i] = value
+	}
+	return doc
+}
+
+func arrayIndex(token string, length int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.Errorf("%s is not an array index", token)
+	}
+	if i >= length {
+		return 0, errors.Errorf("the array index %d is out of bounds", i)
+	}
+	return i, nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(c))
+		for k, vv := range c {
+			m[k] = deepCopy(vv)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(c))
+		for k, vv := range c {
+			s[k] = deepCopy(vv)
+		}
+		return s
+	}
+	return v
+}
+
+// jsonEqual compares decoded JSON values. Numbers are equal if their values are equal, e.g. 1 and 1.0.
+func jsonEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if w, ok := bv[k]; !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k := range av {
+			if !jsonEqual(av[k], bv[k]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okx := new(big.Rat).SetString(string(av))
+		y, oky := new(big.Rat).SetString(string(bv))
+		return okx && oky && x.Cmp(y) == 0
+	}
+	return a == b
+}
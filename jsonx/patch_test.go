@@ -0,0 +1,116 @@
+package jsonx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/jsonschema/v3"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	const doc = `{"foo":"bar","baz":{"qux":1},"list":["a","b"]}`
+
+	for k, tc := range []struct {
+		patch    string
+		expected string
+		err      string
+	}{
+		{patch: `[{"op":"add","path":"/new","value":{"a":1}}]`, expected: `{"baz":{"qux":1},"foo":"bar","list":["a","b"],"new":{"a":1}}`},
+		{patch: `[{"op":"add","path":"/list/1","value":"x"}]`, expected: `{"baz":{"qux":1},"foo":"bar","list":["a","x","b"]}`},
+		{patch: `[{"op":"add","path":"/list/-","value":"c"}]`, expected: `{"baz":{"qux":1},"foo":"bar","list":["a","b","c"]}`},
+		{patch: `[{"op":"add","path":"/foo","value":null}]`, expected: `{"baz":{"qux":1},"foo":null,"list":["a","b"]}`},
+		{patch: `[{"op":"remove","path":"/baz/qux"}]`, expected: `{"baz":{},"foo":"bar","list":["a","b"]}`},
+		{patch: `[{"op":"remove","path":"/list/0"}]`, expected: `{"baz":{"qux":1},"foo":"bar","list":["b"]}`},
+		{patch: `[{"op":"replace","path":"/baz/qux","value":12345678901234567890}]`, expected: `{"baz":{"qux":12345678901234567890},"foo":"bar","list":["a","b"]}`},
+		{patch: `[{"op":"move","from":"/foo","path":"/baz/foo"}]`, expected: `{"baz":{"foo":"bar","qux":1},"list":["a","b"]}`},
+		{patch: `[{"op":"copy","from":"/baz","path":"/copy"},{"op":"add","path":"/copy/x","value":true}]`, expected: `{"baz":{"qux":1},"copy":{"qux":1,"x":true},"foo":"bar","list":["a","b"]}`},
+		{patch: `[{"op":"test","path":"/baz/qux","value":1.0},{"op":"remove","path":"/foo"}]`, expected: `{"baz":{"qux":1},"list":["a","b"]}`},
+		{patch: `[{"op":"replace","path":"","value":[]}]`, expected: `[]`},
+		{patch: `[{"op":"add","path":"/a~1b~0c","value":1}]`, expected: `{"a/b~c":1,"baz":{"qux":1},"foo":"bar","list":["a","b"]}`},
+		{patch: `[{"op":"test","path":"/foo","value":"baz"}]`, err: "unable to apply operation 0 (test /foo): the value does not match"},
+		{patch: `[{"op":"add","path":"/x","value":1},{"op":"replace","path":"/missing","value":1}]`, err: "unable to apply operation 1 (replace /missing): the key missing does not exist"},
+		{patch: `[{"op":"remove","path":"/list/2"}]`, err: "the array index 2 is out of bounds"},
+		{patch: `[{"op":"remove","path":"/list/01"}]`, err: "01 is not an array index"},
+		{patch: `[{"op":"add","path":"/foo/bar","value":1}]`, err: "neither an object nor an array"},
+		{patch: `[{"op":"add","path":"/foo"}]`, err: "the value is missing"},
+		{patch: `[{"op":"move","from":"/baz","path":"/baz/qux/x"}]`, err: "unable to move /baz into one of its children"},
+		{patch: `[{"op":"invalid","path":"/foo"}]`, err: "unknown operation: invalid"},
+		{patch: `[{"op":"remove","path":"foo"}]`, err: "must start with a slash"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			actual, err := ApplyJSONPatch([]byte(doc), []byte(tc.patch))
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+
+				var e *PatchError
+				assert.True(t, errors.As(err, &e))
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	for k, tc := range []struct {
+		doc, patch, expected string
+	}{
+		{doc: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{doc: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{doc: `{"a":"b"}`, patch: `{"a":null}`, expected: `{}`},
+		{doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{doc: `{"a":["b"]}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{doc: `{"a":"c"}`, patch: `{"a":["b"]}`, expected: `{"a":["b"]}`},
+		{doc: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, expected: `{"a":{"b":"d"}}`},
+		{doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, expected: `{"a":[1]}`},
+		{doc: `["a","b"]`, patch: `["c","d"]`, expected: `["c","d"]`},
+		{doc: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+		{doc: `{"e":null}`, patch: `{"a":1}`, expected: `{"e":null,"a":1}`},
+		{doc: `[1,2]`, patch: `{"a":"b","c":null}`, expected: `{"a":"b"}`},
+		{doc: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, expected: `{"a":{"bb":{}}}`},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			actual, err := ApplyMergePatch([]byte(tc.doc), []byte(tc.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+
+	t.Run("case=invalid patch", func(t *testing.T) {
+		_, err := ApplyMergePatch([]byte(`{}`), []byte(`{`))
+		assert.Error(t, err)
+	})
+}
+
+func TestPatchWithSchema(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	require.NoError(t, c.AddResource("schema.json", strings.NewReader(`{
+  "type": "object",
+  "properties": {"port": {"type": "integer"}}
+}`)))
+	schema, err := c.Compile(context.Background(), "schema.json")
+	require.NoError(t, err)
+
+	t.Run("case=valid", func(t *testing.T) {
+		actual, err := ApplyMergePatch([]byte(`{"port":80}`), []byte(`{"port":443}`), WithPatchSchema(schema))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"port":443}`, string(actual))
+	})
+
+	t.Run("case=invalid", func(t *testing.T) {
+		_, err := ApplyJSONPatch([]byte(`{"port":80}`), []byte(`[{"op":"replace","path":"/port","value":"foo"}]`), WithPatchSchema(schema))
+		require.Error(t, err)
+
+		var e *jsonschema.ValidationError
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, "#/port", e.InstancePtr)
+	})
+}
@@ -0,0 +1,282 @@
+package jwksx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/ory/x/httpx"
+	"github.com/ory/x/watcherx"
+)
+
+type (
+	// CachedFetcher fetches JSON Web Key Sets from a remote endpoint and caches them. Unlike Fetcher, it
+	// refreshes the keys in the background before they expire, so rotated keys are picked up and removed keys
+	// are no longer returned.
+	CachedFetcher struct {
+		remote string
+		o      *cachedFetcherOptions
+		group  singleflight.Group
+
+		sync.RWMutex
+		keys        map[string]jose.JSONWebKey
+		expiresAt   time.Time
+		refreshAt   time.Time
+		lastAttempt time.Time
+	}
+
+	cachedFetcherOptions struct {
+		hc                 *retryablehttp.Client
+		ttl                time.Duration
+		maxTTL             time.Duration
+		minRefreshInterval time.Duration
+		fetchTimeout       time.Duration
+		watch              *url.URL
+	}
+	// CachedFetcherOption configures the CachedFetcher.
+	CachedFetcherOption func(*cachedFetcherOptions)
+)
+
+// WithHTTPClient sets the HTTP client used to fetch the keys. Defaults to httpx.NewResilientClient, which
+// retries failed requests with exponential backoff.
+func WithHTTPClient(hc *retryablehttp.Client) CachedFetcherOption {
+	return func(o *cachedFetcherOptions) {
+		o.hc = hc
+	}
+}
+
+// WithTTL sets how long the keys are cached if the response has no Cache-Control max-age, no-store or
+// no-cache directive.
+// Defaults to 15 minutes.
+func WithTTL(ttl time.Duration) CachedFetcherOption {
+	return func(o *cachedFetcherOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithMaxTTL sets the maximum time the keys are cached, even if the Cache-Control max-age directive of the
+// response is longer. Defaults to 24 hours.
+func WithMaxTTL(ttl time.Duration) CachedFetcherOption {
+	return func(o *cachedFetcherOptions) {
+		o.maxTTL = ttl
+	}
+}
+
+// WithFetchTimeout sets the time after which fetching the keys, including retries, is aborted. Defaults to
+// one minute.
+func WithFetchTimeout(timeout time.Duration) CachedFetcherOption {
+	return func(o *cachedFetcherOptions) {
+		o.fetchTimeout = timeout
+	}
+}
+
+// WithMinRefreshInterval sets the minimum time between two fetches caused by unknown key IDs or failed
+// fetches, so that tokens with random key IDs can not be used to flood the remote. It is also the minimum
+// time the keys are cached, even if the Cache-Control max-age directive of the response is shorter.
+// Defaults to 30 seconds.
+func WithMinRefreshInterval(interval time.Duration) CachedFetcherOption {
+	return func(o *cachedFetcherOptions) {
+		o.minRefreshInterval = interval
+	}
+}
+
+// WithInvalidationWatcher watches the location using watcherx and invalidates the cached keys on every
+// change, e.g. of the file the remote serves.
+func WithInvalidationWatcher(u *url.URL) CachedFetcherOption {
+	return func(o *cachedFetcherOptions) {
+		o.watch = u
+	}
+}
+
+// NewCachedFetcher returns a CachedFetcher for the remote JSON Web Key Set. The keys are fetched and
+// refreshed in the background until the context is canceled.
+func NewCachedFetcher(ctx context.Context, remote string, opts ...CachedFetcherOption) (*CachedFetcher, error) {
+	o := &cachedFetcherOptions{
+		ttl:                15 * time.Minute,
+		maxTTL:             24 * time.Hour,
+		minRefreshInterval: 30 * time.Second,
+		fetchTimeout:       time.Minute,
+	}
+	for _, f := range opts {
+		f(o)
+	}
+	if o.hc == nil {
+		o.hc = httpx.NewResilientClient()
+	}
+
+	var events watcherx.EventChannel
+	if o.watch != nil {
+		events = make(watcherx.EventChannel)
+		if _, err := watcherx.Watch(ctx, o.watch, events); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &CachedFetcher{remote: remote, o: o}
+	go f.refreshInBackground(ctx, events)
+	return f, nil
+}
+
+// GetKey returns the JSON Web Key with the key ID. If the key is unknown or expired, the keys are fetched
+// again, but at most once per minimum refresh interval. If the keys can not be fetched, expired keys are
+// returned.
+func (f *CachedFetcher) GetKey(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	f.RLock()
+	k, ok := f.keys[kid]
+	expired := time.Now().After(f.expiresAt)
+	throttled := time.Since(f.lastAttempt) < f.o.minRefreshInterval
+	f.RUnlock()
+
+	if ok && !expired {
+		return &k, nil
+	}
+
+	if !throttled {
+		if err := f.refresh(ctx); err != nil && !ok {
+			return nil, err
+		}
+	}
+
+	f.RLock()
+	defer f.RUnlock()
+	if k, ok := f.keys[kid]; ok {
+		return &k, nil
+	}
+	return nil, errors.Errorf("unable to find JSON Web Key with ID: %s", kid)
+}
+
+// Invalidate removes all cached keys, so that they are fetched again by the next call to GetKey.
+func (f *CachedFetcher) Invalidate() {
+	f.Lock()
+	defer f.Unlock()
+	f.keys = nil
+	f.expiresAt, f.refreshAt, f.lastAttempt = time.Time{}, time.Time{}, time.Time{}
+}
+
+func (f *CachedFetcher) refreshInBackground(ctx context.Context, events watcherx.EventChannel) {
+	for {
+		f.RLock()
+		t := time.NewTimer(time.Until(f.refreshAt))
+		f.RUnlock()
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case e := <-events:
+			t.Stop()
+			if _, ok := e.(*watcherx.ErrorEvent); ok {
+				continue
+			}
+			f.Invalidate()
+		case <-t.C:
+		}
+
+		// Errors are returned by GetKey.
+		_ = f.refresh(ctx)
+	}
+}
+
+// refresh fetches the keys. Concurrent calls share one request, which is not aborted if the context of
+// the call which started it is canceled, because the other calls wait for its result.
+func (f *CachedFetcher) refresh(ctx context.Context) error {
+	result := f.group.DoChan("", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), f.o.fetchTimeout)
+		defer cancel()
+
+		keys, ttl, err := f.fetch(ctx)
+
+		f.Lock()
+		defer f.Unlock()
+		f.lastAttempt = time.Now()
+		if err != nil {
+			f.refreshAt = f.lastAttempt.Add(f.o.minRefreshInterval)
+			return nil, err
+		}
+
+		f.keys = keys
+		f.expiresAt = f.lastAttempt.Add(ttl)
+		// Refresh before the keys expire so that requests never wait for the remote.
+		f.refreshAt = f.lastAttempt.Add(ttl * 9 / 10)
+		return nil, nil
+	})
+
+	select {
+	case r := <-result:
+		return r.Err
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+func (f *CachedFetcher) fetch(ctx context.Context) (map[string]jose.JSONWebKey, time.Duration, error) {
+	req, err := retryablehttp.NewRequest("GET", f.remote, nil)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	res, err := f.o.hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, errors.Errorf("expected status code 200 but got %d when requesting %s", res.StatusCode, f.remote)
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(set.Keys))
+	for _, k := range set.Keys {
+		keys[k.KeyID] = k
+	}
+
+	ttl := f.o.ttl
+	if maxAge, ok := parseMaxAge(res.Header.Get("Cache-Control")); ok {
+		ttl = maxAge
+	}
+	// Short TTLs would cause a fetch on every request and long ones would keep removed keys around.
+	if ttl < f.o.minRefreshInterval {
+		ttl = f.o.minRefreshInterval
+	}
+	if ttl > f.o.maxTTL {
+		ttl = f.o.maxTTL
+	}
+	return keys, ttl, nil
+}
+
+// parseMaxAge returns how long the response may be cached according to the Cache-Control header. The
+// no-store and no-cache directives and max-age values of zero or less allow no caching at all.
+func parseMaxAge(header string) (time.Duration, bool) {
+	maxAge, ok := time.Duration(0), false
+	for _, directive := range strings.Split(header, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store", directive == "no-cache":
+			return 0, true
+		case strings.HasPrefix(directive, "max-age=") && !ok:
+			seconds, err := strconv.Atoi(strings.Trim(directive[len("max-age="):], `"`))
+			if err != nil {
+				continue
+			}
+			if seconds < 0 {
+				seconds = 0
+			}
+			maxAge, ok = time.Duration(seconds)*time.Second, true
+		}
+	}
+	return maxAge, ok
+}
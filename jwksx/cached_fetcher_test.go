@@ -0,0 +1,254 @@
+package jwksx
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	kid        = "7d5f5ad0674ec2f2960b1a34f33370a0f71471fa0e3ef0c0a692977d276dafe8"
+	rotatedKid = "rotated"
+	rotated    = `{"keys":[{"use":"sig","kty":"oct","kid":"rotated","alg":"HS256","k":"Y2hhbmdlbWVjaGFuZ2VtZWNoYW5nZW1lY2hhbmdlbWU"}]}`
+)
+
+type jwksServer struct {
+	*httptest.Server
+	called int32
+	body   atomic.Value
+	status int32
+}
+
+func newJWKSServer(t *testing.T, body string) *jwksServer {
+	s := &jwksServer{status: http.StatusOK}
+	s.body.Store(body)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.called, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&s.status)))
+		_, _ = w.Write([]byte(s.body.Load().(string)))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) calls() int {
+	return int(atomic.LoadInt32(&s.called))
+}
+
+func newTestCachedFetcher(t *testing.T, remote string, opts ...CachedFetcherOption) *CachedFetcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	hc := retryablehttp.NewClient()
+	hc.RetryMax = 0
+	hc.Logger = nil
+
+	f, err := NewCachedFetcher(ctx, remote, append([]CachedFetcherOption{WithHTTPClient(hc)}, opts...)...)
+	require.NoError(t, err)
+	return f
+}
+
+func TestCachedFetcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("case=caches keys", func(t *testing.T) {
+		s := newJWKSServer(t, keys)
+		f := newTestCachedFetcher(t, s.URL)
+
+		for i := 0; i < 3; i++ {
+			k, err := f.GetKey(ctx, kid)
+			require.NoError(t, err)
+			assert.EqualValues(t, secret, k.Key)
+		}
+		assert.Equal(t, 1, s.calls())
+	})
+
+	t.Run("case=throttles fetches of unknown keys", func(t *testing.T) {
+		s := newJWKSServer(t, keys)
+		f := newTestCachedFetcher(t, s.URL, WithMinRefreshInterval(time.Hour))
+
+		_, err := f.GetKey(ctx, kid)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err = f.GetKey(ctx, "does-not-exist")
+			require.Error(t, err)
+		}
+		assert.Equal(t, 1, s.calls())
+	})
+
+	t.Run("case=fetches unknown keys after the minimum refresh interval", func(t *testing.T) {
+		s := newJWKSServer(t, keys)
+		f := newTestCachedFetcher(t, s.URL, WithMinRefreshInterval(time.Millisecond))
+
+		_, err := f.GetKey(ctx, kid)
+		require.NoError(t, err)
+
+		s.body.Store(rotated)
+		time.Sleep(10 * time.Millisecond)
+
+		k, err := f.GetKey(ctx, rotatedKid)
+		require.NoError(t, err)
+		assert.Equal(t, rotatedKid, k.KeyID)
+	})
+
+	t.Run("case=refreshes keys in the background", func(t *testing.T) {
+		s := newJWKSServer(t, keys)
+		f := newTestCachedFetcher(t, s.URL, WithTTL(50*time.Millisecond), WithMinRefreshInterval(50*time.Millisecond))
+
+		_, err := f.GetKey(ctx, kid)
+		require.NoError(t, err)
+
+		s.body.Store(rotated)
+		assert.Eventually(t, func() bool {
+			f.RLock()
+			defer f.RUnlock()
+			_, ok := f.keys[rotatedKid]
+			return ok
+		}, time.Second, 10*time.Millisecond)
+
+		_, err = f.GetKey(ctx, kid)
+		require.Error(t, err, "rotated keys must no longer be returned")
+	})
+
+	t.Run("case=uses the max age of the response", func(t *testing.T) {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+			_, _ = w.Write([]byte(keys))
+		}))
+		defer s.Close()
+		f := newTestCachedFetcher(t, s.URL, WithTTL(time.Millisecond))
+
+		_, err := f.GetKey(ctx, kid)
+		require.NoError(t, err)
+
+		f.RLock()
+		defer f.RUnlock()
+		assert.True(t, f.expiresAt.After(time.Now().Add(59*time.Minute)))
+	})
+
+	t.Run("case=clamps the max age of the response", func(t *testing.T) {
+		for _, tc := range []struct {
+			maxAge   string
+			expected time.Duration
+		}{
+			{maxAge: "0", expected: time.Minute},
+			{maxAge: "1", expected: time.Minute},
+			{maxAge: "31536000", expected: time.Hour},
+		} {
+			t.Run("max-age="+tc.maxAge, func(t *testing.T) {
+				s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Cache-Control", "max-age="+tc.maxAge)
+					_, _ = w.Write([]byte(keys))
+				}))
+				defer s.Close()
+				f := newTestCachedFetcher(t, s.URL, WithMinRefreshInterval(time.Minute), WithMaxTTL(time.Hour))
+
+				_, err := f.GetKey(ctx, kid)
+				require.NoError(t, err)
+
+				f.RLock()
+				defer f.RUnlock()
+				assert.WithinDuration(t, f.lastAttempt.Add(tc.expected), f.expiresAt, time.Millisecond)
+			})
+		}
+	})
+
+	t.Run("case=fetches are not canceled with the caller's context", func(t *testing.T) {
+		release := make(chan struct{})
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			_, _ = w.Write([]byte(keys))
+		}))
+		defer s.Close()
+		f := newTestCachedFetcher(t, s.URL, WithMinRefreshInterval(0))
+
+		canceled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := f.GetKey(canceled, kid)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		close(release)
+		k, err := f.GetKey(ctx, kid)
+		require.NoError(t, err)
+		assert.EqualValues(t, secret, k.Key)
+	})
+
+	t.Run("case=returns expired keys if the remote fails", func(t *testing.T) {
+		s := newJWKSServer(t, keys)
+		f := newTestCachedFetcher(t, s.URL, WithTTL(time.Millisecond), WithMinRefreshInterval(time.Millisecond))
+
+		_, err := f.GetKey(ctx, kid)
+		require.NoError(t, err)
+
+		atomic.StoreInt32(&s.status, http.StatusInternalServerError)
+		time.Sleep(10 * time.Millisecond)
+
+		k, err := f.GetKey(ctx, kid)
+		require.NoError(t, err)
+		assert.EqualValues(t, secret, k.Key)
+	})
+
+	t.Run("case=returns fetch errors", func(t *testing.T) {
+		s := newJWKSServer(t, keys)
+		atomic.StoreInt32(&s.status, http.StatusNotFound)
+		f := newTestCachedFetcher(t, s.URL, WithMinRefreshInterval(0))
+
+		_, err := f.GetKey(ctx, kid)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected status code 200 but got 404")
+	})
+
+	t.Run("case=invalidates keys on changes of the watched file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, ioutil.WriteFile(file, []byte(keys), 0600))
+
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, file)
+		}))
+		defer s.Close()
+		f := newTestCachedFetcher(t, s.URL, WithMinRefreshInterval(time.Hour), WithInvalidationWatcher(&url.URL{Scheme: "file", Path: file}))
+
+		_, err := f.GetKey(ctx, kid)
+		require.NoError(t, err)
+
+		require.NoError(t, ioutil.WriteFile(file, []byte(rotated), 0600))
+		assert.Eventually(t, func() bool {
+			_, err := f.GetKey(ctx, rotatedKid)
+			return err == nil
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestParseMaxAge(t *testing.T) {
+	for header, tc := range map[string]struct {
+		expected time.Duration
+		ok       bool
+	}{
+		"max-age=60":                  {expected: time.Minute, ok: true},
+		"public, max-age=60, private": {expected: time.Minute, ok: true},
+		`MAX-AGE="60"`:                {expected: time.Minute, ok: true},
+		"max-age=0":                   {ok: true},
+		"max-age=-1":                  {ok: true},
+		"no-cache":                    {ok: true},
+		"max-age=60, no-store":        {ok: true},
+		"max-age=foo":                 {},
+		"":                            {},
+	} {
+		t.Run("header="+header, func(t *testing.T) {
+			actual, ok := parseMaxAge(header)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -2,27 +2,80 @@ package networkx
 
 import (
 	"net"
+	"os"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	"github.com/ory/x/configx"
 )
 
+// AddressIsUnixSocket returns true if the address is a unix socket address, e.g. `unix:/var/run/app.sock`
+// or `unix:///var/run/app.sock`.
 func AddressIsUnixSocket(address string) bool {
 	return strings.HasPrefix(address, "unix:")
 }
 
+// AddressIsSystemdSocket returns true if the address refers to a socket passed by systemd socket activation,
+// e.g. `systemd:` or `systemd:app-admin.socket`.
+func AddressIsSystemdSocket(address string) bool {
+	return strings.HasPrefix(address, "systemd:")
+}
+
+// UnixSocketPath returns the file path of a unix socket address.
+func UnixSocketPath(address string) string {
+	if strings.HasPrefix(address, "unix://") {
+		return strings.TrimPrefix(address, "unix://")
+	}
+	return strings.TrimPrefix(address, "unix:")
+}
+
+// MakeListener creates a listener for the given address. The address may be
+//
+//   - `unix:/path/to/socket` or `unix:///path/to/socket` to listen on a unix socket. The socket's
+//     ownership and mode are set from socketPermission if it is not nil. A stale socket file left
+//     behind by a previous process is removed.
+//   - `systemd:` or `systemd:<name>` to use a socket passed by systemd socket activation. Without a name the
+//     first passed socket is used, otherwise the one matching the name in `FileDescriptorName=`.
+//   - any other address to listen on TCP.
 func MakeListener(address string, socketPermission *configx.UnixPermission) (net.Listener, error) {
-	if AddressIsUnixSocket(address) {
-		addr := strings.TrimPrefix(address, "unix:")
-		l, err := net.Listen("unix", addr)
-		if err != nil {
+	switch {
+	case AddressIsUnixSocket(address):
+		addr := UnixSocketPath(address)
+		if err := removeStaleSocket(addr); err != nil {
 			return nil, err
 		}
-		err = socketPermission.SetPermission(addr)
+		l, err := net.Listen("unix", addr)
 		if err != nil {
 			return nil, err
 		}
+		if socketPermission != nil {
+			if err := socketPermission.SetPermission(addr); err != nil {
+				_ = l.Close()
+				return nil, err
+			}
+		}
 		return l, nil
+	case AddressIsSystemdSocket(address):
+		return SystemdListener(strings.TrimPrefix(address, "systemd:"))
 	}
 	return net.Listen("tcp", address)
 }
+
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return errors.Errorf("unable to listen on unix socket %s: file exists and is not a socket", path)
+	}
+	// Only remove the socket if no one is listening on it anymore.
+	if c, err := net.Dial("unix", path); err == nil {
+		_ = c.Close()
+		return errors.Errorf("unable to listen on unix socket %s: address already in use", path)
+	}
+	return errors.WithStack(os.Remove(path))
+}
@@ -2,9 +2,15 @@ package networkx
 
 import (
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/configx"
 )
 
 func TestAddressIsUnixSocket(t *testing.T) {
@@ -13,6 +19,7 @@ func TestAddressIsUnixSocket(t *testing.T) {
 		e bool
 	}{
 		{a: "unix:/var/baz", e: true},
+		{a: "unix:///var/baz", e: true},
 		{a: "https://foo", e: false},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
@@ -20,3 +27,65 @@ func TestAddressIsUnixSocket(t *testing.T) {
 		})
 	}
 }
+
+func TestUnixSocketPath(t *testing.T) {
+	assert.Equal(t, "/var/baz", UnixSocketPath("unix:/var/baz"))
+	assert.Equal(t, "/var/baz", UnixSocketPath("unix:///var/baz"))
+	assert.Equal(t, "baz", UnixSocketPath("unix:baz"))
+}
+
+func TestMakeListener(t *testing.T) {
+	t.Run("case=tcp", func(t *testing.T) {
+		l, err := MakeListener("127.0.0.1:0", nil)
+		require.NoError(t, err)
+		defer l.Close()
+		assert.Equal(t, "tcp", l.Addr().Network())
+	})
+
+	t.Run("case=unix socket with permissions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.sock")
+		l, err := MakeListener("unix://"+path, &configx.UnixPermission{Mode: 0o600})
+		require.NoError(t, err)
+		defer l.Close()
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0o600, fi.Mode().Perm())
+
+		c, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		_ = c.Close()
+	})
+
+	t.Run("case=removes stale unix socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.sock")
+		l, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, l.Close())
+
+		l, err = MakeListener("unix:"+path, nil)
+		require.NoError(t, err)
+		require.NoError(t, l.Close())
+	})
+
+	t.Run("case=does not remove socket in use", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.sock")
+		l, err := MakeListener("unix:"+path, nil)
+		require.NoError(t, err)
+		defer l.Close()
+
+		_, err = MakeListener("unix:"+path, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "address already in use")
+	})
+
+	t.Run("case=does not remove other files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.sock")
+		require.NoError(t, os.WriteFile(path, []byte("foo"), 0o600))
+
+		_, err := MakeListener("unix:"+path, nil)
+		require.Error(t, err)
+		assert.FileExists(t, path)
+	})
+}
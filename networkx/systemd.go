@@ -0,0 +1,91 @@
+package networkx
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd, see sd_listen_fds(3).
+const listenFDsStart = 3
+
+// ErrNoSystemdSocket is returned if no matching socket was passed by systemd.
+var ErrNoSystemdSocket = errors.New("no socket was passed by systemd socket activation")
+
+var (
+	systemdMu     sync.Mutex
+	systemdLoaded bool
+	// systemdFiles keeps the passed file descriptors open for the lifetime of the process.
+	systemdFiles []*os.File
+)
+
+// SystemdListener returns a listener for a socket passed by systemd socket activation. If name is empty, the first
+// passed socket is returned, otherwise the socket whose `FileDescriptorName=` equals name.
+//
+// Several listeners can be created from the sockets of a single unit, e.g. one for the public and one for
+// the admin API.
+func SystemdListener(name string) (net.Listener, error) {
+	files, err := loadSystemdFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if name != "" && f.Name() != name {
+			continue
+		}
+		// net.FileListener duplicates the file descriptor, so the listener can be closed independently.
+		l, err := net.FileListener(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to use systemd socket %s", f.Name())
+		}
+		return l, nil
+	}
+
+	if name != "" {
+		return nil, errors.Wrapf(ErrNoSystemdSocket, "looking for socket %s", name)
+	}
+	return nil, errors.WithStack(ErrNoSystemdSocket)
+}
+
+func loadSystemdFiles() ([]*os.File, error) {
+	systemdMu.Lock()
+	defer systemdMu.Unlock()
+
+	if systemdLoaded {
+		return systemdFiles, nil
+	}
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		// The sockets were not passed to this process.
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse LISTEN_FDS")
+	}
+
+	var names []string
+	if v := os.Getenv("LISTEN_FDNAMES"); v != "" {
+		names = strings.Split(v, ":")
+	}
+
+	files := make([]*os.File, n)
+	for i := range files {
+		fd := listenFDsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) {
+			name = names[i]
+		}
+		files[i] = os.NewFile(uintptr(fd), name)
+	}
+
+	systemdFiles, systemdLoaded = files, true
+	return files, nil
+}
@@ -0,0 +1,80 @@
+package networkx
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setSystemdFiles(t *testing.T, files []*os.File) {
+	systemdMu.Lock()
+	systemdFiles, systemdLoaded = files, true
+	systemdMu.Unlock()
+	t.Cleanup(func() {
+		systemdMu.Lock()
+		systemdFiles, systemdLoaded = nil, false
+		systemdMu.Unlock()
+	})
+}
+
+func TestSystemdListener(t *testing.T) {
+	t.Run("case=no sockets passed to this process", func(t *testing.T) {
+		setenv(t, "LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+		setenv(t, "LISTEN_FDS", "1")
+
+		_, err := MakeListener("systemd:", nil)
+		assert.True(t, errors.Is(err, ErrNoSystemdSocket), "%+v", err)
+	})
+
+	t.Run("case=selects socket by name", func(t *testing.T) {
+		var files []*os.File
+		for _, name := range []string{"public", "admin"} {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			f, err := l.(*net.TCPListener).File()
+			require.NoError(t, err)
+			require.NoError(t, l.Close())
+			files = append(files, os.NewFile(f.Fd(), name))
+		}
+		setSystemdFiles(t, files)
+
+		first, err := MakeListener("systemd:", nil)
+		require.NoError(t, err)
+		defer first.Close()
+
+		admin, err := MakeListener("systemd:admin", nil)
+		require.NoError(t, err)
+		defer admin.Close()
+
+		public, err := MakeListener("systemd:public", nil)
+		require.NoError(t, err)
+		defer public.Close()
+
+		assert.Equal(t, public.Addr().String(), first.Addr().String())
+		assert.NotEqual(t, public.Addr().String(), admin.Addr().String())
+
+		c, err := net.Dial("tcp", admin.Addr().String())
+		require.NoError(t, err)
+		_ = c.Close()
+
+		_, err = MakeListener("systemd:metrics", nil)
+		assert.True(t, errors.Is(err, ErrNoSystemdSocket), "%+v", err)
+	})
+}
+
+func setenv(t *testing.T, key, value string) {
+	prev, ok := os.LookupEnv(key)
+	require.NoError(t, os.Setenv(key, value))
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, prev)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
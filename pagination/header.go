@@ -9,11 +9,27 @@ import (
 )
 
 func header(u *url.URL, rel string, limit, offset int) string {
+	return Link(u, rel, map[string]string{
+		"limit":  strconv.Itoa(limit),
+		"offset": strconv.Itoa(offset),
+	})
+}
+
+// Link returns an RFC 5988 link with the relation type rel to the URL, with the query parameters set to the
+// given values. Query parameters with an empty value are removed. The URL is not modified.
+func Link(u *url.URL, rel string, params map[string]string) string {
 	q := u.Query()
-	q.Set("limit", strconv.Itoa(limit))
-	q.Set("offset", strconv.Itoa(offset))
-	u.RawQuery = q.Encode()
-	return fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel)
+	for k, v := range params {
+		if v == "" {
+			q.Del(k)
+			continue
+		}
+		q.Set(k, v)
+	}
+
+	nu := *u
+	nu.RawQuery = q.Encode()
+	return fmt.Sprintf("<%s>; rel=\"%s\"", nu.String(), rel)
 }
 
 // Header adds an http header for pagination using a responsewriter where backwards compatibility is required.
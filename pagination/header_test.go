@@ -102,3 +102,14 @@ func TestHeader(t *testing.T) {
 		assert.EqualValues(t, expect, r.Result().Header.Get("Link"))
 	})
 }
+
+func TestLink(t *testing.T) {
+	u, err := url.Parse("http://example.com/items?foo=bar&page_token=current")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `<http://example.com/items?foo=bar&page_token=next>; rel="next"`, Link(u, "next", map[string]string{"page_token": "next"}))
+	assert.Equal(t, `<http://example.com/items?foo=bar>; rel="first"`, Link(u, "first", map[string]string{"page_token": ""}))
+	assert.Equal(t, "foo=bar&page_token=current", u.RawQuery, "the URL is not modified")
+}
@@ -0,0 +1,57 @@
+package tokenpagination
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ory/x/pagination"
+	"github.com/ory/x/sqlcon/keysetpagination"
+)
+
+// ParseQuery verifies the page token in the `page_token` query parameter and returns the keyset pagination
+// options it encodes. The `page_size` query parameter takes precedence over the size stored in the token.
+// Without page token, the options for the first page are returned.
+func (c *Codec) ParseQuery(r *http.Request) ([]keysetpagination.Option, error) {
+	q := r.URL.Query()
+
+	var opts []keysetpagination.Option
+	if raw := q.Get("page_token"); raw != "" {
+		t, err := c.Decode(r.URL, raw)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, keysetpagination.WithToken(t.Cursor))
+		if t.Size > 0 {
+			opts = append(opts, keysetpagination.WithSize(t.Size))
+		}
+	}
+
+	if size, err := strconv.Atoi(q.Get("page_size")); err == nil {
+		opts = append(opts, keysetpagination.WithSize(size))
+	}
+	return opts, nil
+}
+
+// NextPageToken returns the signed token for the page following the item with the given sort key values,
+// see keysetpagination.Paginator.NextPageToken. The token keeps the page size of the paginator and is only
+// accepted for the path and query parameters of the list request URL u.
+func (c *Codec) NextPageToken(u *url.URL, p *keysetpagination.Paginator, values ...interface{}) (string, error) {
+	cursor, err := p.NextPageToken(values...)
+	if err != nil {
+		return "", err
+	}
+	return c.Encode(u, Token{Size: p.Size(), Cursor: cursor})
+}
+
+// Header sets the RFC 5988 Link header pointing to the first and, unless next is empty, the next page. The
+// page size is stored in the token and therefore removed from the link to the next page, but kept in the link
+// to the first page.
+func Header(w http.ResponseWriter, u *url.URL, next string) {
+	links := []string{pagination.Link(u, "first", map[string]string{"page_token": ""})}
+	if next != "" {
+		links = append(links, pagination.Link(u, "next", map[string]string{"page_token": next, "page_size": ""}))
+	}
+	w.Header().Set("Link", strings.Join(links, ","))
+}
@@ -0,0 +1,85 @@
+package tokenpagination
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon/keysetpagination"
+	"github.com/ory/x/urlx"
+)
+
+func TestKeysetPagination(t *testing.T) {
+	c, err := NewCodec([][]byte{secret})
+	require.NoError(t, err)
+	columns := []keysetpagination.Column{{Name: "created_at", Order: keysetpagination.OrderDescending}, {Name: "id"}}
+
+	opts, err := c.ParseQuery(httptest.NewRequest("GET", "/items?page_size=2", nil))
+	require.NoError(t, err)
+	first, err := keysetpagination.New(columns, opts...)
+	require.NoError(t, err)
+	assert.True(t, first.IsFirstPage())
+	assert.Equal(t, 2, first.Size())
+
+	createdAt := time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)
+	next, err := c.NextPageToken(urlx.ParseOrPanic("/items?page_size=2"), first, createdAt, "some-id")
+	require.NoError(t, err)
+
+	t.Run("case=next page keeps size", func(t *testing.T) {
+		opts, err := c.ParseQuery(httptest.NewRequest("GET", "/items?page_token="+url.QueryEscape(next), nil))
+		require.NoError(t, err)
+		p, err := keysetpagination.New(columns, opts...)
+		require.NoError(t, err)
+
+		assert.False(t, p.IsFirstPage())
+		assert.Equal(t, 2, p.Size())
+		where, args := p.Where()
+		assert.Equal(t, "((created_at < ?) OR (created_at = ? AND id > ?))", where)
+		assert.Equal(t, []interface{}{createdAt, createdAt, "some-id"}, args)
+	})
+
+	t.Run("case=page size takes precedence", func(t *testing.T) {
+		opts, err := c.ParseQuery(httptest.NewRequest("GET", "/items?page_size=5&page_token="+url.QueryEscape(next), nil))
+		require.NoError(t, err)
+		p, err := keysetpagination.New(columns, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, 5, p.Size())
+	})
+
+	t.Run("case=other filters", func(t *testing.T) {
+		_, err := c.ParseQuery(httptest.NewRequest("GET", "/items?state=inactive&page_token="+url.QueryEscape(next), nil))
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+
+	t.Run("case=unsigned keyset token", func(t *testing.T) {
+		cursor, err := first.NextPageToken(createdAt, "some-id")
+		require.NoError(t, err)
+
+		_, err = c.ParseQuery(httptest.NewRequest("GET", "/items?page_token="+url.QueryEscape(cursor), nil))
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+}
+
+func TestHeader(t *testing.T) {
+	u, err := url.Parse("https://example.com/items?page_size=2&page_token=current&foo=bar")
+	require.NoError(t, err)
+
+	t.Run("case=next page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Header(w, u, "next")
+		assert.Equal(t, `<https://example.com/items?foo=bar&page_size=2>; rel="first",<https://example.com/items?foo=bar&page_token=next>; rel="next"`, w.Header().Get("Link"))
+	})
+
+	t.Run("case=last page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Header(w, u, "")
+		assert.Equal(t, `<https://example.com/items?foo=bar&page_size=2>; rel="first"`, w.Header().Get("Link"))
+	})
+
+	assert.Equal(t, "page_size=2&page_token=current&foo=bar", u.RawQuery, "the URL is not modified")
+}
@@ -0,0 +1,179 @@
+// Package tokenpagination implements pagination using signed opaque page tokens.
+//
+// A page token carries the page size, the cursor of the next page, an expiry, and a hash of the
+// path and the query parameters of the request it was issued for. It is signed, so clients can not
+// forge cursors, and it is only accepted for the same path and query parameters, except page_token
+// and page_size, so clients can not use it to read past filters applied to the first page or
+// continue on another endpoint. It expires, so leaked tokens are useless after a while. Tokens are
+// not encrypted; cursors must not contain secrets.
+//
+// The cursors of sqlcon/keysetpagination are supported out of the box:
+//
+//	opts, err := codec.ParseQuery(r)
+//	if err != nil {
+//		return err
+//	}
+//	p, err := keysetpagination.New(columns, opts...)
+//	if err != nil {
+//		return errors.WithStack(tokenpagination.ErrInvalidToken)
+//	}
+//	if err := c.Scope(p.Scope()).All(&items); err != nil {
+//		return err
+//	}
+//	if p.Trim(&items) {
+//		last := items[len(items)-1]
+//		next, err := codec.NextPageToken(r.URL, p, last.CreatedAt, last.ID)
+//		if err != nil {
+//			return err
+//		}
+//		tokenpagination.Header(w, r.URL, next)
+//	}
+package tokenpagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/errorsx"
+)
+
+var (
+	// ErrInvalidToken is returned if a page token is malformed, its signature is invalid, or it was issued for
+	// another path or other query parameters.
+	ErrInvalidToken = errorsx.ErrBadRequest.WithID("invalid_page_token").WithReason("The page token is invalid.")
+	// ErrExpiredToken is returned if a page token is expired.
+	ErrExpiredToken = errorsx.ErrBadRequest.WithID("expired_page_token").WithReason("The page token is expired. Request the first page again.")
+)
+
+// DefaultTTL is how long page tokens are valid unless configured otherwise.
+const DefaultTTL = time.Hour
+
+type (
+	// Token is the content of a page token.
+	Token struct {
+		// Size is the page size.
+		Size int `json:"s,omitempty"`
+		// Cursor identifies the first item of the page, e.g. the token of keysetpagination.
+		Cursor string `json:"c"`
+		// ExpiresAt is when the token expires. It is set by Codec.Encode.
+		ExpiresAt time.Time `json:"e"`
+		// Scope is the hash of the path and the query parameters the token was issued for. It is set by
+		// Codec.Encode.
+		Scope string `json:"h"`
+	}
+
+	// Codec encodes and signs page tokens, and decodes and verifies them.
+	Codec struct {
+		secrets [][]byte
+		ttl     time.Duration
+		now     func() time.Time
+	}
+
+	// CodecOption configures a Codec.
+	CodecOption func(*Codec)
+)
+
+// WithTTL sets how long page tokens are valid. Defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) CodecOption {
+	return func(c *Codec) {
+		c.ttl = ttl
+	}
+}
+
+// NewCodec returns a codec signing tokens with the first secret and accepting tokens signed with any of the
+// secrets, so that secrets can be rotated without invalidating the tokens of clients currently paginating.
+// Secrets must be at least 32 bytes long. Use different secrets for different APIs, so that the tokens of
+// one API are not accepted by another.
+func NewCodec(secrets [][]byte, opts ...CodecOption) (*Codec, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("at least one secret is required to sign page tokens")
+	}
+	for k, s := range secrets {
+		if len(s) < 32 {
+			return nil, errors.Errorf("page token secret %d must be at least 32 bytes long but has %d bytes", k, len(s))
+		}
+	}
+
+	c := &Codec{secrets: secrets, ttl: DefaultTTL, now: time.Now}
+	for _, o := range opts {
+		o(c)
+	}
+	return c, nil
+}
+
+// Encode returns the signed page token for the list request URL u, which expires after the configured TTL.
+func (c *Codec) Encode(u *url.URL, t Token) (string, error) {
+	t.ExpiresAt = c.now().Add(c.ttl).UTC().Truncate(time.Second)
+	t.Scope = scope(u)
+
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(c.secrets[0], encoded)), nil
+}
+
+// Decode verifies the signature, expiry, and scope of the page token sent with the list request URL u and
+// returns its content. It returns ErrInvalidToken or ErrExpiredToken if the token is not acceptable.
+func (c *Codec) Decode(u *url.URL, token string) (*Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+
+	var valid bool
+	for _, s := range c.secrets {
+		// Check all secrets to not leak which one matched through the response time.
+		valid = hmac.Equal(signature, sign(s, parts[0])) || valid
+	}
+	if !valid {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+
+	var t Token
+	if err := json.Unmarshal(payload, &t); err != nil {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+	if t.Scope != scope(u) {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+	if !c.now().Before(t.ExpiresAt) {
+		return nil, errors.WithStack(ErrExpiredToken)
+	}
+	return &t, nil
+}
+
+// scope hashes the path and the query parameters of the URL, except the ones changing from page to page.
+func scope(u *url.URL) string {
+	q := u.Query()
+	q.Del("page_token")
+	q.Del("page_size")
+
+	h := sha256.Sum256([]byte(u.EscapedPath() + "?" + q.Encode()))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+func sign(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
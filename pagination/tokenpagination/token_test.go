@@ -0,0 +1,121 @@
+package tokenpagination
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/errorsx"
+	"github.com/ory/x/urlx"
+)
+
+var (
+	secret      = []byte("a-very-secret-secret-of-32-bytes")
+	otherSecret = []byte("another-secret-with-at-least-32-bytes")
+)
+
+func TestCodec(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	u := urlx.ParseOrPanic("/items?state=active&page_size=10")
+	newCodec := func(t *testing.T, secrets [][]byte, opts ...CodecOption) *Codec {
+		c, err := NewCodec(secrets, opts...)
+		require.NoError(t, err)
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	t.Run("case=round trip", func(t *testing.T) {
+		c := newCodec(t, [][]byte{secret})
+		token, err := c.Encode(u, Token{Size: 10, Cursor: "cursor"})
+		require.NoError(t, err)
+
+		actual, err := c.Decode(u, token)
+		require.NoError(t, err)
+		assert.Equal(t, &Token{Size: 10, Cursor: "cursor", ExpiresAt: now.Add(DefaultTTL), Scope: scope(u)}, actual)
+	})
+
+	t.Run("case=expired", func(t *testing.T) {
+		c := newCodec(t, [][]byte{secret}, WithTTL(time.Minute))
+		token, err := c.Encode(u, Token{Cursor: "cursor"})
+		require.NoError(t, err)
+
+		now = now.Add(time.Minute)
+		defer func() { now = now.Add(-time.Minute) }()
+
+		_, err = c.Decode(u, token)
+		assert.True(t, errors.Is(err, ErrExpiredToken))
+		assert.Equal(t, 400, errorsx.ToProblem(err).Code)
+	})
+
+	t.Run("case=rotated secrets", func(t *testing.T) {
+		token, err := newCodec(t, [][]byte{otherSecret}).Encode(u, Token{Cursor: "cursor"})
+		require.NoError(t, err)
+
+		_, err = newCodec(t, [][]byte{secret, otherSecret}).Decode(u, token)
+		assert.NoError(t, err)
+
+		_, err = newCodec(t, [][]byte{secret}).Decode(u, token)
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+
+	t.Run("case=invalid tokens", func(t *testing.T) {
+		c := newCodec(t, [][]byte{secret})
+		token, err := c.Encode(u, Token{Cursor: "cursor"})
+		require.NoError(t, err)
+		parts := strings.Split(token, ".")
+
+		forged, err := newCodec(t, [][]byte{otherSecret}).Encode(u, Token{Cursor: "forged"})
+		require.NoError(t, err)
+
+		for k, token := range []string{
+			"",
+			"not-a-token",
+			parts[0],
+			parts[0] + ".",
+			parts[0] + "." + parts[1] + ".",
+			strings.Split(forged, ".")[0] + "." + parts[1],
+			parts[0] + "." + strings.Split(forged, ".")[1],
+			parts[0] + ".!!!",
+		} {
+			_, err := c.Decode(u, token)
+			assert.True(t, errors.Is(err, ErrInvalidToken), "%d: %+v", k, err)
+		}
+	})
+
+	t.Run("case=scope", func(t *testing.T) {
+		c := newCodec(t, [][]byte{secret})
+		token, err := c.Encode(u, Token{Cursor: "cursor"})
+		require.NoError(t, err)
+
+		for _, other := range []string{
+			"/items?state=active&page_size=10&page_token=current",
+			"/items?state=active",
+		} {
+			_, err := c.Decode(urlx.ParseOrPanic(other), token)
+			assert.NoError(t, err, other)
+		}
+
+		for _, other := range []string{
+			"/items",
+			"/items?state=inactive",
+			"/items?state=active&state=inactive",
+			"/items?state=active&owner=someone",
+			"/other-items?state=active",
+		} {
+			_, err := c.Decode(urlx.ParseOrPanic(other), token)
+			assert.True(t, errors.Is(err, ErrInvalidToken), "%s: %+v", other, err)
+		}
+	})
+
+	t.Run("case=requires secrets", func(t *testing.T) {
+		_, err := NewCodec(nil)
+		assert.Error(t, err)
+
+		_, err = NewCodec([][]byte{secret, []byte("short")})
+		assert.Error(t, err)
+	})
+}
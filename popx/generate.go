@@ -0,0 +1,105 @@
+package popx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/x/cmdx"
+)
+
+// MigrationDialects are the dialects Generate creates migration files for.
+var MigrationDialects = []string{"cockroach", "mysql", "postgres", "sqlite3"}
+
+var (
+	migrationNamePattern     = regexp.MustCompile("^[a-z0-9_]+$")
+	migrationNameReplacer    = strings.NewReplacer(" ", "_", "-", "_", ".", "_")
+	migrationTemplate        = template.Must(template.New("migration").Parse(migrationTemplateContent))
+	migrationTemplateContent = `-- Migration: {{ .Name }} ({{ .Direction }})
+-- Version: {{ .Version }}
+-- Dialect: {{ .Dialect }}
+-- Generated at: {{ .Time }}
+`
+)
+
+// Generate creates empty, timestamped up and down migration files called `name`
+// for all MigrationDialects in `dir` and returns their paths.
+//
+//	files, err := popx.Generate("persistence/sql/migrations", "create_identities")
+func Generate(dir, name string) ([]string, error) {
+	return generate(dir, name, time.Now().UTC())
+}
+
+func generate(dir, name string, now time.Time) ([]string, error) {
+	name = migrationNameReplacer.Replace(strings.ToLower(strings.TrimSpace(name)))
+	if !migrationNamePattern.MatchString(name) {
+		return nil, errors.Errorf("migration name %q must only contain letters, digits, and underscores", name)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	version := now.Format("20060102150405") + "000000"
+	var files []string
+	for _, dialect := range MigrationDialects {
+		for _, direction := range []string{"up", "down"} {
+			p := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.%s.sql", version, name, dialect, direction))
+
+			// #nosec G302 G304 - migration files are meant to be committed and read by everyone
+			f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if err != nil {
+				return files, errors.WithStack(err)
+			}
+
+			err = migrationTemplate.Execute(f, map[string]string{
+				"Name":      name,
+				"Direction": direction,
+				"Version":   version,
+				"Dialect":   dialect,
+				"Time":      now.Format(time.RFC3339),
+			})
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return files, errors.Wrapf(err, "unable to write migration file %s", p)
+			}
+
+			files = append(files, p)
+		}
+	}
+
+	return files, nil
+}
+
+// NewGenerateCmd returns a *cobra.Command that generates migration files using Generate.
+func NewGenerateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "generate <directory> <name>",
+		Short: "Generate up and down migration files for all supported dialects",
+		Long: `Generates empty, timestamped up and down SQL migration files for
+CockroachDB, MySQL, PostgreSQL, and SQLite in the given directory.`,
+		Example: "generate persistence/sql/migrations create_identities",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := Generate(args[0], args[1])
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Unable to generate migration files: %s\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			for _, f := range files {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), f)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package popx
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	now := time.Date(2021, 11, 3, 13, 37, 0, 0, time.UTC)
+
+	t.Run("case=generates up and down migrations for every dialect", func(t *testing.T) {
+		dir := t.TempDir()
+		files, err := generate(dir, "Create identities", now)
+		require.NoError(t, err)
+		require.Len(t, files, len(MigrationDialects)*2)
+
+		for _, f := range files {
+			name := filepath.Base(f)
+			match := mrx.FindStringSubmatch(name)
+			require.NotNil(t, match, "%s", name)
+			assert.Equal(t, "20211103133700000000", match[1])
+			assert.Equal(t, "create_identities", match[2])
+
+			content, err := ioutil.ReadFile(f)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "-- Version: 20211103133700000000")
+		}
+		assert.Equal(t, filepath.Join(dir, "20211103133700000000_create_identities.cockroach.up.sql"), files[0])
+	})
+
+	t.Run("case=does not overwrite existing migrations", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := generate(dir, "identities", now)
+		require.NoError(t, err)
+		_, err = generate(dir, "identities", now)
+		require.Error(t, err)
+	})
+
+	t.Run("case=rejects invalid names", func(t *testing.T) {
+		_, err := generate(t.TempDir(), "identities;drop", now)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,55 @@
+package popx
+
+import (
+	"fmt"
+
+	"github.com/gobuffalo/pop/v6"
+)
+
+// GoMigrationFunc is the signature of a migration implemented in Go. It receives the
+// migration's transaction and the name of the dialect (e.g. "postgres") it runs against.
+type GoMigrationFunc func(tx *pop.Tx, dialect string) error
+
+// GoMigration is a migration implemented in Go, for transformations that can not be
+// expressed in portable SQL.
+type GoMigration struct {
+	// Version of the migration (20210101000001000000)
+	Version string
+	// Name of the migration (migrate_identities)
+	Name string
+	// DBType restricts the migration to a dialect (all|postgres|mysql...). Defaults to "all".
+	DBType string
+	// Up applies the migration.
+	Up GoMigrationFunc
+	// Down rolls back the migration. If nil, rolling back is a no-op.
+	Down GoMigrationFunc
+}
+
+func (gm GoMigration) migrations() Migrations {
+	dbType := gm.DBType
+	if dbType == "" {
+		dbType = "all"
+	}
+
+	mfs := make(Migrations, 0, 2)
+	for direction, fn := range map[string]GoMigrationFunc{"up": gm.Up, "down": gm.Down} {
+		fn := fn
+		mfs = append(mfs, Migration{
+			Path:      fmt.Sprintf("go:%s_%s.%s.%s", gm.Version, gm.Name, dbType, direction),
+			Version:   gm.Version,
+			Name:      gm.Name,
+			DBType:    dbType,
+			Direction: direction,
+			Type:      "go",
+			Runner: func(mf Migration, c *pop.Connection, tx *pop.Tx) error {
+				if fn == nil && mf.Direction == "up" {
+					return fmt.Errorf("no up function defined for %s", mf.Path)
+				} else if fn == nil {
+					return nil
+				}
+				return fn(tx, c.Dialect.Name())
+			},
+		})
+	}
+	return mfs
+}
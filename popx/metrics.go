@@ -0,0 +1,64 @@
+package popx
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MigrationMetrics is a Prometheus collector which records the execution time of
+// migrations and migration runs. Register it with a prometheus.Registerer and pass
+// it to the migrator using WithMetrics.
+type MigrationMetrics struct {
+	migrationDuration *prometheus.HistogramVec
+	runDuration       *prometheus.HistogramVec
+}
+
+var _ prometheus.Collector = (*MigrationMetrics)(nil)
+
+// NewMigrationMetrics creates new migration metrics. The prefix is prepended to all metric names.
+func NewMigrationMetrics(prefix string) *MigrationMetrics {
+	if prefix != "" {
+		prefix += "_"
+	}
+
+	buckets := prometheus.ExponentialBuckets(0.01, 4, 10)
+	return &MigrationMetrics{
+		migrationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    prefix + "migration_duration_seconds",
+			Help:    "duration of a single migration in seconds",
+			Buckets: buckets,
+		}, []string{"direction", "version", "name"}),
+		runDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    prefix + "migration_run_duration_seconds",
+			Help:    "duration of a migration run in seconds",
+			Buckets: buckets,
+		}, []string{"direction"}),
+	}
+}
+
+// Describe implements prometheus Collector interface.
+func (m *MigrationMetrics) Describe(in chan<- *prometheus.Desc) {
+	m.migrationDuration.Describe(in)
+	m.runDuration.Describe(in)
+}
+
+// Collect implements prometheus Collector interface.
+func (m *MigrationMetrics) Collect(in chan<- prometheus.Metric) {
+	m.migrationDuration.Collect(in)
+	m.runDuration.Collect(in)
+}
+
+func (m *MigrationMetrics) observeMigration(direction string, mi Migration, took time.Duration) {
+	if m == nil {
+		return
+	}
+	m.migrationDuration.WithLabelValues(direction, mi.Version, mi.Name).Observe(took.Seconds())
+}
+
+func (m *MigrationMetrics) observeRun(direction string, took time.Duration) {
+	if m == nil {
+		return
+	}
+	m.runDuration.WithLabelValues(direction).Observe(took.Seconds())
+}
@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/gobuffalo/pop/v6"
 	"github.com/pkg/errors"
@@ -20,6 +21,8 @@ type (
 		Dir              fs.FS
 		l                *logrusx.Logger
 		migrationContent MigrationContent
+		additionalDirs   []fs.FS
+		goMigrations     []GoMigration
 	}
 	MigrationContent func(mf Migration, c *pop.Connection, r []byte, usingTemplate bool) (string, error)
 )
@@ -41,6 +44,67 @@ func WithMigrationContentMiddleware(middleware func(content string, err error) (
 	}
 }
 
+// WithMigrationsFrom adds the migrations found in the given file systems to the box. Migrations
+// from all sources are merged and ordered by version, which allows libraries to ship their own
+// schema while the host application runs one unified migration pass:
+//
+//	migrations, err := NewMigrationBox(appMigrations, m, WithMigrationsFrom(networkx.Migrations))
+func WithMigrationsFrom(dirs ...fs.FS) func(*MigrationBox) *MigrationBox {
+	return func(m *MigrationBox) *MigrationBox {
+		m.additionalDirs = append(m.additionalDirs, dirs...)
+		return m
+	}
+}
+
+// WithGoMigrations adds migrations implemented in Go to the box. They are interleaved by version
+// with the SQL migrations and share their status and rollback semantics.
+func WithGoMigrations(migrations ...GoMigration) func(*MigrationBox) *MigrationBox {
+	return func(m *MigrationBox) *MigrationBox {
+		m.goMigrations = append(m.goMigrations, migrations...)
+		return m
+	}
+}
+
+// WithStatementTimeout sets the statement timeout for all migrations which do not define their own.
+func WithStatementTimeout(d time.Duration) func(*MigrationBox) *MigrationBox {
+	return func(m *MigrationBox) *MigrationBox {
+		m.StatementTimeout = d
+		return m
+	}
+}
+
+// WithLockTimeout sets the lock timeout for all migrations which do not define their own.
+func WithLockTimeout(d time.Duration) func(*MigrationBox) *MigrationBox {
+	return func(m *MigrationBox) *MigrationBox {
+		m.LockTimeout = d
+		return m
+	}
+}
+
+// WithOutOfOrderPolicy sets how pending migrations older than the newest applied migration are handled.
+func WithOutOfOrderPolicy(p OutOfOrderPolicy) func(*MigrationBox) *MigrationBox {
+	return func(m *MigrationBox) *MigrationBox {
+		m.OutOfOrderPolicy = p
+		return m
+	}
+}
+
+// WithProgressReporter sets a callback which receives progress events while migrations are applied or rolled back.
+func WithProgressReporter(f func(MigrationProgress)) func(*MigrationBox) *MigrationBox {
+	return func(m *MigrationBox) *MigrationBox {
+		m.OnProgress = f
+		return m
+	}
+}
+
+// WithMetrics records the duration of migrations and migration runs in the given metrics.
+func WithMetrics(metrics *MigrationMetrics) func(*MigrationBox) *MigrationBox {
+	return func(m *MigrationBox) *MigrationBox {
+		m.Metrics = metrics
+		return m
+	}
+}
+
 // NewMigrationBox from a packr.Dir and a Connection.
 //
 //	migrations, err := NewMigrationBox(pkger.Dir("/migrations"))
@@ -67,23 +131,40 @@ func NewMigrationBox(dir fs.FS, m *Migrator, opts ...func(*MigrationBox) *Migrat
 				m.l.WithField("migration", mf.Path).Trace("This is usually ok - ignoring migration because content is empty. This is ok!")
 				return nil
 			}
-			if _, err = tx.Exec(content); err != nil {
+			if tx == nil {
+				_, err = c.Store.Exec(content)
+			} else {
+				_, err = tx.Exec(content)
+			}
+			if err != nil {
 				return errors.Wrapf(err, "error executing %s, sql: %s", mf.Path, content)
 			}
 			return nil
 		}
 	}
 
-	err := mb.findMigrations(runner)
-	if err != nil {
-		return mb, err
+	for _, dir := range append([]fs.FS{mb.Dir}, mb.additionalDirs...) {
+		mfs, err := mb.findMigrations(dir, runner)
+		if err != nil {
+			return mb, err
+		}
+		if err := mb.addMigrations(mfs); err != nil {
+			return mb, err
+		}
+	}
+
+	for _, gm := range mb.goMigrations {
+		if err := mb.addMigrations(gm.migrations()); err != nil {
+			return mb, err
+		}
 	}
 
 	return mb, nil
 }
 
-func (fm *MigrationBox) findMigrations(runner func([]byte) func(mf Migration, c *pop.Connection, tx *pop.Tx) error) error {
-	return fs.WalkDir(fm.Dir, ".", func(p string, info fs.DirEntry, err error) error {
+func (fm *MigrationBox) findMigrations(dir fs.FS, runner func([]byte) func(mf Migration, c *pop.Connection, tx *pop.Tx) error) (Migrations, error) {
+	var mfs Migrations
+	err := fs.WalkDir(dir, ".", func(p string, info fs.DirEntry, err error) error {
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -106,7 +187,7 @@ func (fm *MigrationBox) findMigrations(runner func([]byte) func(mf Migration, c
 			return nil
 		}
 
-		f, err := fm.Dir.Open(p)
+		f, err := dir.Open(p)
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -124,12 +205,36 @@ func (fm *MigrationBox) findMigrations(runner func([]byte) func(mf Migration, c
 			Type:      match.Type,
 			Runner:    runner(content),
 		}
+		if err := parseMigrationAnnotations(content, &mf); err != nil {
+			return err
+		}
+		mfs = append(mfs, mf)
+		return nil
+	})
+	return mfs, err
+}
+
+// addMigrations adds the migrations of one source, e.g. a directory. Migrations of different sources must
+// not share a version, as merging them would silently run only one of them. Within a source, this is not
+// checked to remain compatible with directories defining the same version more than once.
+func (fm *MigrationBox) addMigrations(mfs Migrations) error {
+	for _, mf := range mfs {
+		for _, existing := range fm.Migrations[mf.Direction] {
+			if existing.Version == mf.Version && existing.DBType == mf.DBType {
+				return errors.Errorf("migration version %s for database type %s is defined more than once: %s and %s", mf.Version, mf.DBType, existing.Path, mf.Path)
+			}
+		}
+	}
+
+	for _, mf := range mfs {
 		fm.Migrations[mf.Direction] = append(fm.Migrations[mf.Direction], mf)
-		mod := sortIdent(fm.Migrations[mf.Direction])
-		if mf.Direction == "down" {
+	}
+	for direction := range fm.Migrations {
+		mod := sortIdent(fm.Migrations[direction])
+		if direction == "down" {
 			mod = sort.Reverse(mod)
 		}
 		sort.Sort(mod)
-		return nil
-	})
+	}
+	return nil
 }
@@ -0,0 +1,56 @@
+package popx
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+func TestMigrationBoxWithMigrationsFrom(t *testing.T) {
+	empty := &fstest.MapFile{Data: []byte("")}
+	library := fstest.MapFS{
+		"migrations/20210101000001_library.up.sql":   empty,
+		"migrations/20210101000003_library.up.sql":   empty,
+		"migrations/20210101000001_library.down.sql": empty,
+		"migrations/20210101000003_library.down.sql": empty,
+	}
+	app := fstest.MapFS{
+		"20210101000002_app.up.sql":   empty,
+		"20210101000004_app.up.sql":   empty,
+		"20210101000002_app.down.sql": empty,
+		"20210101000004_app.down.sql": empty,
+	}
+
+	t.Run("case=merges and orders migrations", func(t *testing.T) {
+		mb, err := NewMigrationBox(app, NewMigrator(nil, logrusx.New("", ""), nil, 0), WithMigrationsFrom(library))
+		require.NoError(t, err)
+
+		versions := func(mfs Migrations) (v []string) {
+			for _, m := range mfs {
+				v = append(v, m.Version)
+			}
+			return
+		}
+
+		assert.Equal(t, []string{"20210101000001", "20210101000002", "20210101000003", "20210101000004"}, versions(mb.Migrations["up"]))
+		assert.Equal(t, []string{"20210101000004", "20210101000003", "20210101000002", "20210101000001"}, versions(mb.Migrations["down"]))
+	})
+
+	t.Run("case=fails on duplicate versions", func(t *testing.T) {
+		_, err := NewMigrationBox(library, NewMigrator(nil, logrusx.New("", ""), nil, 0), WithMigrationsFrom(library))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "defined more than once")
+	})
+
+	t.Run("case=allows duplicate versions within one directory", func(t *testing.T) {
+		_, err := NewMigrationBox(fstest.MapFS{
+			"20210101000001_a.up.sql": empty,
+			"20210101000001_b.up.sql": empty,
+		}, NewMigrator(nil, logrusx.New("", ""), nil, 0))
+		require.NoError(t, err)
+	})
+}
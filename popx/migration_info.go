@@ -3,6 +3,7 @@ package popx
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/gobuffalo/pop/v6"
 )
@@ -21,6 +22,12 @@ type Migration struct {
 	Type string
 	// DB type (all|postgres|mysql...)
 	DBType string
+	// StatementTimeout limits the execution time of the migration's statements on PostgreSQL and CockroachDB (-- popx:statement_timeout=30s)
+	StatementTimeout time.Duration
+	// LockTimeout limits how long the migration waits for locks (-- popx:lock_timeout=5s)
+	LockTimeout time.Duration
+	// NoTransaction runs the migration outside of a transaction (-- popx:no_transaction)
+	NoTransaction bool
 	// Runner function to run/execute the migration
 	Runner func(Migration, *pop.Connection, *pop.Tx) error
 }
@@ -0,0 +1,162 @@
+package popx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/pkg/errors"
+)
+
+var annotationPattern = regexp.MustCompile(`^--\s*popx:([a-z_]+)(?:\s*=\s*(\S+))?\s*$`)
+
+// parseMigrationAnnotations reads annotations from the leading comment block of a migration:
+//
+//	-- popx:statement_timeout=30s
+//	-- popx:lock_timeout=5s
+//	-- popx:no_transaction
+//	CREATE INDEX CONCURRENTLY ...
+func parseMigrationAnnotations(content []byte, mf *Migration) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		} else if !strings.HasPrefix(line, "--") {
+			break
+		}
+
+		match := annotationPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		switch key, value := match[1], match[2]; key {
+		case "no_transaction":
+			mf.NoTransaction = true
+		case "statement_timeout", "lock_timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return errors.Wrapf(err, "unable to parse annotation %s of migration %s", key, mf.Path)
+			}
+			if key == "statement_timeout" {
+				mf.StatementTimeout = d
+			} else {
+				mf.LockTimeout = d
+			}
+		default:
+			return errors.Errorf("unknown annotation %s in migration %s", key, mf.Path)
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+func (m *Migrator) timeouts(mi Migration) (statementTimeout, lockTimeout time.Duration) {
+	statementTimeout, lockTimeout = mi.StatementTimeout, mi.LockTimeout
+	if statementTimeout == 0 {
+		statementTimeout = m.StatementTimeout
+	}
+	if lockTimeout == 0 {
+		lockTimeout = m.LockTimeout
+	}
+	return
+}
+
+// runMigration runs the migration and then bookkeeping, which records the migration in the migration table.
+//
+// Unless the migration is flagged with NoTransaction, both run in the same transaction with the
+// statement and lock timeouts applied. The statement timeout is only enforced by PostgreSQL and
+// CockroachDB within transactions; on other dialects and for migrations without a transaction, the
+// execution time is limited by the Migrator's PerMigrationTimeout only.
+func (m *Migrator) runMigration(ctx context.Context, c *pop.Connection, mi Migration, direction string, bookkeeping func(tx *pop.Tx) error) error {
+	statementTimeout, lockTimeout := m.timeouts(mi)
+	dialect := c.Dialect.Name()
+
+	if statementTimeout > 0 && (mi.NoTransaction || (dialect != "postgres" && dialect != "cockroach")) {
+		m.l.WithField("version", mi.Version).Debug("Statement timeouts are only supported within transactions on PostgreSQL and CockroachDB and will be ignored.")
+	}
+
+	if mi.NoTransaction {
+		m.l.WithField("version", mi.Version).Debug("Running migration without a transaction.")
+		if m.PerMigrationTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, m.PerMigrationTimeout)
+			defer cancel()
+		}
+		if err := mi.Run(c.WithContext(ctx), nil); err != nil {
+			return err
+		}
+		return m.isolatedTransaction(ctx, direction, bookkeeping)
+	}
+
+	return m.isolatedTransaction(ctx, direction, func(tx *pop.Tx) (err error) {
+		reset, err := m.setTimeouts(tx, dialect, statementTimeout, lockTimeout)
+		defer func() {
+			// Session settings outlive the transaction, so they are reset even if the migration failed.
+			// Unlike on PostgreSQL, failed statements do not abort MySQL transactions.
+			for _, statement := range reset {
+				if _, rerr := tx.Exec(statement); rerr != nil && err == nil {
+					err = errors.Wrapf(rerr, "unable to reset timeout: %s", statement)
+				}
+			}
+		}()
+		if err != nil {
+			return err
+		}
+
+		if err := mi.Run(c, tx); err != nil {
+			return err
+		}
+		return bookkeeping(tx)
+	})
+}
+
+// setTimeouts applies the timeouts to the transaction and returns statements which reset
+// session-level settings once the migration ran. Where possible, settings are scoped to the transaction.
+func (m *Migrator) setTimeouts(tx *pop.Tx, dialect string, statementTimeout, lockTimeout time.Duration) (reset []string, err error) {
+	var statements []string
+	switch dialect {
+	case "postgres":
+		if statementTimeout > 0 {
+			statements = append(statements, fmt.Sprintf("SET LOCAL statement_timeout = %d", statementTimeout.Milliseconds()))
+		}
+		if lockTimeout > 0 {
+			statements = append(statements, fmt.Sprintf("SET LOCAL lock_timeout = %d", lockTimeout.Milliseconds()))
+		}
+	case "cockroach":
+		if statementTimeout > 0 {
+			statements = append(statements, fmt.Sprintf("SET LOCAL statement_timeout = %d", statementTimeout.Milliseconds()))
+		}
+		if lockTimeout > 0 {
+			m.l.Debug("Lock timeouts are not supported by CockroachDB and will be ignored.")
+		}
+	case "mysql":
+		// MySQL has no transaction-scoped lock timeouts.
+		if lockTimeout > 0 {
+			seconds := int64(lockTimeout.Seconds())
+			if seconds < 1 {
+				seconds = 1
+			}
+			statements = append(statements,
+				fmt.Sprintf("SET SESSION lock_wait_timeout = %d", seconds),
+				fmt.Sprintf("SET SESSION innodb_lock_wait_timeout = %d", seconds),
+			)
+			reset = append(reset,
+				"SET SESSION lock_wait_timeout = DEFAULT",
+				"SET SESSION innodb_lock_wait_timeout = DEFAULT",
+			)
+		}
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return reset, errors.Wrapf(err, "unable to set timeout: %s", statement)
+		}
+	}
+	return reset, nil
+}
@@ -0,0 +1,71 @@
+package popx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMigrationAnnotations(t *testing.T) {
+	for k, tc := range []struct {
+		d        string
+		content  string
+		expected Migration
+		err      bool
+	}{
+		{
+			d:       "no annotations",
+			content: "CREATE TABLE foo (id INT);",
+		},
+		{
+			d: "all annotations",
+			content: `-- This migration creates an index.
+-- popx:statement_timeout=30s
+-- popx:lock_timeout = 500ms
+--popx:no_transaction
+
+CREATE INDEX CONCURRENTLY foo_idx ON foo (id);`,
+			expected: Migration{StatementTimeout: 30 * time.Second, LockTimeout: 500 * time.Millisecond, NoTransaction: true},
+		},
+		{
+			d: "ignores annotations after the header",
+			content: `CREATE TABLE foo (id INT);
+-- popx:no_transaction`,
+		},
+		{
+			d:       "invalid duration",
+			content: "-- popx:statement_timeout=soon",
+			err:     true,
+		},
+		{
+			d:       "unknown annotation",
+			content: "-- popx:statement_timeouts=1s",
+			err:     true,
+		},
+	} {
+		t.Run("case="+tc.d, func(t *testing.T) {
+			var actual Migration
+			err := parseMigrationAnnotations([]byte(tc.content), &actual)
+			if tc.err {
+				require.Error(t, err, "%d", k)
+				return
+			}
+			require.NoError(t, err, "%d", k)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestMigratorTimeouts(t *testing.T) {
+	m := &Migrator{StatementTimeout: time.Minute, LockTimeout: time.Second}
+
+	statementTimeout, lockTimeout := m.timeouts(Migration{})
+	assert.Equal(t, time.Minute, statementTimeout)
+	assert.Equal(t, time.Second, lockTimeout)
+
+	statementTimeout, lockTimeout = m.timeouts(Migration{StatementTimeout: time.Hour})
+	assert.Equal(t, time.Hour, statementTimeout)
+	assert.Equal(t, time.Second, lockTimeout)
+}
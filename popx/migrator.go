@@ -65,6 +65,19 @@ type Migrator struct {
 
 	// DumpMigrations if true will dump the migrations to a file called schema.sql
 	DumpMigrations bool
+
+	// StatementTimeout and LockTimeout are the defaults for migrations which do not define their own timeouts.
+	StatementTimeout time.Duration
+	LockTimeout      time.Duration
+
+	// Metrics, if set, records the duration of migrations and migration runs.
+	Metrics *MigrationMetrics
+
+	// OnProgress, if set, is called before and after each migration is applied or rolled back.
+	OnProgress func(MigrationProgress)
+
+	// OutOfOrderPolicy defines how pending migrations older than the newest applied migration are handled.
+	OutOfOrderPolicy OutOfOrderPolicy
 }
 
 // MigrationIsCompatible returns true if the migration is compatible with the current database.
@@ -92,54 +105,47 @@ func (m *Migrator) UpTo(ctx context.Context, step int) (applied int, err error)
 	err = m.exec(ctx, func() error {
 		mtn := m.migrationTableName(ctx, c)
 		mfs := m.Migrations["up"].SortAndFilter(c.Dialect.Name())
-		for _, mi := range mfs {
-			exists, err := c.Where("version = ?", mi.Version).Exists(mtn)
-			if err != nil {
-				return errors.Wrapf(err, "problem checking for migration version %s", mi.Version)
-			}
+		pending, outOfOrder, legacy, err := m.pendingMigrations(c, mtn, mfs)
+		if err != nil {
+			return err
+		}
 
-			if exists {
-				m.l.WithField("version", mi.Version).Debug("Migration has already been applied, skipping.")
-				continue
-			}
+		skip, err := m.handleOutOfOrder(outOfOrder)
+		if err != nil {
+			return err
+		}
 
-			if len(mi.Version) > 14 {
-				m.l.WithField("version", mi.Version).Debug("Migration has not been applied but it might be a legacy migration, investigating.")
+		for _, mi := range legacy {
+			legacyVersion := mi.Version[:14]
+			m.l.WithField("version", mi.Version).WithField("legacy_version", legacyVersion).WithField("migration_table", mtn).Debug("Migration has already been applied in a legacy migration run. Updating version in migration table.")
+			if err := m.isolatedTransaction(ctx, "init-migrate", func(tx *pop.Tx) error {
+				// We do not want to remove the legacy migration version or subsequent migrations might be applied twice.
+				//
+				// Do not activate the following - it is just for reference.
+				//
+				// if _, err := tx.Store.Exec(fmt.Sprintf("DELETE FROM %s WHERE version = ?", mtn), legacyVersion); err != nil {
+				//	return errors.Wrapf(err, "problem removing legacy version %s", mi.Version)
+				// }
 
-				legacyVersion := mi.Version[:14]
-				exists, err = c.Where("version = ?", legacyVersion).Exists(mtn)
-				if err != nil {
-					return errors.Wrapf(err, "problem checking for migration version %s", mi.Version)
-				}
+				// #nosec G201 - mtn is a system-wide const
+				_, err := tx.Exec(tx.Rebind(fmt.Sprintf("INSERT INTO %s (version) VALUES (?)", mtn)), mi.Version)
+				return errors.Wrapf(err, "problem inserting migration version %s", mi.Version)
+			}); err != nil {
+				return err
+			}
+		}
 
-				if exists {
-					m.l.WithField("version", mi.Version).WithField("legacy_version", legacyVersion).WithField("migration_table", mtn).Debug("Migration has already been applied in a legacy migration run. Updating version in migration table.")
-					if err := m.isolatedTransaction(ctx, "init-migrate", func(tx *pop.Tx) error {
-						// We do not want to remove the legacy migration version or subsequent migrations might be applied twice.
-						//
-						// Do not activate the following - it is just for reference.
-						//
-						// if _, err := tx.Store.Exec(fmt.Sprintf("DELETE FROM %s WHERE version = ?", mtn), legacyVersion); err != nil {
-						//	return errors.Wrapf(err, "problem removing legacy version %s", mi.Version)
-						// }
-
-						// #nosec G201 - mtn is a system-wide const
-						_, err := tx.Exec(tx.Rebind(fmt.Sprintf("INSERT INTO %s (version) VALUES (?)", mtn)), mi.Version)
-						return errors.Wrapf(err, "problem inserting migration version %s", mi.Version)
-					}); err != nil {
-						return err
-					}
-					continue
-				}
+		progress := m.newProgressReporter("up", len(pending)-len(skip), step)
+		for _, mi := range pending {
+			if skip[mi.Version] {
+				m.l.WithField("version", mi.Version).Debug("Migration is out of order, skipping.")
+				continue
 			}
 
 			m.l.WithField("version", mi.Version).Debug("Migration has not yet been applied, running migration.")
 
-			if err = m.isolatedTransaction(ctx, "up", func(tx *pop.Tx) error {
-				if err := mi.Run(c, tx); err != nil {
-					return err
-				}
-
+			progress.start(mi)
+			if err = m.runMigration(ctx, c, mi, "up", func(tx *pop.Tx) error {
 				// #nosec G201 - mtn is a system-wide const
 				if _, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (version) VALUES ('%s')", mtn, mi.Version)); err != nil {
 					return errors.Wrapf(err, "problem inserting migration version %s", mi.Version)
@@ -148,6 +154,7 @@ func (m *Migrator) UpTo(ctx context.Context, step int) (applied int, err error)
 			}); err != nil {
 				return err
 			}
+			progress.finish(mi)
 
 			m.l.Debugf("> %s", mi.Name)
 			applied++
@@ -155,6 +162,7 @@ func (m *Migrator) UpTo(ctx context.Context, step int) (applied int, err error)
 				break
 			}
 		}
+		progress.complete()
 		if applied == 0 {
 			m.l.Debugf("Migrations already up to date, nothing to apply")
 		} else {
@@ -174,47 +182,22 @@ func (m *Migrator) Down(ctx context.Context, step int) error {
 	c := m.Connection.WithContext(ctx)
 	return m.exec(ctx, func() error {
 		mtn := m.migrationTableName(ctx, c)
-		count, err := c.Count(mtn)
+		// Walk the applied migrations instead of assuming they form a contiguous prefix, as out-of-order
+		// migrations may have been skipped.
+		mfs, err := m.appliedMigrations(c, mtn, m.Migrations["down"].SortAndFilter(c.Dialect.Name(), sort.Reverse))
 		if err != nil {
-			return errors.Wrap(err, "migration down: unable count existing migration")
-		}
-		mfs := m.Migrations["down"].SortAndFilter(c.Dialect.Name(), sort.Reverse)
-		// skip all ran migration
-		if len(mfs) > count {
-			mfs = mfs[len(mfs)-count:]
+			return err
 		}
 		// run only required steps
 		if step > 0 && len(mfs) >= step {
 			mfs = mfs[:step]
 		}
+		progress := m.newProgressReporter("down", len(mfs), 0)
 		for _, mi := range mfs {
-			exists, err := c.Where("version = ?", mi.Version).Exists(mtn)
-			if err != nil {
-				return errors.Wrapf(err, "problem checking for migration version %s", mi.Version)
-			}
-
-			if !exists && len(mi.Version) > 14 {
-				legacyVersion := mi.Version[:14]
-				legacyVersionExists, err := c.Where("version = ?", legacyVersion).Exists(mtn)
-				if err != nil {
-					return errors.Wrapf(err, "problem checking for migration version %s", mi.Version)
-				}
-
-				if !legacyVersionExists {
-					return errors.Wrapf(err, "problem checking for migration version %s", legacyVersion)
-				}
-			} else if !exists {
-				return errors.Errorf("migration version %s does not exist", mi.Version)
-			}
-
-			err = m.isolatedTransaction(ctx, "down", func(tx *pop.Tx) error {
-				err := mi.Run(c, tx)
-				if err != nil {
-					return err
-				}
-
+			progress.start(mi)
+			err = m.runMigration(ctx, c, mi, "down", func(tx *pop.Tx) error {
 				// #nosec G201 - mtn is a system-wide const
-				if _, err = tx.Exec(tx.Rebind(fmt.Sprintf("DELETE FROM %s WHERE version = ?", mtn)), mi.Version); err != nil {
+				if _, err := tx.Exec(tx.Rebind(fmt.Sprintf("DELETE FROM %s WHERE version = ?", mtn)), mi.Version); err != nil {
 					return errors.Wrapf(err, "problem deleting migration version %s", mi.Version)
 				}
 
@@ -223,9 +206,11 @@ func (m *Migrator) Down(ctx context.Context, step int) error {
 			if err != nil {
 				return err
 			}
+			progress.finish(mi)
 
 			m.l.Debugf("< %s", mi.Name)
 		}
+		progress.complete()
 		return nil
 	})
 }
@@ -430,6 +415,17 @@ func (m MigrationStatuses) HasPending() bool {
 	return false
 }
 
+// LatestApplied returns the version of the most recent applied migration, or an empty string if
+// no migration was applied.
+func (m MigrationStatuses) LatestApplied() string {
+	for k := len(m) - 1; k >= 0; k-- {
+		if m[k].State == Applied {
+			return m[k].Version
+		}
+	}
+	return ""
+}
+
 func (m *Migrator) migrationTableName(ctx context.Context, con *pop.Connection) string {
 	return con.MigrationTableName()
 }
@@ -489,6 +485,16 @@ func (m *Migrator) Status(ctx context.Context) (MigrationStatuses, error) {
 	return statuses, nil
 }
 
+// SchemaVersion returns the version of the most recent applied migration. It can be used with
+// healthx.WithSchemaVersion to report the schema version on the version endpoint.
+func (m *Migrator) SchemaVersion(ctx context.Context) (string, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	return statuses.LatestApplied(), nil
+}
+
 // DumpMigrationSchema will generate a file of the current database schema
 func (m *Migrator) DumpMigrationSchema(ctx context.Context) error {
 	c := m.Connection.WithContext(ctx)
@@ -9,8 +9,11 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/gobuffalo/pop/v6"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -170,3 +173,247 @@ func TestMigratorUpgradingFromStart(t *testing.T) {
 
 	require.NoError(t, transactional.Down(ctx, -1))
 }
+
+func TestMigratorOutOfOrder(t *testing.T) {
+	ctx := context.Background()
+	l := logrusx.New("", "")
+
+	migration := func(table string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(fmt.Sprintf("CREATE TABLE %s (id INTEGER PRIMARY KEY);", table))}
+	}
+	released := fstest.MapFS{
+		"20210101000001_one.sqlite3.up.sql":   migration("one"),
+		"20210101000003_three.sqlite3.up.sql": migration("three"),
+	}
+	drop := func(table string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(fmt.Sprintf("DROP TABLE %s;", table))}
+	}
+	merged := fstest.MapFS{
+		"20210101000001_one.sqlite3.up.sql":     migration("one"),
+		"20210101000001_one.sqlite3.down.sql":   drop("one"),
+		"20210101000002_two.sqlite3.up.sql":     migration("two"),
+		"20210101000002_two.sqlite3.down.sql":   drop("two"),
+		"20210101000003_three.sqlite3.up.sql":   migration("three"),
+		"20210101000003_three.sqlite3.down.sql": drop("three"),
+	}
+
+	newConnection := func(t *testing.T) *pop.Connection {
+		c, err := pop.NewConnection(&pop.ConnectionDetails{
+			URL: "sqlite://file::memory:?_fk=true",
+		})
+		require.NoError(t, err)
+		require.NoError(t, c.Open())
+
+		mb, err := NewMigrationBox(released, NewMigrator(c, l, nil, 0))
+		require.NoError(t, err)
+		require.NoError(t, mb.Up(ctx))
+		return c
+	}
+
+	t.Run("policy=fail", func(t *testing.T) {
+		mb, err := NewMigrationBox(merged, NewMigrator(newConnection(t), l, nil, 0), WithOutOfOrderPolicy(OutOfOrderFail))
+		require.NoError(t, err)
+		require.ErrorIs(t, mb.Up(ctx), ErrOutOfOrderMigration)
+
+		status, err := mb.Status(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, Pending, status[1].State)
+	})
+
+	t.Run("policy=warn", func(t *testing.T) {
+		mb, err := NewMigrationBox(merged, NewMigrator(newConnection(t), l, nil, 0), WithOutOfOrderPolicy(OutOfOrderWarn))
+		require.NoError(t, err)
+		require.NoError(t, mb.Up(ctx))
+
+		status, err := mb.Status(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, Pending, status[1].State)
+
+		// The skipped migration must not be rolled back.
+		require.NoError(t, mb.Down(ctx, 1))
+		status, err = mb.Status(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{Applied, Pending, Pending}, []string{status[0].State, status[1].State, status[2].State})
+
+		require.NoError(t, mb.Down(ctx, -1))
+		status, err = mb.Status(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, Pending, status[0].State)
+	})
+
+	t.Run("policy=apply", func(t *testing.T) {
+		mb, err := NewMigrationBox(merged, NewMigrator(newConnection(t), l, nil, 0), WithOutOfOrderPolicy(OutOfOrderApply))
+		require.NoError(t, err)
+		require.NoError(t, mb.Up(ctx))
+
+		status, err := mb.Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, status.HasPending())
+	})
+}
+
+func TestMigratorProgress(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := pop.NewConnection(&pop.ConnectionDetails{
+		URL: "sqlite://file::memory:?_fk=true",
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+
+	migrations := fstest.MapFS{
+		"20210101000001_one.sqlite3.up.sql":   {Data: []byte("CREATE TABLE one (id INTEGER PRIMARY KEY);")},
+		"20210101000002_two.sqlite3.up.sql":   {Data: []byte("CREATE TABLE two (id INTEGER PRIMARY KEY);")},
+		"20210101000001_one.sqlite3.down.sql": {Data: []byte("DROP TABLE one;")},
+		"20210101000002_two.sqlite3.down.sql": {Data: []byte("DROP TABLE two;")},
+	}
+
+	var events []MigrationProgress
+	mb, err := NewMigrationBox(migrations, NewMigrator(c, logrusx.New("", ""), nil, 0), WithProgressReporter(func(p MigrationProgress) {
+		events = append(events, p)
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, mb.Up(ctx))
+	require.Len(t, events, 4)
+	for k, expected := range []struct {
+		version string
+		index   int
+		done    bool
+	}{
+		{"20210101000001", 1, false},
+		{"20210101000001", 1, true},
+		{"20210101000002", 2, false},
+		{"20210101000002", 2, true},
+	} {
+		assert.Equal(t, "up", events[k].Direction)
+		assert.Equal(t, expected.version, events[k].Version)
+		assert.Equal(t, expected.index, events[k].Index)
+		assert.Equal(t, expected.done, events[k].Done)
+		assert.Equal(t, 2, events[k].Total)
+	}
+
+	events = nil
+	require.NoError(t, mb.Down(ctx, 1))
+	require.Len(t, events, 2)
+	assert.Equal(t, "down", events[1].Direction)
+	assert.Equal(t, "20210101000002", events[1].Version)
+	assert.Equal(t, 1, events[1].Total)
+	assert.True(t, events[1].Done)
+}
+
+func TestMigratorGoMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := pop.NewConnection(&pop.ConnectionDetails{
+		URL: "sqlite://file::memory:?_fk=true",
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+
+	migrations := fstest.MapFS{
+		"20210101000001_identities.sqlite3.up.sql":   {Data: []byte("CREATE TABLE identities (id INTEGER PRIMARY KEY, email TEXT);")},
+		"20210101000001_identities.sqlite3.down.sql": {Data: []byte("DROP TABLE identities;")},
+		"20210101000003_sessions.sqlite3.up.sql":     {Data: []byte("CREATE TABLE sessions (id INTEGER PRIMARY KEY);")},
+		"20210101000003_sessions.sqlite3.down.sql":   {Data: []byte("DROP TABLE sessions;")},
+	}
+
+	var dialects []string
+	mb, err := NewMigrationBox(migrations, NewMigrator(c, logrusx.New("", ""), nil, 0), WithGoMigrations(GoMigration{
+		Version: "20210101000002",
+		Name:    "backfill_identities",
+		Up: func(tx *pop.Tx, dialect string) error {
+			dialects = append(dialects, dialect)
+			_, err := tx.Exec("INSERT INTO identities (id, email) VALUES (1, 'foo@bar.com')")
+			return err
+		},
+		Down: func(tx *pop.Tx, dialect string) error {
+			_, err := tx.Exec("DELETE FROM identities")
+			return err
+		},
+	}))
+	require.NoError(t, err)
+
+	status, err := mb.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 3)
+	assert.Equal(t, "backfill_identities", status[1].Name)
+
+	require.NoError(t, mb.Up(ctx))
+	assert.Equal(t, []string{"sqlite3"}, dialects)
+
+	var count int
+	require.NoError(t, c.Store.Get(&count, "SELECT COUNT(*) FROM identities"))
+	assert.Equal(t, 1, count)
+
+	status, err = mb.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.HasPending())
+
+	require.NoError(t, mb.Down(ctx, 2))
+	require.NoError(t, c.Store.Get(&count, "SELECT COUNT(*) FROM identities"))
+	assert.Equal(t, 0, count)
+}
+
+func TestMigratorNoTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := pop.NewConnection(&pop.ConnectionDetails{
+		URL: "sqlite://file::memory:?_fk=true",
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+
+	migrations := fstest.MapFS{
+		"20210101000001_identities.sqlite3.up.sql":   {Data: []byte("CREATE TABLE identities (id INTEGER PRIMARY KEY, email TEXT);")},
+		"20210101000001_identities.sqlite3.down.sql": {Data: []byte("DROP TABLE identities;")},
+		"20210101000002_index.sqlite3.up.sql":        {Data: []byte("-- popx:no_transaction\n-- popx:statement_timeout=1m\nCREATE INDEX identities_email_idx ON identities (email);")},
+		"20210101000002_index.sqlite3.down.sql":      {Data: []byte("-- popx:no_transaction\nDROP INDEX identities_email_idx;")},
+	}
+
+	mb, err := NewMigrationBox(migrations, NewMigrator(c, logrusx.New("", ""), nil, 0), WithLockTimeout(time.Second))
+	require.NoError(t, err)
+	assert.True(t, mb.Migrations["up"][1].NoTransaction)
+	assert.Equal(t, time.Minute, mb.Migrations["up"][1].StatementTimeout)
+
+	require.NoError(t, mb.Up(ctx))
+	status, err := mb.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.HasPending())
+
+	require.NoError(t, mb.Down(ctx, -1))
+}
+
+func TestMigratorMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := pop.NewConnection(&pop.ConnectionDetails{
+		URL: "sqlite://file::memory:?_fk=true",
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+
+	migrations := fstest.MapFS{
+		"20210101000001_one.sqlite3.up.sql": {Data: []byte("CREATE TABLE one (id INTEGER PRIMARY KEY);")},
+		"20210101000002_two.sqlite3.up.sql": {Data: []byte("CREATE TABLE two (id INTEGER PRIMARY KEY);")},
+	}
+
+	metrics := NewMigrationMetrics("test")
+	mb, err := NewMigrationBox(migrations, NewMigrator(c, logrusx.New("", ""), nil, 0), WithMetrics(metrics))
+	require.NoError(t, err)
+	require.NoError(t, mb.Up(ctx))
+
+	// one series per migration and one for the run
+	assert.Equal(t, 3, testutil.CollectAndCount(metrics))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics, "test_migration_duration_seconds"))
+}
+
+func TestMigrationStatusesLatestApplied(t *testing.T) {
+	assert.Equal(t, "", MigrationStatuses{}.LatestApplied())
+	assert.Equal(t, "", MigrationStatuses{{Version: "1", State: Pending}}.LatestApplied())
+	assert.Equal(t, "2", MigrationStatuses{
+		{Version: "1", State: Applied},
+		{Version: "2", State: Applied},
+		{Version: "3", State: Pending},
+	}.LatestApplied())
+}
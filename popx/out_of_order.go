@@ -0,0 +1,124 @@
+package popx
+
+import (
+	"github.com/gobuffalo/pop/v6"
+	"github.com/pkg/errors"
+)
+
+// OutOfOrderPolicy defines how the migrator treats pending migrations which have a version
+// older than the newest applied migration. This typically happens after merging feature
+// branches which have been developed in parallel.
+type OutOfOrderPolicy int
+
+const (
+	// OutOfOrderApply applies out-of-order migrations in version order together with
+	// all other pending migrations. This is the default.
+	OutOfOrderApply OutOfOrderPolicy = iota
+	// OutOfOrderWarn logs a warning and skips out-of-order migrations. They remain pending.
+	OutOfOrderWarn
+	// OutOfOrderFail aborts the migration run before applying anything.
+	OutOfOrderFail
+)
+
+// ErrOutOfOrderMigration is returned when pending migrations older than the newest applied
+// migration were found and the OutOfOrderFail policy is set.
+var ErrOutOfOrderMigration = errors.New("found pending migrations which are older than the newest applied migration")
+
+func (p OutOfOrderPolicy) String() string {
+	switch p {
+	case OutOfOrderApply:
+		return "apply"
+	case OutOfOrderWarn:
+		return "warn"
+	case OutOfOrderFail:
+		return "fail"
+	}
+	return "unknown"
+}
+
+// pendingMigrations returns all pending migrations, the subset of them which is older than the newest
+// applied migration, and the migrations which have only been applied under their legacy version. The
+// migrations are expected to be sorted in ascending order.
+func (m *Migrator) pendingMigrations(c *pop.Connection, mtn string, mfs Migrations) (pending, outOfOrder, legacy Migrations, err error) {
+	var unapplied Migrations
+	for _, mi := range mfs {
+		applied, isLegacy, err := m.isApplied(c, mtn, mi.Version)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if isLegacy {
+			legacy = append(legacy, mi)
+		}
+		if applied {
+			outOfOrder = append(outOfOrder, unapplied...)
+			unapplied = nil
+		} else {
+			pending = append(pending, mi)
+			unapplied = append(unapplied, mi)
+		}
+	}
+	return pending, outOfOrder, legacy, nil
+}
+
+// appliedMigrations returns the migrations which have been applied, keeping their order. Unlike counting the
+// rows of the migration table, this also works if older migrations have been skipped using OutOfOrderWarn.
+func (m *Migrator) appliedMigrations(c *pop.Connection, mtn string, mfs Migrations) (Migrations, error) {
+	var applied Migrations
+	for _, mi := range mfs {
+		ok, _, err := m.isApplied(c, mtn, mi.Version)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			applied = append(applied, mi)
+		}
+	}
+	return applied, nil
+}
+
+// isApplied checks whether the migration or its legacy counterpart has been applied. If only the legacy
+// counterpart has been applied, legacy is true.
+func (m *Migrator) isApplied(c *pop.Connection, mtn string, version string) (applied, legacy bool, err error) {
+	exists, err := c.Where("version = ?", version).Exists(mtn)
+	if err != nil {
+		return false, false, errors.Wrapf(err, "problem checking for migration version %s", version)
+	}
+
+	if exists || len(version) <= 14 {
+		return exists, false, nil
+	}
+
+	legacyVersion := version[:14]
+	exists, err = c.Where("version = ?", legacyVersion).Exists(mtn)
+	if err != nil {
+		return false, false, errors.Wrapf(err, "problem checking for migration version %s", legacyVersion)
+	}
+	return exists, exists, nil
+}
+
+func (m *Migrator) handleOutOfOrder(outOfOrder Migrations) (skip map[string]bool, err error) {
+	skip = make(map[string]bool)
+	if len(outOfOrder) == 0 {
+		return skip, nil
+	}
+
+	versions := make([]string, len(outOfOrder))
+	for k, mi := range outOfOrder {
+		versions[k] = mi.Version
+	}
+
+	l := m.l.WithField("out_of_order_versions", versions).WithField("out_of_order_policy", m.OutOfOrderPolicy.String())
+	switch m.OutOfOrderPolicy {
+	case OutOfOrderFail:
+		return nil, errors.Wrapf(ErrOutOfOrderMigration, "versions %v", versions)
+	case OutOfOrderWarn:
+		l.Warn("Found pending migrations which are older than the newest applied migration. Skipping them, they will remain pending.")
+		for _, v := range versions {
+			skip[v] = true
+		}
+	default:
+		l.Info("Found pending migrations which are older than the newest applied migration. Applying them out of order.")
+	}
+	return skip, nil
+}
@@ -0,0 +1,75 @@
+package popx
+
+import (
+	"time"
+)
+
+// MigrationProgress is reported to Migrator.OnProgress before and after each migration
+// is applied or rolled back.
+type MigrationProgress struct {
+	// Direction of the migration run (up|down).
+	Direction string `json:"direction"`
+	// Version of the current migration.
+	Version string `json:"version"`
+	// Name of the current migration.
+	Name string `json:"name"`
+	// Index is the 1-based position of the current migration in this run.
+	Index int `json:"index"`
+	// Total is the number of migrations this run is expected to execute.
+	Total int `json:"total"`
+	// Done is false when the migration starts and true once it completed successfully.
+	Done bool `json:"done"`
+	// Took is the execution time of the current migration. It is zero if Done is false.
+	Took time.Duration `json:"took"`
+	// Elapsed is the time passed since the run started.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+type progressReporter struct {
+	m            *Migrator
+	direction    string
+	total, index int
+	started      time.Time
+	current      time.Time
+}
+
+func (m *Migrator) newProgressReporter(direction string, total, step int) *progressReporter {
+	if step > 0 && total > step {
+		total = step
+	}
+	return &progressReporter{m: m, direction: direction, total: total, started: time.Now()}
+}
+
+func (p *progressReporter) start(mi Migration) {
+	p.index++
+	p.current = time.Now()
+	p.report(mi, false, 0)
+}
+
+func (p *progressReporter) finish(mi Migration) {
+	took := time.Since(p.current)
+	p.m.Metrics.observeMigration(p.direction, mi, took)
+	p.report(mi, true, took)
+}
+
+// complete is called once all migrations of the run were executed successfully.
+func (p *progressReporter) complete() {
+	p.m.Metrics.observeRun(p.direction, time.Since(p.started))
+}
+
+func (p *progressReporter) report(mi Migration, done bool, took time.Duration) {
+	if p.m.OnProgress == nil {
+		return
+	}
+
+	p.m.OnProgress(MigrationProgress{
+		Direction: p.direction,
+		Version:   mi.Version,
+		Name:      mi.Name,
+		Index:     p.index,
+		Total:     p.total,
+		Done:      done,
+		Took:      took,
+		Elapsed:   time.Since(p.started),
+	})
+}
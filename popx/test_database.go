@@ -0,0 +1,108 @@
+package popx
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/randx"
+)
+
+// NewIsolatedTestConnection creates a uniquely named schema (PostgreSQL), database
+// (MySQL, CockroachDB), or in-memory database (SQLite) next to the database `c` is
+// connected to and returns a connection scoped to it. The schema is dropped and the
+// connection is closed once the test completes.
+//
+// Use this helper when running `go test -p` against a shared database server, to
+// prevent test packages from deadlocking on the shared migration table.
+func NewIsolatedTestConnection(t testing.TB, c *pop.Connection) *pop.Connection {
+	name := "test_" + randx.MustString(16, randx.AlphaLowerNum)
+
+	dsn, create, drop, err := isolatedTestDSN(c, name)
+	require.NoError(t, err)
+
+	if create != "" {
+		require.NoError(t, c.RawQuery(create).Exec(), "unable to create isolated test schema %s", name)
+	}
+
+	isolated, err := pop.NewConnection(&pop.ConnectionDetails{URL: dsn})
+	require.NoError(t, err)
+	require.NoError(t, isolated.Open())
+
+	t.Cleanup(func() {
+		if err := isolated.Close(); err != nil {
+			t.Logf("Unable to close connection to isolated test schema %s: %+v", name, err)
+		}
+		if drop == "" {
+			return
+		}
+		if err := c.RawQuery(drop).Exec(); err != nil {
+			t.Logf("Unable to drop isolated test schema %s: %+v", name, err)
+		}
+	})
+
+	return isolated
+}
+
+// NewMigratedTestConnection works like NewIsolatedTestConnection but additionally
+// runs all "up" migrations found in `migrations` against the isolated schema.
+func NewMigratedTestConnection(t testing.TB, c *pop.Connection, migrations fs.FS, l *logrusx.Logger, opts ...func(*MigrationBox) *MigrationBox) *pop.Connection {
+	isolated := NewIsolatedTestConnection(t, c)
+
+	mb, err := NewMigrationBox(migrations, NewMigrator(isolated, l, nil, 0), opts...)
+	require.NoError(t, err)
+	require.NoError(t, mb.Up(context.Background()))
+
+	return isolated
+}
+
+func isolatedTestDSN(c *pop.Connection, name string) (dsn, create, drop string, err error) {
+	switch c.Dialect.Name() {
+	case "sqlite3":
+		// Every named in-memory database is isolated from all others.
+		return fmt.Sprintf("sqlite://file:%s?mode=memory&cache=shared&_fk=true", name), "", "", nil
+	case "postgres":
+		u, err := url.Parse(c.URL())
+		if err != nil {
+			return "", "", "", errors.WithStack(err)
+		}
+		q := u.Query()
+		q.Set("search_path", name)
+		u.RawQuery = q.Encode()
+		return u.String(),
+			fmt.Sprintf("CREATE SCHEMA %s", name),
+			fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", name),
+			nil
+	case "cockroach":
+		u, err := url.Parse(c.URL())
+		if err != nil {
+			return "", "", "", errors.WithStack(err)
+		}
+		u.Scheme = "cockroach"
+		u.Path = "/" + name
+		return u.String(),
+			fmt.Sprintf("CREATE DATABASE %s", name),
+			fmt.Sprintf("DROP DATABASE IF EXISTS %s CASCADE", name),
+			nil
+	case "mysql":
+		cfg, err := mysql.ParseDSN(strings.TrimPrefix(c.URL(), "mysql://"))
+		if err != nil {
+			return "", "", "", errors.WithStack(err)
+		}
+		cfg.DBName = name
+		return "mysql://" + cfg.FormatDSN(),
+			fmt.Sprintf("CREATE DATABASE %s", name),
+			fmt.Sprintf("DROP DATABASE IF EXISTS %s", name),
+			nil
+	}
+	return "", "", "", errors.Errorf("isolated test schemas are not supported for dialect: %s", c.Dialect.Name())
+}
@@ -0,0 +1,56 @@
+package popx_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+	. "github.com/ory/x/popx"
+	"github.com/ory/x/sqlcon/dockertest"
+)
+
+func TestNewMigratedTestConnection(t *testing.T) {
+	sqlite, err := pop.NewConnection(&pop.ConnectionDetails{
+		URL: "sqlite://file::memory:?_fk=true",
+	})
+	require.NoError(t, err)
+	require.NoError(t, sqlite.Open())
+
+	connections := map[string]*pop.Connection{
+		"sqlite": sqlite,
+	}
+
+	if !testing.Short() {
+		dockertest.Parallel([]func(){
+			func() {
+				connections["postgres"] = dockertest.ConnectToTestPostgreSQLPop(t)
+			},
+			func() {
+				connections["mysql"] = dockertest.ConnectToTestMySQLPop(t)
+			},
+			func() {
+				connections["cockroach"] = dockertest.ConnectToTestCockroachDBPop(t)
+			},
+		})
+	}
+
+	l := logrusx.New("", "")
+	for name, c := range connections {
+		t.Run(fmt.Sprintf("database=%s", name), func(t *testing.T) {
+			// Two isolated connections must not see each other's migration tables.
+			a := NewMigratedTestConnection(t, c, transactionalMigrations, l)
+			b := NewIsolatedTestConnection(t, c)
+
+			count, err := a.Count(a.MigrationTableName())
+			require.NoError(t, err)
+			assert.NotZero(t, count)
+
+			_, err = b.Count(b.MigrationTableName())
+			require.Error(t, err)
+		})
+	}
+}
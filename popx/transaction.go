@@ -2,28 +2,47 @@ package popx
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/cockroachdb/cockroach-go/v2/crdb"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 
 	"github.com/gobuffalo/pop/v6"
+
+	"github.com/ory/x/sqlcon"
 )
 
 type transactionContextKey int
 
-const transactionKey transactionContextKey = 0
+const (
+	transactionKey transactionContextKey = iota
+	savepointDepthKey
+)
 
 func WithTransaction(ctx context.Context, tx *pop.Connection) context.Context {
 	return context.WithValue(ctx, transactionKey, tx)
 }
 
+// Transaction runs callback in a transaction which is committed if callback returns nil and rolled back
+// otherwise. On CockroachDB, the transaction is retried if it fails with a retryable error.
+//
+// If ctx carries a transaction, see WithTransaction, or connection is a transaction already, callback runs
+// in a savepoint of that transaction instead. If callback fails, only the changes made since the savepoint
+// are rolled back and the enclosing transaction can continue. The transaction or savepoint is rolled back
+// if callback panics. This allows library code to compose without
+// opening a second transaction or prematurely committing the caller's transaction. Savepoints are
+// supported by PostgreSQL, CockroachDB, MySQL (InnoDB), and SQLite.
 func Transaction(ctx context.Context, connection *pop.Connection, callback func(context.Context, *pop.Connection) error) error {
 	c := ctx.Value(transactionKey)
 	if c != nil {
 		if conn, ok := c.(*pop.Connection); ok {
-			return callback(ctx, conn.WithContext(ctx))
+			return savepoint(ctx, conn.WithContext(ctx), callback)
 		}
 	}
+	if connection.TX != nil {
+		return savepoint(WithTransaction(ctx, connection), connection.WithContext(ctx), callback)
+	}
 
 	if connection.Dialect.Name() == "cockroach" {
 		return connection.WithContext(ctx).Dialect.Lock(func() error {
@@ -38,11 +57,65 @@ func Transaction(ctx context.Context, connection *pop.Connection, callback func(
 		})
 	}
 
-	return connection.WithContext(ctx).Transaction(func(tx *pop.Connection) error {
-		return callback(WithTransaction(ctx, tx), tx)
+	return connection.WithContext(ctx).Dialect.Lock(func() error {
+		tx, err := connection.WithContext(ctx).NewTransaction()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				_ = tx.TX.Rollback()
+				panic(r)
+			}
+		}()
+
+		if err := callback(WithTransaction(ctx, tx), tx); err != nil {
+			if rerr := tx.TX.Rollback(); rerr != nil {
+				return errors.WithMessagef(err, "unable to roll back transaction: %s", rerr)
+			}
+			return err
+		}
+		return errors.WithStack(tx.TX.Commit())
 	})
 }
 
+func savepoint(ctx context.Context, tx *pop.Connection, callback func(context.Context, *pop.Connection) error) error {
+	depth, _ := ctx.Value(savepointDepthKey).(int)
+	depth++
+	name := fmt.Sprintf("popx_savepoint_%d", depth)
+
+	if err := tx.RawQuery("SAVEPOINT " + name).Exec(); err != nil {
+		return errors.Wrapf(sqlcon.HandleError(err), "unable to create savepoint %s", name)
+	}
+
+	rollback := func() error {
+		if err := tx.RawQuery("ROLLBACK TO SAVEPOINT " + name).Exec(); err != nil {
+			return err
+		}
+		return tx.RawQuery("RELEASE SAVEPOINT " + name).Exec()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = rollback()
+			panic(r)
+		}
+	}()
+
+	if err := callback(context.WithValue(ctx, savepointDepthKey, depth), tx); err != nil {
+		if rerr := rollback(); rerr != nil {
+			return errors.WithMessagef(err, "unable to roll back to savepoint %s: %s", name, rerr)
+		}
+		return err
+	}
+
+	if err := tx.RawQuery("RELEASE SAVEPOINT " + name).Exec(); err != nil {
+		return errors.Wrapf(sqlcon.HandleError(err), "unable to release savepoint %s", name)
+	}
+	return nil
+}
+
 func GetConnection(ctx context.Context, connection *pop.Connection) *pop.Connection {
 	c := ctx.Value(transactionKey)
 	if c != nil {
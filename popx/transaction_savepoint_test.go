@@ -0,0 +1,100 @@
+//go:build sqlite
+// +build sqlite
+
+package popx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon"
+)
+
+func TestTransactionSavepoints(t *testing.T) {
+	c, err := pop.NewConnection(sqlcon.NewTestSQLite(t))
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+	t.Cleanup(func() { _ = c.Close() })
+	require.NoError(t, c.RawQuery("CREATE TABLE items (name TEXT)").Exec())
+
+	ctx := context.Background()
+	insert := func(tx *pop.Connection, name string) error {
+		return tx.RawQuery("INSERT INTO items (name) VALUES (?)", name).Exec()
+	}
+	names := func(t *testing.T) (names []string) {
+		require.NoError(t, c.RawQuery("SELECT name FROM items ORDER BY name").All(&names))
+		return names
+	}
+	reset := func(t *testing.T) {
+		require.NoError(t, c.RawQuery("DELETE FROM items").Exec())
+	}
+
+	t.Run("case=commits", func(t *testing.T) {
+		t.Cleanup(func() { reset(t) })
+		require.NoError(t, Transaction(ctx, c, func(ctx context.Context, tx *pop.Connection) error {
+			return insert(tx, "a")
+		}))
+		assert.Equal(t, []string{"a"}, names(t))
+	})
+
+	t.Run("case=rolls back", func(t *testing.T) {
+		t.Cleanup(func() { reset(t) })
+		expected := errors.New("foo")
+		require.ErrorIs(t, Transaction(ctx, c, func(ctx context.Context, tx *pop.Connection) error {
+			require.NoError(t, insert(tx, "a"))
+			return expected
+		}), expected)
+		assert.Empty(t, names(t))
+	})
+
+	t.Run("case=nested transaction rolls back to savepoint", func(t *testing.T) {
+		t.Cleanup(func() { reset(t) })
+		require.NoError(t, Transaction(ctx, c, func(ctx context.Context, tx *pop.Connection) error {
+			require.NoError(t, insert(tx, "a"))
+
+			// The inner call receives the non-transactional connection but picks up the transaction from ctx.
+			err := Transaction(ctx, c, func(ctx context.Context, tx *pop.Connection) error {
+				require.NoError(t, insert(tx, "b"))
+				require.NoError(t, Transaction(ctx, tx, func(ctx context.Context, tx *pop.Connection) error {
+					return insert(tx, "c")
+				}))
+				return errors.New("inner failure")
+			})
+			require.Error(t, err)
+
+			return Transaction(ctx, tx, func(ctx context.Context, tx *pop.Connection) error {
+				return insert(tx, "d")
+			})
+		}))
+		assert.Equal(t, []string{"a", "d"}, names(t))
+	})
+
+	t.Run("case=transaction from context", func(t *testing.T) {
+		t.Cleanup(func() { reset(t) })
+		require.NoError(t, c.Transaction(func(tx *pop.Connection) error {
+			require.NoError(t, insert(tx, "a"))
+			require.Error(t, Transaction(WithTransaction(ctx, tx), c, func(ctx context.Context, tx *pop.Connection) error {
+				require.NoError(t, insert(GetConnection(ctx, c), "b"))
+				return errors.New("inner failure")
+			}))
+			return nil
+		}))
+		assert.Equal(t, []string{"a"}, names(t))
+	})
+
+	t.Run("case=rolls back on panic", func(t *testing.T) {
+		t.Cleanup(func() { reset(t) })
+		assert.Panics(t, func() {
+			_ = Transaction(ctx, c, func(ctx context.Context, tx *pop.Connection) error {
+				require.NoError(t, insert(tx, "a"))
+				panic("foo")
+			})
+		})
+		assert.Empty(t, names(t))
+	})
+}
@@ -0,0 +1,36 @@
+package prometheus
+
+import (
+	"bytes"
+	_ "embed"
+	"io"
+)
+
+//go:embed config.schema.json
+var ConfigSchema string
+
+const ConfigSchemaID = "ory://prometheus-config"
+
+// AddConfigSchema adds the Prometheus schema to the compiler.
+// The interface is specified instead of `jsonschema.Compiler` to allow the use of any jsonschema library fork or version.
+func AddConfigSchema(c interface {
+	AddResource(url string, r io.Reader) error
+}) error {
+	return c.AddResource(ConfigSchemaID, bytes.NewBufferString(ConfigSchema))
+}
+
+type (
+	// Config configures the metrics Handler.
+	Config struct {
+		// Path is the path the metrics are served at. Defaults to MetricsPrometheusPath.
+		Path string `json:"path,omitempty"`
+		// Auth requires scrapers to authenticate using HTTP Basic Authentication if set.
+		Auth *AuthConfig `json:"auth,omitempty"`
+	}
+
+	// AuthConfig contains the credentials scrapers authenticate with.
+	AuthConfig struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+)
@@ -0,0 +1,27 @@
+package prometheus_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/jsonschema/v3"
+
+	prometheus "github.com/ory/x/prometheusx"
+)
+
+func TestConfigSchema(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	require.NoError(t, prometheus.AddConfigSchema(c))
+	require.NoError(t, c.AddResource("config", bytes.NewBufferString(fmt.Sprintf(`{"properties":{"metrics":{"$ref":"%s"}}}`, prometheus.ConfigSchemaID))))
+	schema, err := c.Compile(context.Background(), "config")
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.Validate(bytes.NewBufferString(`{"metrics":{"enabled":true,"auth":{"username":"prometheus","password":"secret"}}}`)))
+	assert.Error(t, schema.Validate(bytes.NewBufferString(`{"metrics":{"path":"metrics"}}`)))
+	assert.Error(t, schema.Validate(bytes.NewBufferString(`{"metrics":{"auth":{"username":"prometheus"}}}`)))
+}
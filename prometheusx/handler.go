@@ -1,9 +1,11 @@
 package prometheus
 
 import (
+	"crypto/subtle"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/ory/herodot"
@@ -17,22 +19,48 @@ const (
 type Handler struct {
 	H             herodot.Writer
 	VersionString string
+
+	c       Config
+	metrics http.Handler
+}
+
+// HandlerOption configures the Handler.
+type HandlerOption func(*Handler)
+
+// WithConfig sets the path the metrics are served at and the credentials scrapers must authenticate with,
+// e.g. as returned by configx.Provider.PrometheusMetrics.
+func WithConfig(c Config) HandlerOption {
+	return func(h *Handler) {
+		h.c = c
+	}
 }
 
 // NewHandler instantiates a handler.
 func NewHandler(
 	h herodot.Writer,
 	version string,
+	opts ...HandlerOption,
 ) *Handler {
-	return &Handler{
+	handler := &Handler{
 		H:             h,
 		VersionString: version,
+		// The OpenMetrics format is negotiated with scrapers supporting it, as only it includes exemplars.
+		metrics: promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+			EnableOpenMetrics: true,
+		})),
+	}
+	for _, o := range opts {
+		o(handler)
 	}
+	if handler.c.Path == "" {
+		handler.c.Path = MetricsPrometheusPath
+	}
+	return handler
 }
 
 // SetRoutes registers this handler's routes.
 func (h *Handler) SetRoutes(r *httprouter.Router) {
-	r.GET(MetricsPrometheusPath, h.Metrics)
+	r.GET(h.c.Path, h.Metrics)
 }
 
 // Metrics outputs prometheus metrics
@@ -54,6 +82,23 @@ func (h *Handler) SetRoutes(r *httprouter.Router) {
 //
 //     Responses:
 //       200: emptyResponse
+//       401: emptyResponse
 func (h *Handler) Metrics(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	promhttp.Handler().ServeHTTP(rw, r)
+	if h.c.Auth != nil && !h.authenticated(r) {
+		rw.Header().Set("WWW-Authenticate", `Basic realm="metrics", charset="UTF-8"`)
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	h.metrics.ServeHTTP(rw, r)
+}
+
+func (h *Handler) authenticated(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	// Compare both values to not leak which one is wrong through the response time.
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(h.c.Auth.Username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(h.c.Auth.Password)) == 1
+	return usernameOK && passwordOK
 }
@@ -12,6 +12,7 @@ import (
 	prometheus "github.com/ory/x/prometheusx"
 
 	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,3 +36,52 @@ func TestHandler(t *testing.T) {
 	require.NoError(t, err)
 	require.EqualValues(t, "go_info", *text["go_info"].Name)
 }
+
+func TestHandlerConfig(t *testing.T) {
+	writer := herodot.NewJSONWriter(logrusx.New("Ory X", "test"))
+
+	t.Run("case=serves metrics at path", func(t *testing.T) {
+		router := httprouter.New()
+		prometheus.NewHandler(writer, "test", prometheus.WithConfig(prometheus.Config{Path: "/admin/metrics"})).SetRoutes(router)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/metrics", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "go_info")
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", prometheus.MetricsPrometheusPath, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("case=requires basic auth", func(t *testing.T) {
+		router := httprouter.New()
+		prometheus.NewHandler(writer, "test", prometheus.WithConfig(prometheus.Config{
+			Auth: &prometheus.AuthConfig{Username: "prometheus", Password: "secret"},
+		})).SetRoutes(router)
+
+		for k, tc := range []struct {
+			username, password string
+			auth               bool
+			expected           int
+		}{
+			{expected: http.StatusUnauthorized},
+			{username: "prometheus", password: "wrong", auth: true, expected: http.StatusUnauthorized},
+			{username: "wrong", password: "secret", auth: true, expected: http.StatusUnauthorized},
+			{username: "prometheus", password: "secret", auth: true, expected: http.StatusOK},
+		} {
+			r := httptest.NewRequest("GET", prometheus.MetricsPrometheusPath, nil)
+			if tc.auth {
+				r.SetBasicAuth(tc.username, tc.password)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expected, w.Code, "%d", k)
+			if tc.expected == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"), "%d", k)
+				assert.NotContains(t, w.Body.String(), "go_info", "%d", k)
+			}
+		}
+	})
+}
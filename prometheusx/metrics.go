@@ -3,8 +3,10 @@ package prometheus
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/ory/x/httpx"
+	"github.com/ory/x/tracing"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -126,6 +128,25 @@ func (h Metrics) instrumentHandlerStatusBucket(next http.Handler) http.HandlerFu
 	}
 }
 
+// instrumentHandlerResponseTime observes the response time of the endpoint. If the request context carries a
+// span, see tracing.TraceID, its trace ID is attached as exemplar so slow requests can be looked up in the
+// tracing backend. Exemplars are only exposed using the OpenMetrics format, which the Handler negotiates with
+// the scraper.
+func (h Metrics) instrumentHandlerResponseTime(endpoint string, next http.Handler) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(rw, r)
+
+		observer := h.responseTime.With(prometheus.Labels{"endpoint": endpoint})
+		seconds := time.Since(start).Seconds()
+		if traceID := tracing.TraceID(r.Context()); traceID != "" {
+			observer.(prometheus.ExemplarObserver).ObserveWithExemplar(seconds, prometheus.Labels{"trace_id": traceID})
+			return
+		}
+		observer.Observe(seconds)
+	}
+}
+
 // Instrument will instrument any http.HandlerFunc with custom metrics
 func (h Metrics) Instrument(rw http.ResponseWriter, next http.HandlerFunc, endpoint string) http.HandlerFunc {
 	labels := prometheus.Labels{}
@@ -136,7 +157,7 @@ func (h Metrics) Instrument(rw http.ResponseWriter, next http.HandlerFunc, endpo
 	wrapped := promhttp.InstrumentHandlerResponseSize(h.responseSize.MustCurryWith(labels), next)
 	wrapped = promhttp.InstrumentHandlerCounter(h.totalRequests.MustCurryWith(labels), wrapped)
 	wrapped = promhttp.InstrumentHandlerDuration(h.duration.MustCurryWith(labels), wrapped)
-	wrapped = promhttp.InstrumentHandlerDuration(h.responseTime.MustCurryWith(prometheus.Labels{"endpoint": endpoint}), wrapped)
+	wrapped = h.instrumentHandlerResponseTime(endpoint, wrapped)
 	wrapped = promhttp.InstrumentHandlerRequestSize(h.requestSize.MustCurryWith(labels), wrapped)
 	wrapped = h.instrumentHandlerStatusBucket(wrapped)
 
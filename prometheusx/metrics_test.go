@@ -8,10 +8,13 @@ import (
 	prometheus "github.com/ory/x/prometheusx"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	ioprometheusclient "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-client-go"
 	"github.com/urfave/negroni"
 )
 
@@ -73,6 +76,30 @@ func TestMetrics(t *testing.T) {
 	require.EqualValues(t, testApp, getLabelValue("app", text["http_requests_statuses_total"].Metric))
 }
 
+func TestMetricsExemplar(t *testing.T) {
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	traceID := span.Context().(jaeger.SpanContext).TraceID().String()
+
+	router := httprouter.New()
+	router.GET("/items/:id", func(rw http.ResponseWriter, r *http.Request, _ httprouter.Params) {})
+	mm := prometheus.NewMetricsManagerWithPrefix("test_app", "exemplar", "", "", "")
+	mm.RegisterRouter(router)
+
+	r := httptest.NewRequest("GET", "/items/a", nil)
+	mm.Handler(router).ServeHTTP(httptest.NewRecorder(), r.WithContext(opentracing.ContextWithSpan(r.Context(), span)))
+
+	r = httptest.NewRequest("GET", prometheus.MetricsPrometheusPath, nil)
+	r.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	w := httptest.NewRecorder()
+	prometheus.NewHandler(nil, "").Metrics(w, r, nil)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Regexp(t, `exemplar_response_time_seconds_bucket\{.*endpoint="/items/\{param\}".*\} 1 # \{trace_id="`+traceID+`"\}`, w.Body.String())
+}
+
 func getLabelValue(name string, metric []*ioprometheusclient.Metric) string {
 	for _, label := range metric[0].Label {
 		if *label.Name == name {
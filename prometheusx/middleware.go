@@ -30,6 +30,13 @@ func (pmm *MetricsManager) ServeHTTP(rw http.ResponseWriter, r *http.Request, ne
 	pmm.prometheusMetrics.Instrument(rw, next, pmm.getLabelForPath(r))(rw, r)
 }
 
+// Handler wraps the handler with the middleware, for use without negroni.
+func (pmm *MetricsManager) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		pmm.ServeHTTP(rw, r, next.ServeHTTP)
+	})
+}
+
 func (pmm *MetricsManager) RegisterRouter(router *httprouter.Router) {
 	pmm.routers = append(pmm.routers, router)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/negroni"
 
+	"github.com/ory/x/httpx"
 	"github.com/ory/x/logrusx"
 )
 
@@ -119,7 +120,9 @@ func (m *Middleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next htt
 
 	// Try to get the real IP
 	remoteAddr := r.RemoteAddr
-	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+	if clientIP, ok := httpx.ClientIPFromContext(r.Context()); ok {
+		remoteAddr = clientIP
+	} else if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
 		remoteAddr = realIP
 	}
 
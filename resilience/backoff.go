@@ -0,0 +1,118 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	backoffOptions struct {
+		initialInterval time.Duration
+		maxInterval     time.Duration
+		maxAttempts     int
+		maxElapsedTime  time.Duration
+		retryable       func(error) bool
+		notify          func(err error, attempt int, wait time.Duration)
+	}
+	// BackoffOption configures RetryWithBackoff.
+	BackoffOption func(*backoffOptions)
+)
+
+// WithInitialInterval sets the wait time before the first retry. Defaults to 100 milliseconds.
+func WithInitialInterval(d time.Duration) BackoffOption {
+	return func(o *backoffOptions) {
+		o.initialInterval = d
+	}
+}
+
+// WithMaxInterval caps the exponentially growing wait time between retries. Defaults to five seconds.
+func WithMaxInterval(d time.Duration) BackoffOption {
+	return func(o *backoffOptions) {
+		o.maxInterval = d
+	}
+}
+
+// WithMaxAttempts sets the maximum number of times the function is called. By default, there is no limit.
+func WithMaxAttempts(attempts int) BackoffOption {
+	return func(o *backoffOptions) {
+		o.maxAttempts = attempts
+	}
+}
+
+// WithMaxElapsedTime gives up instead of waiting if the next attempt would start later than the duration
+// after the first one. By default, there is no limit besides the deadline of the context.
+func WithMaxElapsedTime(d time.Duration) BackoffOption {
+	return func(o *backoffOptions) {
+		o.maxElapsedTime = d
+	}
+}
+
+// WithRetryable sets the function deciding whether an error is retried. By default, all errors are.
+func WithRetryable(f func(error) bool) BackoffOption {
+	return func(o *backoffOptions) {
+		o.retryable = f
+	}
+}
+
+// WithNotify sets a function called before waiting for the next attempt, e.g. to log the error.
+func WithNotify(f func(err error, attempt int, wait time.Duration)) BackoffOption {
+	return func(o *backoffOptions) {
+		o.notify = f
+	}
+}
+
+// RetryWithBackoff calls f until it succeeds, returns an error which is not retryable, the maximum number of
+// attempts or the maximum elapsed time is reached, or the context is done. Retries are delayed with
+// exponential backoff and jitter, so that clients failing at the same time do not retry in lockstep.
+//
+// Errors which are not retryable are returned as is. If the retries are exhausted, the last error is
+// returned with a message saying why, and if the context is done, its error is returned.
+func RetryWithBackoff(ctx context.Context, f func(ctx context.Context) error, opts ...BackoffOption) error {
+	o := &backoffOptions{
+		initialInterval: 100 * time.Millisecond,
+		maxInterval:     5 * time.Second,
+		retryable:       func(error) bool { return true },
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	start := time.Now()
+	interval := o.initialInterval
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		if err == nil || !o.retryable(err) {
+			return err
+		}
+
+		if o.maxAttempts > 0 && attempt >= o.maxAttempts {
+			return errors.WithMessagef(err, "giving up after %d attempts", attempt)
+		}
+
+		// #nosec G404 - jitter does not need to be cryptographically secure
+		wait := interval/2 + time.Duration(rand.Int63n(int64(interval/2)+1))
+		if o.maxElapsedTime > 0 && time.Since(start)+wait > o.maxElapsedTime {
+			return errors.WithMessagef(err, "giving up after %d attempts because the retry budget of %s is exhausted", attempt, o.maxElapsedTime)
+		}
+
+		if o.notify != nil {
+			o.notify(err, attempt, wait)
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return errors.WithStack(ctx.Err())
+		case <-t.C:
+		}
+
+		interval *= 2
+		if interval > o.maxInterval {
+			interval = o.maxInterval
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package resilience
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	retryable := errors.New("retryable")
+	fast := []BackoffOption{WithInitialInterval(time.Millisecond), WithMaxInterval(2 * time.Millisecond)}
+
+	t.Run("case=retries until success", func(t *testing.T) {
+		var calls, notified int
+		require.NoError(t, RetryWithBackoff(ctx, func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return retryable
+			}
+			return nil
+		}, append(fast, WithNotify(func(err error, attempt int, wait time.Duration) {
+			notified++
+			assert.Equal(t, retryable, err)
+			assert.Equal(t, notified, attempt)
+			assert.True(t, wait <= 2*time.Millisecond)
+		}))...))
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 2, notified)
+	})
+
+	t.Run("case=does not retry other errors", func(t *testing.T) {
+		var calls int
+		expected := errors.New("permanent")
+		assert.Equal(t, expected, RetryWithBackoff(ctx, func(context.Context) error {
+			calls++
+			return expected
+		}, append(fast, WithRetryable(func(err error) bool { return err == retryable }))...))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("case=gives up after max attempts", func(t *testing.T) {
+		var calls int
+		err := RetryWithBackoff(ctx, func(context.Context) error {
+			calls++
+			return retryable
+		}, append(fast, WithMaxAttempts(4))...)
+		assert.ErrorIs(t, err, retryable)
+		assert.Contains(t, err.Error(), "giving up after 4 attempts")
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("case=gives up when the elapsed time is exceeded", func(t *testing.T) {
+		err := RetryWithBackoff(ctx, func(context.Context) error {
+			return retryable
+		}, WithInitialInterval(50*time.Millisecond), WithMaxElapsedTime(10*time.Millisecond))
+		assert.ErrorIs(t, err, retryable)
+		assert.Contains(t, err.Error(), "budget")
+	})
+
+	t.Run("case=respects context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		err := RetryWithBackoff(ctx, func(context.Context) error {
+			return retryable
+		}, fast...)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -42,7 +42,7 @@ func cleanURLQuery(in url.Values) (out url.Values) {
 
 // GetDriverName returns the driver name of a given DSN.
 func GetDriverName(dsn string) string {
-	return strings.Split(dsn, "://")[0]
+	return strings.TrimPrefix(strings.Split(dsn, "://")[0], cloudSQLPrefix)
 }
 
 func classifyDSN(dsn string) string {
@@ -3,6 +3,7 @@ package sqlcon
 import (
 	"database/sql"
 	"net/http"
+	"strings"
 
 	"google.golang.org/grpc/codes"
 
@@ -36,18 +37,46 @@ var (
 		StatusField:   http.StatusText(http.StatusBadRequest),
 		ErrorField:    "Unable to serialize access due to a concurrent update in another session",
 	}
+	// ErrNoSuchTable is returned when a SQL statement references a table which does not exist.
 	ErrNoSuchTable = &herodot.DefaultError{
 		CodeField:     http.StatusInternalServerError,
 		GRPCCodeField: codes.Internal,
 		StatusField:   http.StatusText(http.StatusInternalServerError),
 		ErrorField:    "Unable to locate the table",
 	}
+	// ErrForeignKeyViolation is returned when a SQL INSERT / UPDATE / DELETE command violates a foreign key constraint.
+	ErrForeignKeyViolation = &herodot.DefaultError{
+		CodeField:     http.StatusConflict,
+		GRPCCodeField: codes.FailedPrecondition,
+		StatusField:   http.StatusText(http.StatusConflict),
+		ErrorField:    "Unable to insert, update, or delete resource because a related resource does not exist or is still referenced",
+	}
+	// ErrNotNullViolation is returned when a SQL INSERT / UPDATE command omits a required value.
+	ErrNotNullViolation = &herodot.DefaultError{
+		CodeField:     http.StatusBadRequest,
+		GRPCCodeField: codes.InvalidArgument,
+		StatusField:   http.StatusText(http.StatusBadRequest),
+		ErrorField:    "Unable to insert or update resource because a required value is missing",
+	}
+	// ErrCheckViolation is returned when a SQL INSERT / UPDATE command violates a check constraint.
+	ErrCheckViolation = &herodot.DefaultError{
+		CodeField:     http.StatusBadRequest,
+		GRPCCodeField: codes.InvalidArgument,
+		StatusField:   http.StatusText(http.StatusBadRequest),
+		ErrorField:    "Unable to insert or update resource because a value violates a check constraint",
+	}
 )
 
-func handlePostgres(err error, sqlState string) error {
+func handlePostgres(err error, sqlState string, ce *ConstraintError) error {
 	switch sqlState {
 	case "23505": // "unique_violation"
-		return errors.Wrap(ErrUniqueViolation, err.Error())
+		return constraintViolation(ErrUniqueViolation, ce)
+	case "23503": // "foreign_key_violation"
+		return constraintViolation(ErrForeignKeyViolation, ce)
+	case "23502": // "not_null_violation"
+		return constraintViolation(ErrNotNullViolation, ce)
+	case "23514": // "check_violation"
+		return constraintViolation(ErrCheckViolation, ce)
 	case "40001": // "serialization_failure"
 		return errors.Wrap(ErrConcurrentUpdate, err.Error())
 	case "42P01": // "no such table"
@@ -56,10 +85,64 @@ func handlePostgres(err error, sqlState string) error {
 	return errors.WithStack(err)
 }
 
-type stater interface {
-	SQLState() string
+func handleMySQL(err error, e *mysql.MySQLError) error {
+	switch e.Number {
+	case 1062: // ER_DUP_ENTRY
+		return constraintViolation(ErrUniqueViolation, mysqlConstraintError(err, e.Number, e.Message))
+	case 1216, 1217, 1451, 1452: // ER_NO_REFERENCED_ROW, ER_ROW_IS_REFERENCED, ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+		return constraintViolation(ErrForeignKeyViolation, mysqlConstraintError(err, e.Number, e.Message))
+	case 1048, 1364: // ER_BAD_NULL_ERROR, ER_NO_DEFAULT_FOR_FIELD
+		return constraintViolation(ErrNotNullViolation, mysqlConstraintError(err, e.Number, e.Message))
+	case 3819: // ER_CHECK_CONSTRAINT_VIOLATED
+		return constraintViolation(ErrCheckViolation, mysqlConstraintError(err, e.Number, e.Message))
+	case 1146: // ER_NO_SUCH_TABLE
+		return errors.Wrap(ErrNoSuchTable, e.Error())
+	}
+	return errors.WithStack(err)
 }
 
+// Extended SQLite result codes, see https://www.sqlite.org/rescode.html
+const (
+	sqliteError                = 1
+	sqliteConstraintCheck      = 275
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintNotNull    = 1299
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+	sqliteConstraintRowID      = 2579
+)
+
+// handleSqliteCode maps an (extended) SQLite result code to a sqlcon error. It returns nil
+// if the code is not known.
+func handleSqliteCode(err error, code int) error {
+	switch code {
+	case sqliteConstraintUnique, sqliteConstraintPrimaryKey, sqliteConstraintRowID:
+		return constraintViolation(ErrUniqueViolation, sqliteConstraintError(err))
+	case sqliteConstraintForeignKey:
+		return constraintViolation(ErrForeignKeyViolation, sqliteConstraintError(err))
+	case sqliteConstraintNotNull:
+		return constraintViolation(ErrNotNullViolation, sqliteConstraintError(err))
+	case sqliteConstraintCheck:
+		return constraintViolation(ErrCheckViolation, sqliteConstraintError(err))
+	case sqliteError:
+		if strings.Contains(err.Error(), "no such table") {
+			return errors.Wrap(ErrNoSuchTable, err.Error())
+		}
+	}
+	return nil
+}
+
+type (
+	stater interface {
+		SQLState() string
+	}
+	// sqliteCoder is implemented by errors of SQLite drivers such as modernc.org/sqlite
+	// which return the extended result code.
+	sqliteCoder interface {
+		Code() int
+	}
+)
+
 // HandleError returns the right sqlcon.Err* depending on the input error.
 func HandleError(err error) error {
 	if err == nil {
@@ -67,26 +150,28 @@ func HandleError(err error) error {
 	}
 
 	var st stater
+	var sc sqliteCoder
 	if errors.Is(err, sql.ErrNoRows) {
 		return errors.WithStack(ErrNoRows)
-	} else if errors.As(err, &st) {
-		return handlePostgres(err, st.SQLState())
-	} else if e := new(pq.Error); errors.As(err, &e) {
-		return handlePostgres(err, string(e.Code))
 	} else if e := new(pgconn.PgError); errors.As(err, &e) {
-		return handlePostgres(err, e.Code)
+		return handlePostgres(err, e.Code, postgresConstraintError(err, e.ConstraintName, e.TableName, e.ColumnName, e.Detail))
+	} else if e := new(pq.Error); errors.As(err, &e) {
+		return handlePostgres(err, string(e.Code), postgresConstraintError(err, e.Constraint, e.Table, e.Column, e.Detail))
+	} else if errors.As(err, &st) {
+		return handlePostgres(err, st.SQLState(), &ConstraintError{err: err})
 	} else if e := new(mysql.MySQLError); errors.As(err, &e) {
-		switch e.Number {
-		case 1062:
-			return errors.Wrap(ErrUniqueViolation, err.Error())
-		case 1146:
-			return errors.Wrap(ErrNoSuchTable, e.Error())
-		}
+		return handleMySQL(err, e)
 	}
 
 	if err := handleSqlite(err); err != nil {
 		return err
 	}
 
+	if errors.As(err, &sc) {
+		if err := handleSqliteCode(err, sc.Code()); err != nil {
+			return err
+		}
+	}
+
 	return errors.WithStack(err)
 }
@@ -0,0 +1,160 @@
+package sqlcon
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+)
+
+// ConstraintError describes a violated unique, foreign key, not null, or check constraint. It is
+// wrapped by the errors HandleError returns for constraint violations, and wraps the original
+// driver error:
+//
+//	var ce *sqlcon.ConstraintError
+//	if errors.Is(err, sqlcon.ErrUniqueViolation) && errors.As(err, &ce) && ce.HasColumn("email") {
+//		// The email address is already taken.
+//	}
+//
+// All fields are best-effort and empty if the driver does not expose the information.
+type ConstraintError struct {
+	// Constraint is the name of the violated constraint or index.
+	Constraint string
+	// Table is the name of the table the constraint belongs to.
+	Table string
+	// Columns are the names of the columns which violated the constraint.
+	Columns []string
+
+	err error
+}
+
+var (
+	postgresKeyDetail    = regexp.MustCompile(`^Key \((.+?)\)=`)
+	mysqlDuplicateKey    = regexp.MustCompile("for key '([^']+)'")
+	mysqlForeignKey      = regexp.MustCompile("`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(([^)]+)\\)")
+	mysqlColumn          = regexp.MustCompile("^(?:Column|Field) '([^']+)'")
+	mysqlCheckConstraint = regexp.MustCompile("[Cc]heck constraint '([^']+)'")
+	sqliteColumns        = regexp.MustCompile(`(?:UNIQUE|NOT NULL) constraint failed: (.+)$`)
+	sqliteCheckFailed    = regexp.MustCompile(`CHECK constraint failed: (\S+)`)
+)
+
+func (e *ConstraintError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.err
+}
+
+// HasColumn returns true if the column is among the columns which violated the constraint.
+func (e *ConstraintError) HasColumn(column string) bool {
+	for _, c := range e.Columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintViolationError is returned for constraint violations. errors.Cause returns the sqlcon
+// error, e.g. ErrUniqueViolation, while errors.As finds a copy of it with the constraint information
+// added to its details, and the ConstraintError.
+type constraintViolationError struct {
+	base     *herodot.DefaultError
+	detailed *herodot.DefaultError
+}
+
+func (e *constraintViolationError) Error() string {
+	return e.detailed.Error()
+}
+
+func (e *constraintViolationError) Cause() error {
+	return e.base
+}
+
+func (e *constraintViolationError) Unwrap() error {
+	return e.detailed
+}
+
+// constraintViolation wraps the constraint error with the given sqlcon error, adding the
+// constraint information to its details.
+func constraintViolation(base *herodot.DefaultError, ce *ConstraintError) error {
+	de := base.WithWrap(ce)
+	if ce.Constraint != "" {
+		de = de.WithDetail("constraint", ce.Constraint)
+	}
+	if ce.Table != "" {
+		de = de.WithDetail("table", ce.Table)
+	}
+	if len(ce.Columns) > 0 {
+		de = de.WithDetail("columns", ce.Columns)
+	}
+	return errors.Wrap(&constraintViolationError{base: base, detailed: de}, ce.err.Error())
+}
+
+func splitColumns(columns string, quote string) (result []string) {
+	for _, c := range strings.Split(columns, ",") {
+		if c = strings.Trim(strings.TrimSpace(c), quote); c != "" {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// postgresConstraintError works for PostgreSQL and CockroachDB. Unique violations only report
+// the columns in the error detail, e.g. `Key (email)=(foo@bar.com) already exists.`
+func postgresConstraintError(err error, constraint, table, column, detail string) *ConstraintError {
+	ce := &ConstraintError{Constraint: constraint, Table: table, err: err}
+	if column != "" {
+		ce.Columns = []string{column}
+	} else if m := postgresKeyDetail.FindStringSubmatch(detail); len(m) == 2 {
+		ce.Columns = splitColumns(m[1], `"`)
+	}
+	return ce
+}
+
+func mysqlConstraintError(err error, number uint16, message string) *ConstraintError {
+	ce := &ConstraintError{err: err}
+	switch number {
+	case 1062:
+		if m := mysqlDuplicateKey.FindStringSubmatch(message); len(m) == 2 {
+			// MySQL 8 prefixes the index name with the table name.
+			if idx := strings.LastIndex(m[1], "."); idx >= 0 {
+				ce.Table, ce.Constraint = m[1][:idx], m[1][idx+1:]
+			} else {
+				ce.Constraint = m[1]
+			}
+		}
+	case 1216, 1217, 1451, 1452:
+		if m := mysqlForeignKey.FindStringSubmatch(message); len(m) == 4 {
+			ce.Table, ce.Constraint, ce.Columns = m[1], m[2], splitColumns(m[3], "`")
+		}
+	case 1048, 1364:
+		if m := mysqlColumn.FindStringSubmatch(message); len(m) == 2 {
+			ce.Columns = []string{m[1]}
+		}
+	case 3819:
+		if m := mysqlCheckConstraint.FindStringSubmatch(message); len(m) == 2 {
+			ce.Constraint = m[1]
+		}
+	}
+	return ce
+}
+
+// sqliteConstraintError parses messages such as `UNIQUE constraint failed: users.email, users.nid`.
+func sqliteConstraintError(err error) *ConstraintError {
+	ce := &ConstraintError{err: err}
+	if m := sqliteCheckFailed.FindStringSubmatch(err.Error()); len(m) == 2 {
+		ce.Constraint = m[1]
+	} else if m := sqliteColumns.FindStringSubmatch(err.Error()); len(m) == 2 {
+		for _, c := range splitColumns(m[1], "") {
+			if idx := strings.Index(c, "."); idx >= 0 {
+				ce.Table, c = c[:idx], c[idx+1:]
+			}
+			ce.Columns = append(ce.Columns, c)
+		}
+	}
+	return ce
+}
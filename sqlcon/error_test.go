@@ -0,0 +1,139 @@
+package sqlcon
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/herodot"
+)
+
+type fakeSqliteError struct {
+	code int
+	msg  string
+}
+
+func (e *fakeSqliteError) Error() string { return e.msg }
+func (e *fakeSqliteError) Code() int     { return e.code }
+
+func TestHandleError(t *testing.T) {
+	for k, tc := range []struct {
+		err        error
+		expected   error
+		constraint string
+	}{
+		{err: sql.ErrNoRows, expected: ErrNoRows},
+		{err: &pgconn.PgError{Code: "23505", ConstraintName: "users_email_idx"}, expected: ErrUniqueViolation, constraint: "users_email_idx"},
+		{err: &pgconn.PgError{Code: "23503", ConstraintName: "users_org_fk"}, expected: ErrForeignKeyViolation, constraint: "users_org_fk"},
+		{err: &pgconn.PgError{Code: "23502"}, expected: ErrNotNullViolation},
+		{err: &pgconn.PgError{Code: "23514", ConstraintName: "users_age_check"}, expected: ErrCheckViolation, constraint: "users_age_check"},
+		{err: &pgconn.PgError{Code: "40001"}, expected: ErrConcurrentUpdate},
+		{err: &pq.Error{Code: "23505", Constraint: "users_email_idx"}, expected: ErrUniqueViolation, constraint: "users_email_idx"},
+		{err: &pq.Error{Code: "23503"}, expected: ErrForeignKeyViolation},
+		{err: &pq.Error{Code: "42P01"}, expected: ErrNoSuchTable},
+		{
+			err:        &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'foo@bar.com' for key 'users.users_email_idx'"},
+			expected:   ErrUniqueViolation,
+			constraint: "users_email_idx",
+		},
+		{
+			err:        &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'foo@bar.com' for key 'users_email_idx'"},
+			expected:   ErrUniqueViolation,
+			constraint: "users_email_idx",
+		},
+		{
+			err:        &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`db`.`users`, CONSTRAINT `users_org_fk` FOREIGN KEY (`org_id`) REFERENCES `orgs` (`id`))"},
+			expected:   ErrForeignKeyViolation,
+			constraint: "users_org_fk",
+		},
+		{err: &mysql.MySQLError{Number: 1048, Message: "Column 'email' cannot be null"}, expected: ErrNotNullViolation},
+		{err: &mysql.MySQLError{Number: 3819, Message: "Check constraint 'users_age_check' is violated."}, expected: ErrCheckViolation, constraint: "users_age_check"},
+		{err: &mysql.MySQLError{Number: 1146}, expected: ErrNoSuchTable},
+		{err: &fakeSqliteError{code: 2067, msg: "UNIQUE constraint failed: users.email"}, expected: ErrUniqueViolation},
+		{err: &fakeSqliteError{code: 787, msg: "FOREIGN KEY constraint failed"}, expected: ErrForeignKeyViolation},
+		{err: &fakeSqliteError{code: 1299, msg: "NOT NULL constraint failed: users.email"}, expected: ErrNotNullViolation},
+		{err: &fakeSqliteError{code: 275, msg: "CHECK constraint failed: users_age_check"}, expected: ErrCheckViolation, constraint: "users_age_check"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := HandleError(errors.WithStack(tc.err))
+			assert.ErrorIs(t, err, tc.expected)
+			assert.True(t, errors.Cause(err) == tc.expected, "%+v", errors.Cause(err))
+
+			var de *herodot.DefaultError
+			if assert.True(t, errors.As(err, &de)) {
+				if tc.constraint == "" {
+					assert.Nil(t, de.Details()["constraint"])
+				} else {
+					assert.Equal(t, tc.constraint, de.Details()["constraint"])
+				}
+			}
+		})
+	}
+
+	t.Run("case=passes unknown errors through", func(t *testing.T) {
+		expected := errors.New("foo")
+		assert.ErrorIs(t, HandleError(expected), expected)
+		assert.NoError(t, HandleError(nil))
+	})
+}
+
+func TestConstraintError(t *testing.T) {
+	for k, tc := range []struct {
+		err      error
+		expected ConstraintError
+	}{
+		{
+			err:      &pgconn.PgError{Code: "23505", ConstraintName: "users_email_idx", TableName: "users", Detail: `Key (nid, email)=(foo, foo@bar.com) already exists.`},
+			expected: ConstraintError{Constraint: "users_email_idx", Table: "users", Columns: []string{"nid", "email"}},
+		},
+		{
+			err:      &pgconn.PgError{Code: "23502", TableName: "users", ColumnName: "email"},
+			expected: ConstraintError{Table: "users", Columns: []string{"email"}},
+		},
+		{
+			err:      &pq.Error{Code: "23505", Constraint: "users_email_idx", Table: "users", Detail: `Key ("email")=(foo@bar.com) already exists.`},
+			expected: ConstraintError{Constraint: "users_email_idx", Table: "users", Columns: []string{"email"}},
+		},
+		{
+			err:      &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'foo@bar.com' for key 'users.users_email_idx'"},
+			expected: ConstraintError{Constraint: "users_email_idx", Table: "users"},
+		},
+		{
+			err:      &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`db`.`users`, CONSTRAINT `users_org_fk` FOREIGN KEY (`org_id`, `nid`) REFERENCES `orgs` (`id`, `nid`))"},
+			expected: ConstraintError{Constraint: "users_org_fk", Table: "users", Columns: []string{"org_id", "nid"}},
+		},
+		{
+			err:      &mysql.MySQLError{Number: 1364, Message: "Field 'email' doesn't have a default value"},
+			expected: ConstraintError{Columns: []string{"email"}},
+		},
+		{
+			err:      &fakeSqliteError{code: 2067, msg: "UNIQUE constraint failed: users.nid, users.email"},
+			expected: ConstraintError{Table: "users", Columns: []string{"nid", "email"}},
+		},
+		{
+			err:      &fakeSqliteError{code: 275, msg: "CHECK constraint failed: users_age_check"},
+			expected: ConstraintError{Constraint: "users_age_check"},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := HandleError(tc.err)
+
+			var ce *ConstraintError
+			require.True(t, errors.As(err, &ce))
+			assert.Equal(t, tc.expected.Constraint, ce.Constraint)
+			assert.Equal(t, tc.expected.Table, ce.Table)
+			assert.Equal(t, tc.expected.Columns, ce.Columns)
+			for _, c := range tc.expected.Columns {
+				assert.True(t, ce.HasColumn(c))
+			}
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Package keysetpagination implements cursor based pagination for SQL queries.
+//
+// In contrast to offset pagination, keyset pagination filters on the sort key of the last item
+// of the previous page, which allows the database to use an index instead of scanning and
+// discarding all skipped rows:
+//
+//	SELECT * FROM items WHERE (created_at, id) < (?, ?) ORDER BY created_at DESC, id DESC LIMIT 101
+//
+// The sort key values are transported in opaque page tokens. The sort columns must be NOT NULL
+// and together uniquely identify a row, which is usually achieved by adding the primary key as
+// the last column.
+package keysetpagination
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/pagination"
+)
+
+const (
+	// DefaultSize is the page size used if none is requested.
+	DefaultSize = 100
+	// DefaultMaxSize is the largest page size a client may request unless configured otherwise.
+	DefaultMaxSize = 1000
+)
+
+var columnName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+type (
+	// Order is the sort direction of a column.
+	Order string
+
+	// Column is a sort column.
+	Column struct {
+		Name  string
+		Order Order
+	}
+
+	// Paginator builds the query for a single page and the token of the next page.
+	Paginator struct {
+		columns []Column
+		order   string
+		size    int
+		maxSize int
+		token   string
+		values  []interface{}
+	}
+
+	// Option configures a Paginator.
+	Option func(*Paginator)
+)
+
+const (
+	OrderAscending  Order = "ASC"
+	OrderDescending Order = "DESC"
+)
+
+// WithSize sets the page size. Values smaller than one or larger than the maximum page size are
+// capped.
+func WithSize(size int) Option {
+	return func(p *Paginator) {
+		p.size = size
+	}
+}
+
+// WithMaxSize sets the maximum page size. Defaults to DefaultMaxSize.
+func WithMaxSize(size int) Option {
+	return func(p *Paginator) {
+		p.maxSize = size
+	}
+}
+
+// WithToken sets the page token returned by NextPageToken for the previous page. An empty token
+// requests the first page.
+func WithToken(token string) Option {
+	return func(p *Paginator) {
+		p.token = token
+	}
+}
+
+// ParseQuery returns the options encoded in the `page_token` and `page_size` query parameters.
+func ParseQuery(r *http.Request) []Option {
+	q := r.URL.Query()
+	opts := []Option{WithToken(q.Get("page_token"))}
+	if size, err := strconv.Atoi(q.Get("page_size")); err == nil {
+		opts = append(opts, WithSize(size))
+	}
+	return opts
+}
+
+// New returns a paginator sorting by the given columns. It returns ErrInvalidToken if the page
+// token can not be decoded or was issued for a different sort order.
+func New(columns []Column, opts ...Option) (*Paginator, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("at least one sort column is required")
+	}
+
+	columns = append([]Column(nil), columns...)
+	order := make([]string, len(columns))
+	for k, c := range columns {
+		if !columnName.MatchString(c.Name) {
+			return nil, errors.Errorf("invalid sort column name %q", c.Name)
+		}
+		switch c.Order {
+		case OrderAscending, OrderDescending:
+		case "":
+			columns[k].Order = OrderAscending
+		default:
+			return nil, errors.Errorf("invalid sort order %q for column %s", c.Order, c.Name)
+		}
+		order[k] = fmt.Sprintf("%s %s", c.Name, columns[k].Order)
+	}
+
+	p := &Paginator{columns: columns, order: strings.Join(order, ", "), size: DefaultSize, maxSize: DefaultMaxSize}
+	for _, o := range opts {
+		o(p)
+	}
+
+	if p.size < 1 {
+		p.size = 1
+	} else if p.size > p.maxSize {
+		p.size = p.maxSize
+	}
+
+	if p.token != "" {
+		values, err := decodeToken(p.token, p.order, len(p.columns))
+		if err != nil {
+			return nil, err
+		}
+		p.values = values
+	}
+
+	return p, nil
+}
+
+// Size returns the page size.
+func (p *Paginator) Size() int {
+	return p.size
+}
+
+// IsFirstPage returns true if no page token was given.
+func (p *Paginator) IsFirstPage() bool {
+	return p.values == nil
+}
+
+// Where returns the condition selecting the rows after the previous page, or an empty string
+// for the first page. If all columns share the same sort order, a row value comparison is used.
+func (p *Paginator) Where() (clause string, args []interface{}) {
+	if p.values == nil {
+		return "", nil
+	}
+
+	if len(p.columns) == 1 {
+		return fmt.Sprintf("%s %s ?", p.columns[0].Name, comparator(p.columns[0].Order)), p.values
+	}
+
+	sameOrder := true
+	for _, c := range p.columns[1:] {
+		sameOrder = sameOrder && c.Order == p.columns[0].Order
+	}
+
+	if sameOrder {
+		names := make([]string, len(p.columns))
+		for k, c := range p.columns {
+			names[k] = c.Name
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(p.columns)), ", ")
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), comparator(p.columns[0].Order), placeholders), p.values
+	}
+
+	// (a > ?) OR (a = ? AND b < ?) OR ...
+	ors := make([]string, len(p.columns))
+	for k, c := range p.columns {
+		ands := make([]string, 0, k+1)
+		for _, prev := range p.columns[:k] {
+			ands = append(ands, fmt.Sprintf("%s = ?", prev.Name))
+		}
+		ands = append(ands, fmt.Sprintf("%s %s ?", c.Name, comparator(c.Order)))
+		args = append(args, p.values[:k+1]...)
+		ors[k] = fmt.Sprintf("(%s)", strings.Join(ands, " AND "))
+	}
+	return fmt.Sprintf("(%s)", strings.Join(ors, " OR ")), args
+}
+
+func comparator(o Order) string {
+	if o == OrderDescending {
+		return "<"
+	}
+	return ">"
+}
+
+// OrderBy returns the ORDER BY expression, e.g. "created_at DESC, id DESC".
+func (p *Paginator) OrderBy() string {
+	return p.order
+}
+
+// Limit returns the number of rows to fetch, which is one more than the page size to detect
+// whether a next page exists.
+func (p *Paginator) Limit() int {
+	return p.size + 1
+}
+
+// Scope applies the condition, order, and limit to a pop query:
+//
+//	err := c.Scope(paginator.Scope()).All(&items)
+func (p *Paginator) Scope() pop.ScopeFunc {
+	return func(q *pop.Query) *pop.Query {
+		if where, args := p.Where(); where != "" {
+			q = q.Where(where, args...)
+		}
+		return q.Order(p.order).Limit(p.Limit())
+	}
+}
+
+// Trim removes the extra row fetched because of Limit from items, which must be a pointer to a
+// slice. It returns true if there is a next page.
+func (p *Paginator) Trim(items interface{}) (hasNext bool) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		panic(fmt.Sprintf("keysetpagination: Trim expects a pointer to a slice but got %T", items))
+	}
+
+	v = v.Elem()
+	if v.Len() <= p.size {
+		return false
+	}
+	v.Set(v.Slice(0, p.size))
+	return true
+}
+
+// NextPageToken returns the token for the page following the item with the given sort key values.
+// The values must be given in the order of the sort columns.
+func (p *Paginator) NextPageToken(values ...interface{}) (string, error) {
+	if len(values) != len(p.columns) {
+		return "", errors.Errorf("expected %d sort key values but got %d", len(p.columns), len(values))
+	}
+	return encodeToken(p.order, values)
+}
+
+// Header sets the Link header pointing to the next page. It does nothing if next is empty.
+func Header(w http.ResponseWriter, u *url.URL, next string, size int) {
+	if next == "" {
+		return
+	}
+
+	w.Header().Set("Link", pagination.Link(u, "next", map[string]string{
+		"page_token": next,
+		"page_size":  strconv.Itoa(size),
+	}))
+}
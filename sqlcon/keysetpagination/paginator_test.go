@@ -0,0 +1,142 @@
+package keysetpagination
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginator(t *testing.T) {
+	columns := []Column{{Name: "created_at", Order: OrderDescending}, {Name: "id", Order: OrderDescending}}
+
+	t.Run("case=first page", func(t *testing.T) {
+		p, err := New(columns, WithSize(2))
+		require.NoError(t, err)
+
+		assert.True(t, p.IsFirstPage())
+		where, args := p.Where()
+		assert.Empty(t, where)
+		assert.Empty(t, args)
+		assert.Equal(t, "created_at DESC, id DESC", p.OrderBy())
+		assert.Equal(t, 3, p.Limit())
+	})
+
+	t.Run("case=next page", func(t *testing.T) {
+		first, err := New(columns, WithSize(2))
+		require.NoError(t, err)
+
+		items := []int{1, 2, 3}
+		require.True(t, first.Trim(&items))
+		assert.Equal(t, []int{1, 2}, items)
+
+		now := time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)
+		token, err := first.NextPageToken(now, int64(2))
+		require.NoError(t, err)
+
+		p, err := New(columns, WithSize(2), WithToken(token))
+		require.NoError(t, err)
+
+		assert.False(t, p.IsFirstPage())
+		where, args := p.Where()
+		assert.Equal(t, "(created_at, id) < (?, ?)", where)
+		assert.Equal(t, []interface{}{now, int64(2)}, args)
+
+		items = []int{3}
+		assert.False(t, p.Trim(&items))
+		assert.Equal(t, []int{3}, items)
+	})
+
+	t.Run("case=mixed sort order", func(t *testing.T) {
+		mixed := []Column{{Name: "name"}, {Name: "created_at", Order: OrderDescending}, {Name: "id"}}
+		first, err := New(mixed)
+		require.NoError(t, err)
+		token, err := first.NextPageToken("foo", time.Unix(0, 0).UTC(), "some-id")
+		require.NoError(t, err)
+
+		p, err := New(mixed, WithToken(token))
+		require.NoError(t, err)
+		where, args := p.Where()
+		assert.Equal(t, "((name > ?) OR (name = ? AND created_at < ?) OR (name = ? AND created_at = ? AND id > ?))", where)
+		assert.Len(t, args, 6)
+	})
+
+	t.Run("case=single column", func(t *testing.T) {
+		single := []Column{{Name: "id"}}
+		first, err := New(single)
+		require.NoError(t, err)
+		token, err := first.NextPageToken(uint(10))
+		require.NoError(t, err)
+
+		p, err := New(single, WithToken(token))
+		require.NoError(t, err)
+		where, args := p.Where()
+		assert.Equal(t, "id > ?", where)
+		assert.Equal(t, []interface{}{uint64(10)}, args)
+	})
+
+	t.Run("case=rejects tokens for other sort orders", func(t *testing.T) {
+		first, err := New([]Column{{Name: "id"}})
+		require.NoError(t, err)
+		token, err := first.NextPageToken("foo")
+		require.NoError(t, err)
+
+		_, err = New(columns, WithToken(token))
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+
+		_, err = New(columns, WithToken("not-a-token"))
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+
+	t.Run("case=rejects invalid columns", func(t *testing.T) {
+		for _, c := range [][]Column{
+			nil,
+			{{Name: "id; DROP TABLE users"}},
+			{{Name: "id", Order: "sideways"}},
+		} {
+			_, err := New(c)
+			assert.Error(t, err, "%+v", c)
+		}
+	})
+
+	t.Run("case=caps page size", func(t *testing.T) {
+		p, err := New(columns, WithSize(5000))
+		require.NoError(t, err)
+		assert.Equal(t, DefaultMaxSize, p.Size())
+
+		p, err = New(columns, WithSize(50), WithMaxSize(10))
+		require.NoError(t, err)
+		assert.Equal(t, 10, p.Size())
+
+		p, err = New(columns, WithSize(-1))
+		require.NoError(t, err)
+		assert.Equal(t, 1, p.Size())
+	})
+}
+
+func TestParseQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/items?page_size=10&page_token=abc", nil)
+	p := &Paginator{}
+	for _, o := range ParseQuery(r) {
+		o(p)
+	}
+	assert.Equal(t, 10, p.size)
+	assert.Equal(t, "abc", p.token)
+}
+
+func TestHeader(t *testing.T) {
+	u, err := url.Parse("https://example.com/items?foo=bar")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	Header(w, u, "", 10)
+	assert.Empty(t, w.Header().Get("Link"))
+
+	Header(w, u, "abc", 10)
+	assert.Equal(t, `<https://example.com/items?foo=bar&page_size=10&page_token=abc>; rel="next"`, w.Header().Get("Link"))
+	assert.Equal(t, "foo=bar", u.RawQuery)
+}
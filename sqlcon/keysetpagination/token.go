@@ -0,0 +1,149 @@
+package keysetpagination
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidToken is returned when a page token can not be decoded or was issued for a different sort order.
+var ErrInvalidToken = errors.New("the page token is invalid")
+
+type (
+	pageToken struct {
+		// Order is the sort order the token was issued for.
+		Order string `json:"o"`
+		// Values are the sort key values of the last item on the previous page.
+		Values []tokenValue `json:"v"`
+	}
+	tokenValue struct {
+		Type  string `json:"t"`
+		Value string `json:"v"`
+	}
+)
+
+const (
+	typeString = "s"
+	typeInt    = "i"
+	typeUint   = "u"
+	typeFloat  = "f"
+	typeBool   = "b"
+	typeTime   = "t"
+	typeBytes  = "x"
+)
+
+func encodeToken(order string, values []interface{}) (string, error) {
+	t := pageToken{Order: order, Values: make([]tokenValue, len(values))}
+	for k, v := range values {
+		tv, err := encodeValue(v)
+		if err != nil {
+			return "", err
+		}
+		t.Values[k] = tv
+	}
+
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeToken(token, order string, columns int) ([]interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+
+	var t pageToken
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+	if t.Order != order || len(t.Values) != columns {
+		return nil, errors.WithStack(ErrInvalidToken)
+	}
+
+	values := make([]interface{}, len(t.Values))
+	for k, tv := range t.Values {
+		v, err := tv.decode()
+		if err != nil {
+			return nil, errors.WithStack(ErrInvalidToken)
+		}
+		values[k] = v
+	}
+	return values, nil
+}
+
+func encodeValue(v interface{}) (tokenValue, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return tokenValue{}, errors.WithStack(err)
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return tokenValue{}, errors.New("sort key values must not be NULL")
+	case string:
+		return tokenValue{Type: typeString, Value: v}, nil
+	case []byte:
+		return tokenValue{Type: typeBytes, Value: base64.RawURLEncoding.EncodeToString(v)}, nil
+	case bool:
+		return tokenValue{Type: typeBool, Value: strconv.FormatBool(v)}, nil
+	case int:
+		return tokenValue{Type: typeInt, Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int8:
+		return tokenValue{Type: typeInt, Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int16:
+		return tokenValue{Type: typeInt, Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int32:
+		return tokenValue{Type: typeInt, Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int64:
+		return tokenValue{Type: typeInt, Value: strconv.FormatInt(v, 10)}, nil
+	case uint:
+		return tokenValue{Type: typeUint, Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint8:
+		return tokenValue{Type: typeUint, Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint16:
+		return tokenValue{Type: typeUint, Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint32:
+		return tokenValue{Type: typeUint, Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint64:
+		return tokenValue{Type: typeUint, Value: strconv.FormatUint(v, 10)}, nil
+	case float32:
+		return tokenValue{Type: typeFloat, Value: strconv.FormatFloat(float64(v), 'g', -1, 32)}, nil
+	case float64:
+		return tokenValue{Type: typeFloat, Value: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case time.Time:
+		return tokenValue{Type: typeTime, Value: v.Format(time.RFC3339Nano)}, nil
+	case fmt.Stringer:
+		return tokenValue{Type: typeString, Value: v.String()}, nil
+	}
+	return tokenValue{}, errors.Errorf("unable to encode sort key value of type %T in a page token", v)
+}
+
+func (tv tokenValue) decode() (interface{}, error) {
+	switch tv.Type {
+	case typeString:
+		return tv.Value, nil
+	case typeBytes:
+		return base64.RawURLEncoding.DecodeString(tv.Value)
+	case typeBool:
+		return strconv.ParseBool(tv.Value)
+	case typeInt:
+		return strconv.ParseInt(tv.Value, 10, 64)
+	case typeUint:
+		return strconv.ParseUint(tv.Value, 10, 64)
+	case typeFloat:
+		return strconv.ParseFloat(tv.Value, 64)
+	case typeTime:
+		return time.Parse(time.RFC3339Nano, tv.Value)
+	}
+	return nil, errors.Errorf("unknown page token value type %s", tv.Type)
+}
@@ -86,8 +86,16 @@ func ParseConnectionOptions(l *logrusx.Logger, dsn string) (maxConns int, maxIdl
 	return
 }
 
-// FinalizeDSN will return a finalized DSN URI.
+// FinalizeDSN will return a finalized DSN URI. Unix socket and Cloud SQL DSNs are
+// normalized using NormalizeDSN.
 func FinalizeDSN(l *logrusx.Logger, dsn string) string {
+	normalized, err := NormalizeDSN(dsn)
+	if err != nil {
+		l.WithError(err).Warnf("Unable to normalize SQL DSN, could not finalize the DSN URI.")
+		return dsn
+	}
+	dsn = normalized
+
 	if strings.HasPrefix(dsn, "mysql://") {
 		var q url.Values
 		parts := strings.SplitN(dsn, "?", 2)
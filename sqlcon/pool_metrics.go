@@ -0,0 +1,88 @@
+package sqlcon
+
+import (
+	"database/sql"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatsProvider is implemented by *sql.DB, *sqlx.DB, and the store of an open *pop.Connection.
+type StatsProvider interface {
+	Stats() sql.DBStats
+}
+
+type poolCollector struct {
+	db StatsProvider
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*poolCollector)(nil)
+
+// NewPoolCollector returns a Prometheus collector reporting the connection pool statistics of db.
+// All metrics are labeled with db_name, which allows registering one collector per pool.
+func NewPoolCollector(namespace, name string, db StatsProvider) prometheus.Collector {
+	labels := prometheus.Labels{"db_name": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "sql", metric), help, nil, labels)
+	}
+
+	return &poolCollector{
+		db:                db,
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:              desc("open_connections", "The number of established connections both in use and idle."),
+		inUse:             desc("in_use_connections", "The number of connections currently in use."),
+		idle:              desc("idle_connections", "The number of idle connections."),
+		waitCount:         desc("wait_count_total", "The total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "The total time blocked waiting for a new connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "The total number of connections closed due to max_idle_conns."),
+		maxIdleTimeClosed: desc("max_idle_time_closed_total", "The total number of connections closed due to max_conn_idle_time."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "The total number of connections closed due to max_conn_lifetime."),
+	}
+}
+
+// NewPopPoolCollector works like NewPoolCollector for an open *pop.Connection.
+func NewPopPoolCollector(namespace, name string, c *pop.Connection) (prometheus.Collector, error) {
+	db, ok := c.Store.(StatsProvider)
+	if !ok {
+		return nil, errors.Errorf("unable to collect connection pool statistics because the connection store of type %T does not expose them", c.Store)
+	}
+	return NewPoolCollector(namespace, name, db), nil
+}
+
+// Describe implements prometheus Collector interface.
+func (c *poolCollector) Describe(in chan<- *prometheus.Desc) {
+	in <- c.maxOpen
+	in <- c.open
+	in <- c.inUse
+	in <- c.idle
+	in <- c.waitCount
+	in <- c.waitDuration
+	in <- c.maxIdleClosed
+	in <- c.maxIdleTimeClosed
+	in <- c.maxLifetimeClosed
+}
+
+// Collect implements prometheus Collector interface.
+func (c *poolCollector) Collect(in chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+	in <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	in <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	in <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	in <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	in <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	in <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	in <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	in <- prometheus.MustNewConstMetric(c.maxIdleTimeClosed, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed))
+	in <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
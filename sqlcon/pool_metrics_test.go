@@ -0,0 +1,49 @@
+package sqlcon
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type staticStats sql.DBStats
+
+func (s staticStats) Stats() sql.DBStats {
+	return sql.DBStats(s)
+}
+
+func TestPoolCollector(t *testing.T) {
+	c := NewPoolCollector("test", "primary", staticStats{
+		MaxOpenConnections: 10,
+		OpenConnections:    4,
+		InUse:              3,
+		Idle:               1,
+		WaitCount:          7,
+		WaitDuration:       1500 * time.Millisecond,
+		MaxLifetimeClosed:  2,
+	})
+
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP test_sql_in_use_connections The number of connections currently in use.
+# TYPE test_sql_in_use_connections gauge
+test_sql_in_use_connections{db_name="primary"} 3
+# HELP test_sql_max_lifetime_closed_total The total number of connections closed due to max_conn_lifetime.
+# TYPE test_sql_max_lifetime_closed_total counter
+test_sql_max_lifetime_closed_total{db_name="primary"} 2
+# HELP test_sql_open_connections The number of established connections both in use and idle.
+# TYPE test_sql_open_connections gauge
+test_sql_open_connections{db_name="primary"} 4
+# HELP test_sql_wait_count_total The total number of connections waited for.
+# TYPE test_sql_wait_count_total counter
+test_sql_wait_count_total{db_name="primary"} 7
+# HELP test_sql_wait_duration_seconds_total The total time blocked waiting for a new connection.
+# TYPE test_sql_wait_duration_seconds_total counter
+test_sql_wait_duration_seconds_total{db_name="primary"} 1.5
+`), "test_sql_in_use_connections", "test_sql_max_lifetime_closed_total", "test_sql_open_connections", "test_sql_wait_count_total", "test_sql_wait_duration_seconds_total"))
+
+	require.Equal(t, 9, testutil.CollectAndCount(c))
+}
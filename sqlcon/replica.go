@@ -0,0 +1,371 @@
+package sqlcon
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/x/logrusx"
+)
+
+type (
+	// ReplicaSet routes read-only queries to read replicas and everything else to the primary.
+	//
+	// A query is read-only if it is a SELECT statement which neither locks rows (FOR UPDATE, FOR SHARE),
+	// writes (SELECT INTO, RETURNING), nor calls functions with side effects such as nextval. All other
+	// statements, and all queries inside transactions, use the primary.
+	//
+	// Reads are distributed round-robin across all healthy replicas. If no replica is healthy,
+	// reads fall back to the primary. Replicas lag behind the primary, so reads which must see
+	// data that was just written should use a context returned by WithPrimary, or configure a
+	// window after writes in which all reads use the primary (see WithReplicaReadAfterWriteWindow).
+	ReplicaSet struct {
+		primary   *pop.Connection
+		replicas  []*replica
+		next      uint64
+		lastWrite int64
+		l         *logrusx.Logger
+		interval  time.Duration
+		window    time.Duration
+		stop      chan struct{}
+		stopOnce  sync.Once
+		wg        sync.WaitGroup
+	}
+	// ReplicaSetOption configures a ReplicaSet.
+	ReplicaSetOption func(*ReplicaSet)
+
+	replica struct {
+		c       *pop.Connection
+		dsn     string
+		healthy int32
+	}
+
+	// popStore mirrors the method set of pop's unexported store interface.
+	popStore interface {
+		Select(interface{}, string, ...interface{}) error
+		Get(interface{}, string, ...interface{}) error
+		NamedExec(string, interface{}) (sql.Result, error)
+		Exec(string, ...interface{}) (sql.Result, error)
+		PrepareNamed(string) (*sqlx.NamedStmt, error)
+		Transaction() (*pop.Tx, error)
+		Rollback() error
+		Commit() error
+		Close() error
+
+		SelectContext(context.Context, interface{}, string, ...interface{}) error
+		GetContext(context.Context, interface{}, string, ...interface{}) error
+		NamedExecContext(context.Context, string, interface{}) (sql.Result, error)
+		ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+		PrepareNamedContext(context.Context, string) (*sqlx.NamedStmt, error)
+		TransactionContext(context.Context) (*pop.Tx, error)
+		TransactionContextOptions(context.Context, *sql.TxOptions) (*pop.Tx, error)
+	}
+
+	// routingStore sends read-only queries to the replica set and everything else to the primary store.
+	routingStore struct {
+		popStore
+		rs *ReplicaSet
+	}
+
+	primaryContextKey struct{}
+)
+
+var (
+	readOnlyStatement = regexp.MustCompile(`(?is)^\s*\(?\s*SELECT\b`)
+	// writingClause matches clauses and functions which make a SELECT statement lock rows or write.
+	// Matches inside string literals send the query to the primary, which is safe.
+	writingClause = regexp.MustCompile(`(?i)\b(FOR\s+(NO\s+KEY\s+)?UPDATE|FOR\s+(KEY\s+)?SHARE|LOCK\s+IN\s+SHARE\s+MODE|INTO|RETURNING|NEXTVAL|SETVAL|PG_ADVISORY_\w+|GET_LOCK|RELEASE_LOCK)\b`)
+)
+
+// WithReplicaHealthCheckInterval sets how often replicas are pinged. Defaults to five seconds.
+func WithReplicaHealthCheckInterval(d time.Duration) ReplicaSetOption {
+	return func(rs *ReplicaSet) {
+		rs.interval = d
+	}
+}
+
+// WithReplicaReadAfterWriteWindow sends all reads to the primary for the given duration after a
+// write, so that reads following a write see it even if the replicas lag behind. Defaults to zero.
+func WithReplicaReadAfterWriteWindow(d time.Duration) ReplicaSetOption {
+	return func(rs *ReplicaSet) {
+		rs.window = d
+	}
+}
+
+// WithPrimary returns a context which routes all reads made with it to the primary, for
+// example to read data which was just written:
+//
+//	err := c.WithContext(sqlcon.WithPrimary(ctx)).Find(&identity, id)
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryContextKey{}).(bool)
+	return v
+}
+
+// isReadOnly reports whether query can be sent to a replica.
+func isReadOnly(query string) bool {
+	return readOnlyStatement.MatchString(query) && !writingClause.MatchString(query)
+}
+
+// NewReplicaSet connects to the primary and all replica DSNs. The DSNs support the same
+// connection options as ParseConnectionOptions and ParseSlowQueryThreshold.
+//
+// Use Connection to retrieve a *pop.Connection which transparently routes queries.
+func NewReplicaSet(l *logrusx.Logger, primaryDSN string, replicaDSNs []string, opts ...ReplicaSetOption) (*ReplicaSet, error) {
+	primary, err := openPopConnection(l, primaryDSN)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to connect to primary %s", classifyDSN(primaryDSN))
+	}
+
+	rs := &ReplicaSet{
+		primary:  primary,
+		l:        l,
+		interval: 5 * time.Second,
+		stop:     make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(rs)
+	}
+
+	for _, dsn := range replicaDSNs {
+		c, err := openPopConnection(l, dsn)
+		if err != nil {
+			_ = rs.Close()
+			return nil, errors.WithMessagef(err, "unable to connect to replica %s", classifyDSN(dsn))
+		}
+		rs.replicas = append(rs.replicas, &replica{c: c, dsn: classifyDSN(dsn), healthy: 1})
+	}
+
+	primary.Store = &routingStore{popStore: primary.Store, rs: rs}
+
+	if len(rs.replicas) > 0 && rs.interval > 0 {
+		rs.wg.Add(1)
+		go rs.watch()
+	}
+
+	return rs, nil
+}
+
+func openPopConnection(l *logrusx.Logger, dsn string) (*pop.Connection, error) {
+	slowQueryThreshold, dsn := ParseSlowQueryThreshold(l, dsn)
+	maxConns, maxIdleConns, maxConnLifetime, maxIdleConnTime, cleanedDSN := ParseConnectionOptions(l, dsn)
+	details := &pop.ConnectionDetails{
+		URL:             FinalizeDSN(l, cleanedDSN),
+		Pool:            maxConns,
+		IdlePool:        maxIdleConns,
+		ConnMaxLifetime: maxConnLifetime,
+		ConnMaxIdleTime: maxIdleConnTime,
+	}
+	if slowQueryThreshold > 0 {
+		if err := WithSlowQueryLog(l, details, slowQueryThreshold); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := pop.NewConnection(details)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := c.Open(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return c, nil
+}
+
+// Connection returns the connection to the primary which routes read-only queries to the replicas.
+func (rs *ReplicaSet) Connection() *pop.Connection {
+	return rs.primary
+}
+
+// PoolCollectors returns one connection pool collector (see NewPoolCollector) for the primary,
+// labeled db_name="primary", and one for each replica, labeled db_name="replica_<index>".
+func (rs *ReplicaSet) PoolCollectors(namespace string) []prometheus.Collector {
+	collectors := []prometheus.Collector{NewPoolCollector(namespace, "primary", rs.primary.Store.(StatsProvider))}
+	for k, r := range rs.replicas {
+		if db, ok := r.c.Store.(StatsProvider); ok {
+			collectors = append(collectors, NewPoolCollector(namespace, fmt.Sprintf("replica_%d", k), db))
+		}
+	}
+	return collectors
+}
+
+// reader returns the store query should be sent to.
+func (rs *ReplicaSet) reader(ctx context.Context, primary popStore, query string) popStore {
+	if !isReadOnly(query) {
+		rs.wrote()
+		return primary
+	}
+	if usePrimary(ctx) || rs.recentlyWritten() {
+		return primary
+	}
+	if r := rs.healthyReplica(); r != nil {
+		return r.c.Store
+	}
+	return primary
+}
+
+func (rs *ReplicaSet) wrote() {
+	if rs.window > 0 {
+		atomic.StoreInt64(&rs.lastWrite, time.Now().UnixNano())
+	}
+}
+
+func (rs *ReplicaSet) recentlyWritten() bool {
+	if rs.window <= 0 {
+		return false
+	}
+	return time.Since(time.Unix(0, atomic.LoadInt64(&rs.lastWrite))) < rs.window
+}
+
+// healthyReplica returns the next healthy replica in round-robin order, or nil if there is none.
+func (rs *ReplicaSet) healthyReplica() *replica {
+	n := uint64(len(rs.replicas))
+	if n == 0 {
+		return nil
+	}
+
+	start := atomic.AddUint64(&rs.next, 1)
+	for i := uint64(0); i < n; i++ {
+		r := rs.replicas[(start+i)%n]
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			return r
+		}
+	}
+	return nil
+}
+
+func (rs *ReplicaSet) watch() {
+	defer rs.wg.Done()
+	ticker := time.NewTicker(rs.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+			rs.CheckHealth(context.Background())
+		}
+	}
+}
+
+// CheckHealth pings all replicas and updates their health status. It is called periodically
+// in the background and returns the number of healthy replicas.
+func (rs *ReplicaSet) CheckHealth(ctx context.Context) (healthy int) {
+	for _, r := range rs.replicas {
+		ctx, cancel := context.WithTimeout(ctx, rs.interval)
+		err := r.c.WithContext(ctx).RawQuery("SELECT 1").Exec()
+		cancel()
+
+		if err != nil {
+			if atomic.SwapInt32(&r.healthy, 0) == 1 {
+				rs.l.WithError(err).WithField("replica", r.dsn).Warn("Read replica became unhealthy and will not receive reads.")
+			}
+			continue
+		}
+
+		if atomic.SwapInt32(&r.healthy, 1) == 0 {
+			rs.l.WithField("replica", r.dsn).Info("Read replica is healthy again and receives reads.")
+		}
+		healthy++
+	}
+	return healthy
+}
+
+// Close stops the health checks and closes all connections.
+func (rs *ReplicaSet) Close() error {
+	rs.stopOnce.Do(func() { close(rs.stop) })
+	rs.wg.Wait()
+
+	var err error
+	for _, r := range rs.replicas {
+		if cerr := r.c.Close(); cerr != nil && err == nil {
+			err = errors.WithStack(cerr)
+		}
+	}
+	if cerr := rs.primary.Close(); cerr != nil && err == nil {
+		err = errors.WithStack(cerr)
+	}
+	return err
+}
+
+func (s *routingStore) Select(dest interface{}, query string, args ...interface{}) error {
+	return s.rs.reader(context.Background(), s.popStore, query).Select(dest, query, args...)
+}
+
+func (s *routingStore) Get(dest interface{}, query string, args ...interface{}) error {
+	return s.rs.reader(context.Background(), s.popStore, query).Get(dest, query, args...)
+}
+
+func (s *routingStore) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return s.rs.reader(ctx, s.popStore, query).SelectContext(ctx, dest, query, args...)
+}
+
+func (s *routingStore) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return s.rs.reader(ctx, s.popStore, query).GetContext(ctx, dest, query, args...)
+}
+
+func (s *routingStore) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	s.rs.wrote()
+	return s.popStore.NamedExec(query, arg)
+}
+
+func (s *routingStore) Exec(query string, args ...interface{}) (sql.Result, error) {
+	s.rs.wrote()
+	return s.popStore.Exec(query, args...)
+}
+
+func (s *routingStore) PrepareNamed(query string) (*sqlx.NamedStmt, error) {
+	s.rs.wrote()
+	return s.popStore.PrepareNamed(query)
+}
+
+func (s *routingStore) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	s.rs.wrote()
+	return s.popStore.NamedExecContext(ctx, query, arg)
+}
+
+func (s *routingStore) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	s.rs.wrote()
+	return s.popStore.ExecContext(ctx, query, args...)
+}
+
+func (s *routingStore) PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	s.rs.wrote()
+	return s.popStore.PrepareNamedContext(ctx, query)
+}
+
+func (s *routingStore) Transaction() (*pop.Tx, error) {
+	s.rs.wrote()
+	return s.popStore.Transaction()
+}
+
+func (s *routingStore) TransactionContext(ctx context.Context) (*pop.Tx, error) {
+	s.rs.wrote()
+	return s.popStore.TransactionContext(ctx)
+}
+
+func (s *routingStore) TransactionContextOptions(ctx context.Context, opts *sql.TxOptions) (*pop.Tx, error) {
+	s.rs.wrote()
+	return s.popStore.TransactionContextOptions(ctx, opts)
+}
+
+// Stats returns the connection pool statistics of the primary.
+func (s *routingStore) Stats() sql.DBStats {
+	if db, ok := s.popStore.(StatsProvider); ok {
+		return db.Stats()
+	}
+	return sql.DBStats{}
+}
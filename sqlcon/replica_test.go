@@ -0,0 +1,133 @@
+//go:build sqlite
+// +build sqlite
+
+package sqlcon
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+func TestReplicaSet(t *testing.T) {
+	dir := t.TempDir()
+	dsn := func(name string) string {
+		return fmt.Sprintf("sqlite://%s?_fk=true", filepath.Join(dir, name+".sqlite"))
+	}
+
+	rs, err := NewReplicaSet(logrusx.New("", ""), dsn("primary"), []string{dsn("replica-a"), dsn("replica-b")}, WithReplicaHealthCheckInterval(time.Hour))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = rs.Close()
+	})
+
+	seed := func(c *pop.Connection, name string) {
+		require.NoError(t, c.RawQuery("CREATE TABLE origin (name TEXT)").Exec())
+		require.NoError(t, c.RawQuery("INSERT INTO origin (name) VALUES (?)", name).Exec())
+	}
+	seed(rs.primary, "primary")
+	seed(rs.replicas[0].c, "replica-a")
+	seed(rs.replicas[1].c, "replica-b")
+
+	read := func(ctx context.Context) (name string) {
+		require.NoError(t, rs.Connection().WithContext(ctx).Store.Get(&name, "SELECT name FROM origin LIMIT 1"))
+		return
+	}
+
+	t.Run("case=reads are distributed across replicas", func(t *testing.T) {
+		seen := map[string]bool{}
+		for i := 0; i < 4; i++ {
+			seen[read(context.Background())] = true
+		}
+		assert.Equal(t, map[string]bool{"replica-a": true, "replica-b": true}, seen)
+
+		var names []string
+		require.NoError(t, rs.Connection().RawQuery("SELECT name FROM origin").All(&names))
+		assert.NotContains(t, names, "primary")
+	})
+
+	t.Run("case=reads with the primary context go to the primary", func(t *testing.T) {
+		assert.Equal(t, "primary", read(WithPrimary(context.Background())))
+	})
+
+	t.Run("case=writes go to the primary", func(t *testing.T) {
+		require.NoError(t, rs.Connection().RawQuery("INSERT INTO origin (name) VALUES (?)", "written").Exec())
+
+		var name string
+		require.NoError(t, rs.Connection().RawQuery("INSERT INTO origin (name) VALUES (?) RETURNING name", "returned").First(&name))
+		assert.Equal(t, "returned", name)
+
+		var count int
+		require.NoError(t, rs.primary.Store.(*routingStore).popStore.Get(&count, "SELECT COUNT(*) FROM origin"))
+		assert.Equal(t, 3, count)
+
+		for _, r := range rs.replicas {
+			require.NoError(t, r.c.Store.Get(&count, "SELECT COUNT(*) FROM origin"))
+			assert.Equal(t, 1, count)
+		}
+	})
+
+	t.Run("case=reads after writes go to the primary within the window", func(t *testing.T) {
+		rs.window = time.Hour
+		t.Cleanup(func() { rs.window = 0 })
+
+		assert.NotEqual(t, "primary", read(context.Background()))
+		require.NoError(t, rs.Connection().RawQuery("UPDATE origin SET name = name").Exec())
+		assert.Equal(t, "primary", read(context.Background()))
+	})
+
+	t.Run("case=transactions use the primary", func(t *testing.T) {
+		require.NoError(t, rs.Connection().Transaction(func(tx *pop.Connection) error {
+			var name string
+			require.NoError(t, tx.Store.Get(&name, "SELECT name FROM origin LIMIT 1"))
+			assert.Equal(t, "primary", name)
+			return nil
+		}))
+	})
+
+	t.Run("case=unhealthy replicas do not receive reads", func(t *testing.T) {
+		rs.replicas[0].healthy = 0
+		for i := 0; i < 4; i++ {
+			assert.Equal(t, "replica-b", read(context.Background()))
+		}
+
+		rs.replicas[1].healthy = 0
+		assert.Equal(t, "primary", read(context.Background()))
+
+		assert.Equal(t, 2, rs.CheckHealth(context.Background()))
+	})
+}
+
+func TestIsReadOnly(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		readOnly bool
+	}{
+		{query: "SELECT * FROM identities WHERE id = ?", readOnly: true},
+		{query: "  select count(*) from identities", readOnly: true},
+		{query: "(SELECT 1) UNION (SELECT 2)", readOnly: true},
+		{query: "SELECT * FROM identities FOR UPDATE"},
+		{query: "SELECT * FROM identities FOR NO KEY UPDATE"},
+		{query: "SELECT * FROM identities FOR SHARE"},
+		{query: "SELECT * FROM identities LOCK IN SHARE MODE"},
+		{query: "SELECT * INTO backup FROM identities"},
+		{query: "SELECT nextval('identities_seq')"},
+		{query: "SELECT pg_advisory_lock(1)"},
+		{query: "INSERT INTO identities (id) VALUES (?) RETURNING id"},
+		{query: "UPDATE identities SET state = ? RETURNING id"},
+		{query: "WITH deleted AS (DELETE FROM identities RETURNING id) SELECT * FROM deleted"},
+		{query: "DELETE FROM identities"},
+	} {
+		t.Run("query="+tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.readOnly, isReadOnly(tc.query))
+		})
+	}
+}
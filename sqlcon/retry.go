@@ -0,0 +1,112 @@
+package sqlcon
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/resilience"
+)
+
+// RetryOptions configures WithRetry. The zero value uses sensible defaults.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of times the function is called. Defaults to 10.
+	MaxAttempts int
+	// Budget is the maximum amount of time spent retrying. Defaults to 10 seconds.
+	Budget time.Duration
+	// InitialInterval is the wait time before the first retry. Defaults to 10 milliseconds.
+	InitialInterval time.Duration
+	// MaxInterval caps the exponentially growing wait time between retries. Defaults to one second.
+	MaxInterval time.Duration
+	// Logger, if set, is used to log retries.
+	Logger *logrusx.Logger
+}
+
+func (o *RetryOptions) withDefaults() RetryOptions {
+	var r RetryOptions
+	if o != nil {
+		r = *o
+	}
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = 10
+	}
+	if r.Budget <= 0 {
+		r.Budget = 10 * time.Second
+	}
+	if r.InitialInterval <= 0 {
+		r.InitialInterval = 10 * time.Millisecond
+	}
+	if r.MaxInterval <= 0 {
+		r.MaxInterval = time.Second
+	}
+	return r
+}
+
+// IsRetryable returns true if the error is a serialization failure or deadlock which
+// is resolved by retrying the transaction.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var st stater
+	if errors.Is(err, ErrConcurrentUpdate) {
+		return true
+	} else if errors.As(err, &st) {
+		return isRetryableSQLState(st.SQLState())
+	} else if e := new(pq.Error); errors.As(err, &e) {
+		return isRetryableSQLState(string(e.Code))
+	} else if e := new(pgconn.PgError); errors.As(err, &e) {
+		return isRetryableSQLState(e.Code)
+	} else if e := new(mysql.MySQLError); errors.As(err, &e) {
+		switch e.Number {
+		case 1213: // ER_LOCK_DEADLOCK
+			return true
+		case 1205: // ER_LOCK_WAIT_TIMEOUT
+			return true
+		}
+	}
+	return false
+}
+
+func isRetryableSQLState(sqlState string) bool {
+	switch sqlState {
+	case "40001": // "serialization_failure"
+		return true
+	case "40P01": // "deadlock_detected"
+		return true
+	}
+	return false
+}
+
+// WithRetry calls f until it returns an error which is not retryable (see IsRetryable), it succeeds,
+// or the retry budget is exhausted. Retries are delayed with exponential backoff and jitter.
+//
+// f usually runs a whole transaction, because a transaction which failed due to a serialization
+// failure can not be continued:
+//
+//	err := sqlcon.WithRetry(ctx, nil, func(ctx context.Context) error {
+//		return popx.Transaction(ctx, c, func(ctx context.Context, tx *pop.Connection) error {
+//			// ...
+//		})
+//	})
+func WithRetry(ctx context.Context, opts *RetryOptions, f func(ctx context.Context) error) error {
+	o := opts.withDefaults()
+	return resilience.RetryWithBackoff(ctx, f,
+		resilience.WithMaxAttempts(o.MaxAttempts),
+		resilience.WithMaxElapsedTime(o.Budget),
+		resilience.WithInitialInterval(o.InitialInterval),
+		resilience.WithMaxInterval(o.MaxInterval),
+		resilience.WithRetryable(IsRetryable),
+		resilience.WithNotify(func(err error, attempt int, wait time.Duration) {
+			if o.Logger != nil {
+				o.Logger.WithError(err).WithField("attempt", attempt).Debugf("Retryable SQL error occurred, retrying in %s.", wait)
+			}
+		}),
+	)
+}
@@ -0,0 +1,88 @@
+package sqlcon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for k, tc := range []struct {
+		err      error
+		expected bool
+	}{
+		{err: nil, expected: false},
+		{err: errors.New("foo"), expected: false},
+		{err: &pgconn.PgError{Code: "40001"}, expected: true},
+		{err: errors.WithStack(&pgconn.PgError{Code: "40P01"}), expected: true},
+		{err: &pgconn.PgError{Code: "23505"}, expected: false},
+		{err: &pq.Error{Code: "40001"}, expected: true},
+		{err: &mysql.MySQLError{Number: 1213}, expected: true},
+		{err: &mysql.MySQLError{Number: 1062}, expected: false},
+		{err: HandleError(&pgconn.PgError{Code: "40001"}), expected: true},
+	} {
+		assert.Equal(t, tc.expected, IsRetryable(tc.err), "%d", k)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	opts := &RetryOptions{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+
+	t.Run("case=retries serialization failures", func(t *testing.T) {
+		var calls int
+		require.NoError(t, WithRetry(ctx, opts, func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return &pgconn.PgError{Code: "40001"}
+			}
+			return nil
+		}))
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("case=does not retry other errors", func(t *testing.T) {
+		var calls int
+		expected := errors.New("foo")
+		require.ErrorIs(t, WithRetry(ctx, opts, func(context.Context) error {
+			calls++
+			return expected
+		}), expected)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("case=gives up after max attempts", func(t *testing.T) {
+		var calls int
+		err := WithRetry(ctx, &RetryOptions{MaxAttempts: 4, InitialInterval: time.Millisecond}, func(context.Context) error {
+			calls++
+			return &pgconn.PgError{Code: "40P01"}
+		})
+		require.Error(t, err)
+		assert.True(t, IsRetryable(err))
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("case=gives up when the budget is exhausted", func(t *testing.T) {
+		err := WithRetry(ctx, &RetryOptions{Budget: 10 * time.Millisecond, InitialInterval: 50 * time.Millisecond}, func(context.Context) error {
+			return &pgconn.PgError{Code: "40001"}
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "budget")
+	})
+
+	t.Run("case=respects context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		err := WithRetry(ctx, opts, func(context.Context) error {
+			return &pgconn.PgError{Code: "40001"}
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
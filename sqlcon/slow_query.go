@@ -0,0 +1,423 @@
+package sqlcon
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/logrusx"
+)
+
+var (
+	// slowQueryDrivers maps the parent driver and threshold to the wrapping driver, because database/sql
+	// can not unregister drivers.
+	slowQueryDrivers   = map[slowQueryDriverKey]*slowQueryDriver{}
+	slowQueryDriversMu sync.Mutex
+
+	sqlStringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumericLiteral = regexp.MustCompile(`(^|[^\w$])\d[\w.]*`)
+	sqlWhitespace     = regexp.MustCompile(`\s+`)
+
+	// slowQueryCallerSkip lists the packages which are skipped when looking up who issued a query.
+	slowQueryCallerSkip = []string{
+		"database/sql.",
+		"runtime.",
+		"github.com/jmoiron/sqlx.",
+		"github.com/gobuffalo/pop/",
+		"github.com/ory/x/sqlcon.",
+	}
+)
+
+type (
+	slowQueryDriverKey struct {
+		parent    string
+		threshold time.Duration
+	}
+	slowQueryLog struct {
+		l         *logrusx.Logger
+		threshold time.Duration
+	}
+	slowQueryDriver struct {
+		name   string
+		parent driver.Driver
+		log    *slowQueryLog
+
+		// logs maps DSNs to the log of the connections opened with them, so that a driver registered once
+		// per threshold logs to the logger passed to WithSlowQueryLog for the connection.
+		logs   map[string]*slowQueryLog
+		logsMu sync.RWMutex
+	}
+	slowQueryConnector struct {
+		parent driver.Connector
+		driver *slowQueryDriver
+		log    *slowQueryLog
+	}
+	slowQueryDSNConnector struct {
+		dsn    string
+		driver *slowQueryDriver
+	}
+	slowQueryConn struct {
+		driver.Conn
+		log *slowQueryLog
+	}
+	slowQueryStmt struct {
+		driver.Stmt
+		query string
+		log   *slowQueryLog
+	}
+)
+
+var (
+	_ driver.DriverContext      = (*slowQueryDriver)(nil)
+	_ driver.ConnBeginTx        = (*slowQueryConn)(nil)
+	_ driver.ConnPrepareContext = (*slowQueryConn)(nil)
+	_ driver.ExecerContext      = (*slowQueryConn)(nil)
+	_ driver.QueryerContext     = (*slowQueryConn)(nil)
+	_ driver.Pinger             = (*slowQueryConn)(nil)
+	_ driver.SessionResetter    = (*slowQueryConn)(nil)
+	_ driver.Validator          = (*slowQueryConn)(nil)
+	_ driver.NamedValueChecker  = (*slowQueryConn)(nil)
+	_ driver.StmtExecContext    = (*slowQueryStmt)(nil)
+	_ driver.StmtQueryContext   = (*slowQueryStmt)(nil)
+)
+
+// NewSlowQueryDriver wraps a database/sql driver and logs every statement which takes longer than
+// threshold to execute as a warning. The log entry contains the statement with string and numeric
+// literals replaced by placeholders and excess whitespace removed, the number of arguments (but not
+// their values), the duration, and the caller outside of database/sql, sqlx, and pop which issued
+// the statement. Other literals, such as identifiers or keywords, are logged as is.
+//
+// For queries, the duration covers executing the query but not reading the result rows.
+func NewSlowQueryDriver(l *logrusx.Logger, parent driver.Driver, threshold time.Duration) driver.Driver {
+	return &slowQueryDriver{parent: parent, log: &slowQueryLog{l: l, threshold: threshold}}
+}
+
+// WithSlowQueryLog configures the connection details to log slow statements (see NewSlowQueryDriver).
+// It must be called before the connection is created using pop.NewConnection.
+//
+// The wrapping driver is registered once per parent driver and threshold and reused by subsequent
+// calls. Statements are logged using the logger passed for the DSN of the connection details. Use
+// ParseSlowQueryThreshold to configure the threshold using the DSN.
+func WithSlowQueryLog(l *logrusx.Logger, details *pop.ConnectionDetails, threshold time.Duration) error {
+	if threshold <= 0 {
+		return errors.Errorf("the slow query threshold must be positive but got %s", threshold)
+	}
+	if err := details.Finalize(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	parentName := details.Driver
+	if parentName == "" {
+		switch details.Dialect {
+		case "postgres", "cockroach":
+			parentName = "pgx"
+		default:
+			parentName = details.Dialect
+		}
+	}
+
+	// Opening a database does not connect to it but fails if the driver is unknown.
+	db, err := sql.Open(parentName, "")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	parent := db.Driver()
+	_ = db.Close()
+
+	slowQueryDriversMu.Lock()
+	defer slowQueryDriversMu.Unlock()
+
+	key := slowQueryDriverKey{parent: parentName, threshold: threshold}
+	d, ok := slowQueryDrivers[key]
+	if !ok {
+		d = NewSlowQueryDriver(l, parent, threshold).(*slowQueryDriver)
+		d.name = fmt.Sprintf("sqlcon-slow-query-%s-%d", parentName, len(slowQueryDrivers))
+		sql.Register(d.name, d)
+		sqlx.BindDriver(d.name, sqlx.BindType(parentName))
+		slowQueryDrivers[key] = d
+	}
+	details.Driver = d.name
+
+	// pop opens the database with the DSN computed by the dialect, which identifies the logger.
+	c, err := pop.NewConnection(details)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	d.setLog(c.Dialect.URL(), &slowQueryLog{l: l, threshold: threshold})
+	return nil
+}
+
+// ParseSlowQueryThreshold parses the value of slow_query_threshold from the DSN. It returns zero
+// if the parameter is not set or invalid. It also returns the DSN without that query parameter.
+func ParseSlowQueryThreshold(l *logrusx.Logger, dsn string) (threshold time.Duration, cleanedDSN string) {
+	cleanedDSN = dsn
+
+	parts := strings.SplitN(dsn, "?", 2)
+	if len(parts) != 2 {
+		return
+	}
+
+	query, err := url.ParseQuery(parts[1])
+	if err != nil {
+		return
+	}
+
+	v := query.Get("slow_query_threshold")
+	if v == "" {
+		return
+	}
+	query.Del("slow_query_threshold")
+	cleanedDSN = fmt.Sprintf("%s?%s", parts[0], query.Encode())
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		l.Warnf(`SQL DSN query parameter "slow_query_threshold" value %v could not be parsed to a positive duration, slow queries will not be logged`, v)
+		return
+	}
+	return d, cleanedDSN
+}
+
+func (s *slowQueryLog) observe(start time.Time, query string, args int, err error) {
+	took := time.Since(start)
+	if took < s.threshold {
+		return
+	}
+
+	l := s.l.
+		WithField("sql_statement", sanitizeSQL(query)).
+		WithField("sql_args_count", args).
+		WithField("sql_duration", took.String()).
+		WithField("sql_slow_query_threshold", s.threshold.String()).
+		WithField("caller", slowQueryCaller())
+	if err != nil {
+		l = l.WithError(err)
+	}
+	l.Warn("SQL statement exceeded the slow query threshold.")
+}
+
+func sanitizeSQL(query string) string {
+	query = sqlStringLiteral.ReplaceAllString(query, "'?'")
+	query = sqlNumericLiteral.ReplaceAllString(query, "${1}?")
+	return strings.TrimSpace(sqlWhitespace.ReplaceAllString(query, " "))
+}
+
+func slowQueryCaller() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !isSkippedSlowQueryFrame(frame.Function) {
+			return fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func isSkippedSlowQueryFrame(function string) bool {
+	for _, prefix := range slowQueryCallerSkip {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *slowQueryDriver) setLog(dsn string, log *slowQueryLog) {
+	d.logsMu.Lock()
+	defer d.logsMu.Unlock()
+	if d.logs == nil {
+		d.logs = map[string]*slowQueryLog{}
+	}
+	d.logs[dsn] = log
+}
+
+// logFor returns the log of connections opened with the DSN.
+func (d *slowQueryDriver) logFor(dsn string) *slowQueryLog {
+	d.logsMu.RLock()
+	defer d.logsMu.RUnlock()
+	if log, ok := d.logs[dsn]; ok {
+		return log
+	}
+	return d.log
+}
+
+func (d *slowQueryDriver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.parent.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &slowQueryConn{Conn: c, log: d.logFor(dsn)}, nil
+}
+
+func (d *slowQueryDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	if dc, ok := d.parent.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return &slowQueryConnector{parent: c, driver: d, log: d.logFor(dsn)}, nil
+	}
+	return &slowQueryDSNConnector{dsn: dsn, driver: d}, nil
+}
+
+func (c *slowQueryConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.parent.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &slowQueryConn{Conn: conn, log: c.log}, nil
+}
+
+func (c *slowQueryConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c *slowQueryDSNConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *slowQueryDSNConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c *slowQueryConn) Prepare(query string) (driver.Stmt, error) {
+	s, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &slowQueryStmt{Stmt: s, query: query, log: c.log}, nil
+}
+
+func (c *slowQueryConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	pc, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+
+	s, err := pc.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &slowQueryStmt{Stmt: s, query: query, log: c.log}, nil
+}
+
+func (c *slowQueryConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bc.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("the driver does not support non-default isolation levels")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("the driver does not support read-only transactions")
+	}
+	return c.Conn.Begin() //nolint:staticcheck
+}
+
+func (c *slowQueryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (_ driver.Result, err error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	defer func(start time.Time) {
+		if err != driver.ErrSkip {
+			c.log.observe(start, query, len(args), err)
+		}
+	}(time.Now())
+	return ec.ExecContext(ctx, query, args)
+}
+
+func (c *slowQueryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (_ driver.Rows, err error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	defer func(start time.Time) {
+		if err != driver.ErrSkip {
+			c.log.observe(start, query, len(args), err)
+		}
+	}(time.Now())
+	return qc.QueryContext(ctx, query, args)
+}
+
+func (c *slowQueryConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *slowQueryConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *slowQueryConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *slowQueryConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (s *slowQueryStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (_ driver.Result, err error) {
+	defer func(start time.Time) { s.log.observe(start, s.query, len(args), err) }(time.Now())
+
+	if ec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return ec.ExecContext(ctx, args)
+	}
+
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values) //nolint:staticcheck
+}
+
+func (s *slowQueryStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (_ driver.Rows, err error) {
+	defer func(start time.Time) { s.log.observe(start, s.query, len(args), err) }(time.Now())
+
+	if qc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return qc.QueryContext(ctx, args)
+	}
+
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values) //nolint:staticcheck
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for k, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("the driver does not support named parameters")
+		}
+		values[k] = arg.Value
+	}
+	return values, nil
+}
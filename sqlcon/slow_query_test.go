@@ -0,0 +1,127 @@
+package sqlcon
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+)
+
+type sleepingDriver struct{}
+type sleepingConn struct{}
+type sleepingResult struct{}
+
+func (sleepingDriver) Open(string) (driver.Conn, error) { return sleepingConn{}, nil }
+
+func (sleepingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (sleepingConn) Close() error                        { return nil }
+func (sleepingConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (sleepingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "slow") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return sleepingResult{}, nil
+}
+
+type invalidConn struct{ sleepingConn }
+
+func (invalidConn) IsValid() bool { return false }
+
+func (sleepingResult) LastInsertId() (int64, error) { return 0, nil }
+func (sleepingResult) RowsAffected() (int64, error) { return 0, nil }
+
+func TestSlowQueryDriver(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	sql.Register(t.Name(), NewSlowQueryDriver(logrusx.New("", "", logrusx.UseLogger(logger)), sleepingDriver{}, 10*time.Millisecond))
+
+	db, err := sql.Open(t.Name(), "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.Exec("SELECT 'fast'")
+	require.NoError(t, err)
+	assert.Len(t, hook.AllEntries(), 0)
+
+	_, err = db.Exec("SELECT *\n\tFROM slow WHERE email = 'foo@bar.com' AND id = ?", 1)
+	require.NoError(t, err)
+	require.Len(t, hook.AllEntries(), 1)
+
+	entry := hook.LastEntry()
+	assert.Equal(t, "SELECT * FROM slow WHERE email = '?' AND id = ?", entry.Data["sql_statement"])
+	assert.Equal(t, 1, entry.Data["sql_args_count"])
+	assert.NotEmpty(t, entry.Data["caller"])
+}
+
+func TestWithSlowQueryLog(t *testing.T) {
+	sql.Register(t.Name(), sleepingDriver{})
+
+	open := func(t *testing.T, dsn string) (*pop.Connection, *test.Hook) {
+		logger, hook := test.NewNullLogger()
+		details := &pop.ConnectionDetails{Dialect: "postgres", Driver: t.Name(), URL: dsn}
+		require.NoError(t, WithSlowQueryLog(logrusx.New("", "", logrusx.UseLogger(logger)), details, 10*time.Millisecond))
+
+		c, err := pop.NewConnection(details)
+		require.NoError(t, err)
+		require.NoError(t, c.Open())
+		t.Cleanup(func() { _ = c.Close() })
+		return c, hook
+	}
+
+	a, hookA := open(t, "postgres://a@localhost/a")
+	b, hookB := open(t, "postgres://b@localhost/b")
+
+	t.Run("case=the driver is registered once per threshold", func(t *testing.T) {
+		assert.Equal(t, a.Dialect.Details().Driver, b.Dialect.Details().Driver)
+	})
+
+	t.Run("case=statements are logged using the logger of the connection", func(t *testing.T) {
+		require.NoError(t, a.RawQuery("SELECT slow").Exec())
+		assert.Len(t, hookA.AllEntries(), 1)
+		assert.Len(t, hookB.AllEntries(), 0)
+
+		require.NoError(t, b.RawQuery("SELECT slow").Exec())
+		assert.Len(t, hookA.AllEntries(), 1)
+		assert.Len(t, hookB.AllEntries(), 1)
+	})
+}
+
+func TestSlowQueryConn(t *testing.T) {
+	assert.True(t, (&slowQueryConn{Conn: sleepingConn{}}).IsValid())
+	assert.False(t, (&slowQueryConn{Conn: invalidConn{}}).IsValid())
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	assert.Equal(t, "SELECT '?' FROM a WHERE b = '?'", sanitizeSQL("  SELECT 'it''s'\nFROM a   WHERE b = 'c'  "))
+	assert.Equal(t, "SELECT * FROM table1 WHERE a = ? AND b IN (?,?) AND c > $1 LIMIT ?", sanitizeSQL("SELECT * FROM table1 WHERE a = 1.5 AND b IN (2,0x1F) AND c > $1 LIMIT 10"))
+}
+
+func TestParseSlowQueryThreshold(t *testing.T) {
+	l := logrusx.New("", "")
+	for k, tc := range []struct {
+		dsn, cleaned string
+		threshold    time.Duration
+	}{
+		{dsn: "postgres://foo@bar/baz", cleaned: "postgres://foo@bar/baz"},
+		{dsn: "postgres://foo@bar/baz?sslmode=disable", cleaned: "postgres://foo@bar/baz?sslmode=disable"},
+		{dsn: "postgres://foo@bar/baz?slow_query_threshold=250ms&sslmode=disable", cleaned: "postgres://foo@bar/baz?sslmode=disable", threshold: 250 * time.Millisecond},
+		{dsn: "postgres://foo@bar/baz?slow_query_threshold=foo", cleaned: "postgres://foo@bar/baz?"},
+		{dsn: "postgres://foo@bar/baz?slow_query_threshold=-1s", cleaned: "postgres://foo@bar/baz?"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			threshold, cleaned := ParseSlowQueryThreshold(l, tc.dsn)
+			assert.Equal(t, tc.threshold, threshold)
+			assert.Equal(t, tc.cleaned, cleaned)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package sqlcon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/pkg/errors"
+)
+
+const statementTimeoutSavepoint = "sqlcon_statement_timeout"
+
+// WithStatementTimeout calls f with a connection on which statements are aborted by the database
+// server once the deadline of ctx is exceeded, so that queries of abandoned requests do not keep
+// running. If ctx has no deadline, f is called with the connection bound to ctx.
+//
+// The timeout is applied to a transaction. If c is not a transaction already, f runs in a new one
+// which is committed if f returns no error.
+//
+//   - PostgreSQL and CockroachDB: SET LOCAL statement_timeout applies to every statement of the
+//     transaction. If c is a transaction already, f runs in a savepoint and the previous timeout is
+//     restored once f returns, so later statements of the transaction are not affected. If f fails,
+//     the transaction is rolled back to the savepoint.
+//   - MySQL: max_execution_time aborts SELECT statements and is restored once f returns. Other
+//     statements are only cancelled through the context, which closes the connection.
+//   - SQLite: statements are interrupted through the context.
+func WithStatementTimeout(ctx context.Context, c *pop.Connection, f func(ctx context.Context, c *pop.Connection) error) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return f(ctx, c.WithContext(ctx))
+	}
+
+	if c.TX != nil {
+		return withStatementTimeout(ctx, c, deadline, true, f)
+	}
+
+	txCtx := ctx
+	if c.Dialect.Name() == "mysql" {
+		// database/sql rolls back transactions bound to a context once it is done and returns the
+		// connection to the pool. The session timeout would then outlive the transaction, so the
+		// transaction is not bound to ctx and is only ended after the timeout was restored.
+		txCtx = context.Background()
+	}
+	return errors.WithStack(c.WithContext(txCtx).Transaction(func(tx *pop.Connection) error {
+		return withStatementTimeout(ctx, tx, deadline, false, f)
+	}))
+}
+
+func withStatementTimeout(ctx context.Context, tx *pop.Connection, deadline time.Time, nested bool, f func(ctx context.Context, c *pop.Connection) error) error {
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return errors.WithStack(context.DeadlineExceeded)
+	}
+
+	// A timeout of zero disables the timeout, so the remaining time is rounded up.
+	ms := int64((remaining + time.Millisecond - 1) / time.Millisecond)
+
+	// Restoring the previous timeout must succeed even if the deadline of ctx passed in the meantime.
+	detached := tx.WithContext(context.Background())
+	tx = tx.WithContext(ctx)
+
+	switch tx.Dialect.Name() {
+	case "postgres", "cockroach":
+		if !nested {
+			// Transaction-scoped settings are discarded on commit or rollback, even if the
+			// transaction was aborted by a failing statement.
+			if err := execRaw(tx, fmt.Sprintf("SET LOCAL statement_timeout = %d", ms)); err != nil {
+				return err
+			}
+			return f(ctx, tx)
+		}
+		return withLocalStatementTimeout(ctx, tx, detached, ms, f)
+	case "mysql":
+		return withSessionStatementTimeout(ctx, tx, detached, ms, f)
+	}
+	return f(ctx, tx)
+}
+
+// withLocalStatementTimeout sets the statement timeout in a savepoint of the caller's transaction and
+// restores the previous timeout afterwards. Rolling back to the savepoint restores it as well.
+func withLocalStatementTimeout(ctx context.Context, tx, detached *pop.Connection, ms int64, f func(ctx context.Context, c *pop.Connection) error) error {
+	var previous string
+	if err := tx.Store.Get(&previous, "SELECT current_setting('statement_timeout')"); err != nil {
+		return errors.Wrap(HandleError(err), "unable to read statement timeout")
+	}
+
+	if err := execRaw(tx, "SAVEPOINT "+statementTimeoutSavepoint); err != nil {
+		return err
+	}
+	if err := execRaw(tx, fmt.Sprintf("SET LOCAL statement_timeout = %d", ms)); err != nil {
+		if rerr := execRaw(detached, "ROLLBACK TO SAVEPOINT "+statementTimeoutSavepoint); rerr != nil {
+			return errors.WithMessagef(err, "%s", rerr)
+		}
+		return err
+	}
+
+	if err := f(ctx, tx); err != nil {
+		if rerr := execRaw(detached, "ROLLBACK TO SAVEPOINT "+statementTimeoutSavepoint); rerr != nil {
+			return errors.WithMessagef(err, "%s", rerr)
+		}
+		return err
+	}
+
+	if err := execRaw(detached, fmt.Sprintf("SET LOCAL statement_timeout = '%s'", strings.ReplaceAll(previous, "'", "''"))); err != nil {
+		return err
+	}
+	return execRaw(detached, "RELEASE SAVEPOINT "+statementTimeoutSavepoint)
+}
+
+// withSessionStatementTimeout sets the session's execution time limit and restores the previous limit
+// afterwards, even if f failed, because session settings outlive the transaction and the connection is
+// reused by the pool.
+func withSessionStatementTimeout(ctx context.Context, tx, detached *pop.Connection, ms int64, f func(ctx context.Context, c *pop.Connection) error) (err error) {
+	var previous int64
+	if err := tx.Store.Get(&previous, "SELECT @@SESSION.max_execution_time"); err != nil {
+		return errors.Wrap(HandleError(err), "unable to read statement timeout")
+	}
+
+	if err := execRaw(tx, fmt.Sprintf("SET SESSION max_execution_time = %d", ms)); err != nil {
+		return err
+	}
+
+	err = f(ctx, tx)
+	if rerr := execRaw(detached, fmt.Sprintf("SET SESSION max_execution_time = %d", previous)); rerr != nil && err == nil {
+		return rerr
+	}
+	return err
+}
+
+func execRaw(c *pop.Connection, query string) error {
+	if err := c.RawQuery(query).Exec(); err != nil {
+		return errors.Wrapf(HandleError(err), "unable to execute: %s", query)
+	}
+	return nil
+}
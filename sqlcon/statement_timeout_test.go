@@ -0,0 +1,74 @@
+//go:build sqlite
+// +build sqlite
+
+package sqlcon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStatementTimeout(t *testing.T) {
+	c, err := pop.NewConnection(NewTestSQLite(t))
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+	t.Cleanup(func() { _ = c.Close() })
+
+	t.Run("case=without deadline", func(t *testing.T) {
+		var called bool
+		require.NoError(t, WithStatementTimeout(context.Background(), c, func(_ context.Context, c *pop.Connection) error {
+			called = true
+			assert.Nil(t, c.TX)
+			return nil
+		}))
+		assert.True(t, called)
+	})
+
+	t.Run("case=with deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		require.NoError(t, WithStatementTimeout(ctx, c, func(_ context.Context, c *pop.Connection) error {
+			assert.NotNil(t, c.TX)
+			return c.RawQuery("SELECT 1").Exec()
+		}))
+	})
+
+	t.Run("case=within transaction", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		require.NoError(t, c.Transaction(func(tx *pop.Connection) error {
+			return WithStatementTimeout(ctx, tx, func(_ context.Context, c *pop.Connection) error {
+				assert.Equal(t, tx.TX, c.TX, "the caller's transaction is reused")
+				return c.RawQuery("SELECT 1").Exec()
+			})
+		}))
+	})
+
+	t.Run("case=returns errors of f", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		expected := errors.New("foo")
+		assert.ErrorIs(t, WithStatementTimeout(ctx, c, func(context.Context, *pop.Connection) error {
+			return expected
+		}), expected)
+	})
+
+	t.Run("case=deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		assert.ErrorIs(t, WithStatementTimeout(ctx, c, func(context.Context, *pop.Connection) error {
+			t.Fatal("f must not be called")
+			return nil
+		}), context.DeadlineExceeded)
+	})
+}
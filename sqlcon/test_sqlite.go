@@ -0,0 +1,65 @@
+package sqlcon
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/randx"
+)
+
+// NewTestSQLite returns the connection details of a uniquely named, shared-cache, in-memory SQLite
+// database for use in tests:
+//
+//	c, err := pop.NewConnection(sqlcon.NewTestSQLite(t))
+//
+// Foreign keys are enforced, a busy timeout is set, and the connection pool is limited to a single
+// connection, because concurrent connections to a shared-cache database fail with "database is
+// locked" instead of waiting.
+//
+// The database is kept alive until the test completes, even if all connections of the
+// application's pool are closed in between. The test is skipped if no "sqlite3" driver is
+// registered, which is the case if the binary was built without the `sqlite` build tag.
+func NewTestSQLite(t testing.TB) *pop.ConnectionDetails {
+	name := "test_" + randx.MustString(16, randx.AlphaLowerNum)
+
+	if !isDriverRegistered("sqlite3") {
+		t.Skip(`SQLite support was not built into the test binary, use "go test -tags sqlite" to run this test.`)
+	}
+
+	// Shared-cache in-memory databases are deleted once their last connection closes.
+	keepalive, err := sql.Open("sqlite3", testSQLiteFile(name))
+	require.NoError(t, err)
+	keepalive.SetMaxOpenConns(1)
+	keepalive.SetConnMaxLifetime(0)
+	require.NoError(t, keepalive.Ping(), "unable to open in-memory SQLite database %s", name)
+
+	t.Cleanup(func() {
+		if err := keepalive.Close(); err != nil {
+			t.Logf("Unable to close in-memory SQLite database %s: %+v", name, err)
+		}
+	})
+
+	return &pop.ConnectionDetails{URL: testSQLiteDSN(name), Pool: 1, IdlePool: 1}
+}
+
+func testSQLiteFile(name string) string {
+	return fmt.Sprintf("file:%s?mode=memory&cache=shared&_fk=true&_busy_timeout=5000", name)
+}
+
+func testSQLiteDSN(name string) string {
+	return "sqlite://" + testSQLiteFile(name)
+}
+
+func isDriverRegistered(name string) bool {
+	for _, d := range sql.Drivers() {
+		if strings.EqualFold(d, name) {
+			return true
+		}
+	}
+	return false
+}
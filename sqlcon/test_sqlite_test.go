@@ -0,0 +1,39 @@
+package sqlcon
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestSQLiteDSN(t *testing.T) {
+	dsn := testSQLiteDSN("foo")
+	require.True(t, strings.HasPrefix(dsn, "sqlite://file:foo?"))
+
+	_, query, err := parseQuery(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"mode":          {"memory"},
+		"cache":         {"shared"},
+		"_fk":           {"true"},
+		"_busy_timeout": {"5000"},
+	}, query)
+}
+
+func TestNewTestSQLite(t *testing.T) {
+	details := NewTestSQLite(t)
+	assert.NotEqual(t, details.URL, NewTestSQLite(t).URL)
+
+	c, err := pop.NewConnection(details)
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+	t.Cleanup(func() { _ = c.Close() })
+
+	db, ok := c.Store.(StatsProvider)
+	require.True(t, ok)
+	assert.Equal(t, 1, db.Stats().MaxOpenConnections)
+}
@@ -0,0 +1,131 @@
+package sqlcon
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/resilience"
+)
+
+// WaitOptions configures WaitForDatabase. The zero value uses sensible defaults.
+type WaitOptions struct {
+	// Timeout is the maximum amount of time to wait for the database. Defaults to one minute.
+	// The deadline of the context passed to WaitForDatabase applies as well.
+	Timeout time.Duration
+	// InitialInterval is the wait time before the first retry. Defaults to 100 milliseconds.
+	InitialInterval time.Duration
+	// MaxInterval caps the exponentially growing wait time between retries. Defaults to five seconds.
+	MaxInterval time.Duration
+	// Logger, if set, is used to log connection attempts.
+	Logger *logrusx.Logger
+}
+
+func (o *WaitOptions) withDefaults() WaitOptions {
+	var r WaitOptions
+	if o != nil {
+		r = *o
+	}
+	if r.Timeout <= 0 {
+		r.Timeout = time.Minute
+	}
+	if r.InitialInterval <= 0 {
+		r.InitialInterval = 100 * time.Millisecond
+	}
+	if r.MaxInterval <= 0 {
+		r.MaxInterval = 5 * time.Second
+	}
+	if r.Logger == nil {
+		r.Logger = logrusx.New("", "")
+	}
+	return r
+}
+
+// WaitForDatabase connects to the database until it succeeds, the timeout is exceeded, or a
+// permanent error occurs. Retries are delayed with exponential backoff and jitter.
+//
+// Errors which can not be resolved by waiting, such as authentication failures, invalid DSNs,
+// and untrusted TLS certificates, are returned immediately (see IsPermanentConnectionError).
+// All other errors, for example "connection refused" or "the database system is starting up",
+// are retried. This prevents services which start alongside their database, e.g. in
+// docker-compose or Kubernetes, from crash-looping.
+func WaitForDatabase(ctx context.Context, dsn string, opts *WaitOptions) error {
+	o := opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	c, err := openPopConnection(o.Logger, dsn)
+	if err != nil {
+		return errors.WithMessagef(err, "unable to connect to %s", classifyDSN(dsn))
+	}
+	defer func() { _ = c.Close() }()
+
+	var (
+		attempts int
+		last     error
+	)
+	err = resilience.RetryWithBackoff(ctx, func(ctx context.Context) error {
+		attempts++
+		last = c.WithContext(ctx).RawQuery("SELECT 1").Exec()
+		return last
+	},
+		resilience.WithInitialInterval(o.InitialInterval),
+		resilience.WithMaxInterval(o.MaxInterval),
+		resilience.WithRetryable(func(err error) bool {
+			return !IsPermanentConnectionError(err)
+		}),
+		resilience.WithNotify(func(err error, attempt int, wait time.Duration) {
+			o.Logger.WithError(err).WithField("attempt", attempt).Infof("Database is not reachable yet, retrying in %s.", wait)
+		}),
+	)
+
+	switch {
+	case err == nil:
+		if attempts > 1 {
+			o.Logger.WithField("attempt", attempts).Info("Database connection established.")
+		}
+		return nil
+	case IsPermanentConnectionError(err):
+		return errors.WithMessagef(err, "unable to connect to %s", classifyDSN(dsn))
+	}
+	return errors.WithMessagef(last, "gave up connecting to %s after %d attempts", classifyDSN(dsn), attempts)
+}
+
+// IsPermanentConnectionError returns true if the error returned while connecting to a database
+// can not be resolved by retrying, for example because the credentials are wrong.
+func IsPermanentConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if e := new(pgconn.PgError); errors.As(err, &e) {
+		return isPermanentSQLState(e.Code)
+	} else if e := new(pq.Error); errors.As(err, &e) {
+		return isPermanentSQLState(string(e.Code))
+	} else if e := new(mysql.MySQLError); errors.As(err, &e) {
+		switch e.Number {
+		case 1044, 1045, 1698: // ER_DBACCESS_DENIED_ERROR, ER_ACCESS_DENIED_ERROR, ER_ACCESS_DENIED_NO_PASSWORD_ERROR
+			return true
+		}
+		return false
+	}
+
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+	)
+	return errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalid)
+}
+
+func isPermanentSQLState(sqlState string) bool {
+	// Class 28 - Invalid Authorization Specification
+	return strings.HasPrefix(sqlState, "28")
+}
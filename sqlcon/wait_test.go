@@ -0,0 +1,55 @@
+package sqlcon
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPermanentConnectionError(t *testing.T) {
+	for k, tc := range []struct {
+		err      error
+		expected bool
+	}{
+		{err: nil, expected: false},
+		{err: errors.New("dial tcp 127.0.0.1:5432: connect: connection refused"), expected: false},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: false},
+		{err: &pgconn.PgError{Code: "57P03"}, expected: false},
+		{err: errors.WithStack(&pgconn.PgError{Code: "28P01"}), expected: true},
+		{err: &pq.Error{Code: "28000"}, expected: true},
+		{err: &mysql.MySQLError{Number: 1045}, expected: true},
+		{err: &mysql.MySQLError{Number: 1049}, expected: false},
+		{err: errors.WithStack(x509.UnknownAuthorityError{}), expected: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsPermanentConnectionError(tc.err))
+		})
+	}
+}
+
+func TestWaitForDatabase(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	start := time.Now()
+	err = WaitForDatabase(context.Background(), fmt.Sprintf("postgres://user:pass@%s/db?sslmode=disable", addr), &WaitOptions{
+		Timeout:         200 * time.Millisecond,
+		InitialInterval: 10 * time.Millisecond,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gave up")
+	assert.NotContains(t, err.Error(), "pass")
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
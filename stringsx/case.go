@@ -1,6 +1,9 @@
 package stringsx
 
-import "unicode"
+import (
+	"strings"
+	"unicode"
+)
 
 // ToLowerInitial converts a string's first character to lower case.
 func ToLowerInitial(s string) string {
@@ -21,3 +24,67 @@ func ToUpperInitial(s string) string {
 	a[0] = unicode.ToUpper(a[0])
 	return string(a)
 }
+
+// ToSnakeCase converts a string to snake case, e.g. `HTTPServerURL` and `http-server url` to `http_server_url`.
+// See splitWords for how the string is split into words.
+func ToSnakeCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "_"))
+}
+
+// ToScreamingSnake converts a string to screaming snake case, e.g. `serve.publicPort` to `SERVE_PUBLIC_PORT`.
+// See splitWords for how the string is split into words.
+func ToScreamingSnake(s string) string {
+	return strings.ToUpper(strings.Join(splitWords(s), "_"))
+}
+
+// ToKebabCase converts a string to kebab case, e.g. `HTTPServerURL` to `http-server-url`. See splitWords for how
+// the string is split into words.
+func ToKebabCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "-"))
+}
+
+// ToCamelCase converts a string to lower camel case, e.g. `http_server_url` and `HTTPServerURL` to
+// `httpServerUrl`. See splitWords for how the string is split into words.
+func ToCamelCase(s string) string {
+	words := splitWords(s)
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if i > 0 {
+			w = ToUpperInitial(w)
+		}
+		words[i] = w
+	}
+	return strings.Join(words, "")
+}
+
+// splitWords splits the string into words. Words are separated by all characters which are neither letters nor
+// digits, and by changes from lower to upper case. Acronyms are kept together, so `HTTPServer` becomes `HTTP`
+// and `Server`. Digits belong to the preceding word, e.g. `oauth2Client` becomes `oauth2` and `Client`.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
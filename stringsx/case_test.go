@@ -21,3 +21,31 @@ func TestToUpperInitial(t *testing.T) {
 	assert.Equal(t, "AB", ToUpperInitial("aB"))
 	assert.Equal(t, "Ab", ToUpperInitial("ab"))
 }
+
+func TestCaseConversion(t *testing.T) {
+	for _, tc := range []struct {
+		in, snake, screaming, kebab, camel string
+	}{
+		{in: "", snake: "", screaming: "", kebab: "", camel: ""},
+		{in: "foo", snake: "foo", screaming: "FOO", kebab: "foo", camel: "foo"},
+		{in: "fooBar", snake: "foo_bar", screaming: "FOO_BAR", kebab: "foo-bar", camel: "fooBar"},
+		{in: "FooBar", snake: "foo_bar", screaming: "FOO_BAR", kebab: "foo-bar", camel: "fooBar"},
+		{in: "foo_bar", snake: "foo_bar", screaming: "FOO_BAR", kebab: "foo-bar", camel: "fooBar"},
+		{in: "FOO_BAR", snake: "foo_bar", screaming: "FOO_BAR", kebab: "foo-bar", camel: "fooBar"},
+		{in: "foo-bar baz", snake: "foo_bar_baz", screaming: "FOO_BAR_BAZ", kebab: "foo-bar-baz", camel: "fooBarBaz"},
+		{in: "serve.public.port", snake: "serve_public_port", screaming: "SERVE_PUBLIC_PORT", kebab: "serve-public-port", camel: "servePublicPort"},
+		{in: "HTTPServerURL", snake: "http_server_url", screaming: "HTTP_SERVER_URL", kebab: "http-server-url", camel: "httpServerUrl"},
+		{in: "userID", snake: "user_id", screaming: "USER_ID", kebab: "user-id", camel: "userId"},
+		{in: "oauth2Client", snake: "oauth2_client", screaming: "OAUTH2_CLIENT", kebab: "oauth2-client", camel: "oauth2Client"},
+		{in: "__foo__bar__", snake: "foo_bar", screaming: "FOO_BAR", kebab: "foo-bar", camel: "fooBar"},
+		{in: "ÄpfelÜber", snake: "äpfel_über", screaming: "ÄPFEL_ÜBER", kebab: "äpfel-über", camel: "äpfelÜber"},
+		{in: "ΑλφαΒήτα", snake: "αλφα_βήτα", screaming: "ΑΛΦΑ_ΒΉΤΑ", kebab: "αλφα-βήτα", camel: "αλφαΒήτα"},
+	} {
+		t.Run("case="+tc.in, func(t *testing.T) {
+			assert.Equal(t, tc.snake, ToSnakeCase(tc.in))
+			assert.Equal(t, tc.screaming, ToScreamingSnake(tc.in))
+			assert.Equal(t, tc.kebab, ToKebabCase(tc.in))
+			assert.Equal(t, tc.camel, ToCamelCase(tc.in))
+		})
+	}
+}
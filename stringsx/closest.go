@@ -0,0 +1,59 @@
+package stringsx
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Closest returns the candidate with the smallest Levenshtein distance to the input, ignoring case, for
+// "did you mean" suggestions. If several candidates are equally close, the first one is returned. The boolean
+// is false if no candidate is within the maximum distance.
+func Closest(input string, candidates []string, maxDistance int) (string, bool) {
+	var closest string
+	best := maxDistance + 1
+	for _, c := range candidates {
+		if d := Levenshtein(strings.ToLower(input), strings.ToLower(c)); d < best {
+			closest, best = c, d
+		}
+	}
+	return closest, best <= maxDistance
+}
+
+// Levenshtein returns the number of rune insertions, deletions, and substitutions needed to turn a into b.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// suggestionDistance is the maximum distance of "did you mean" suggestions for the input: roughly one typo
+// per three characters.
+func suggestionDistance(input string) int {
+	return utf8.RuneCountInString(input)/3 + 1
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
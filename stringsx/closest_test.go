@@ -0,0 +1,69 @@
+package stringsx
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshtein(t *testing.T) {
+	for k, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "foo", b: "", expected: 3},
+		{a: "", b: "foo", expected: 3},
+		{a: "foo", b: "foo", expected: 0},
+		{a: "kitten", b: "sitting", expected: 3},
+		{a: "serve", b: "sevre", expected: 2},
+		{a: "größe", b: "grosse", expected: 3},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, tc.expected, Levenshtein(tc.a, tc.b))
+		})
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"json", "json-pretty", "yaml", "table"}
+
+	for k, tc := range []struct {
+		input       string
+		maxDistance int
+		expected    string
+		found       bool
+	}{
+		{input: "jsno", maxDistance: 2, expected: "json", found: true},
+		{input: "JSON", maxDistance: 0, expected: "json", found: true},
+		{input: "yml", maxDistance: 1, expected: "yaml", found: true},
+		{input: "json-prety", maxDistance: 1, expected: "json-pretty", found: true},
+		{input: "xml", maxDistance: 1},
+		{input: "tab", maxDistance: 1},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			actual, found := Closest(tc.input, candidates, tc.maxDistance)
+			assert.Equal(t, tc.found, found)
+			if tc.found {
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+
+	t.Run("case=no candidates", func(t *testing.T) {
+		_, found := Closest("foo", nil, 10)
+		assert.False(t, found)
+	})
+
+	t.Run("case=switch suggestion", func(t *testing.T) {
+		f := SwitchExact("jsno")
+		f.AddCase("json")
+		f.AddCase("yaml")
+		assert.Equal(t, "expected one of [json, yaml] but got jsno, did you mean json?", f.ToUnknownCaseErr().Error())
+
+		f = SwitchExact("xml")
+		f.AddCase("json")
+		assert.Equal(t, "expected one of [json] but got xml", f.ToUnknownCaseErr().Error())
+	})
+}
@@ -0,0 +1,45 @@
+package stringsx
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/randx"
+)
+
+// Charset is the set of characters SecureRandom picks from.
+type Charset string
+
+const (
+	// CharsetAlphaNum contains the characters [a-zA-Z0-9].
+	CharsetAlphaNum Charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	// CharsetHex contains the characters [0-9a-f].
+	CharsetHex Charset = "0123456789abcdef"
+	// CharsetURLSafe contains the characters [a-zA-Z0-9-_], which need not be escaped in URLs.
+	CharsetURLSafe = CharsetAlphaNum + "-_"
+)
+
+// SecureRandom returns a string of n characters picked uniformly from the charset using crypto/rand, so it
+// can be used for secrets and identifiers.
+func SecureRandom(n int, charset Charset) (string, error) {
+	if n < 0 {
+		return "", errors.Errorf("the length must not be negative but got %d", n)
+	}
+	if charset == "" {
+		return "", errors.New("the charset must not be empty")
+	}
+
+	seq, err := randx.RuneSequence(n, []rune(charset))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(seq), nil
+}
+
+// MustSecureRandom is like SecureRandom but panics on error.
+func MustSecureRandom(n int, charset Charset) string {
+	s, err := SecureRandom(n, charset)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
@@ -0,0 +1,42 @@
+package stringsx
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureRandom(t *testing.T) {
+	for _, tc := range []struct {
+		charset Charset
+		pattern string
+	}{
+		{charset: CharsetAlphaNum, pattern: "^[a-zA-Z0-9]{64}$"},
+		{charset: CharsetHex, pattern: "^[0-9a-f]{64}$"},
+		{charset: CharsetURLSafe, pattern: "^[a-zA-Z0-9_-]{64}$"},
+		{charset: "äö", pattern: "^[äö]{64}$"},
+	} {
+		t.Run("charset="+string(tc.charset), func(t *testing.T) {
+			actual, err := SecureRandom(64, tc.charset)
+			require.NoError(t, err)
+			assert.Regexp(t, regexp.MustCompile(tc.pattern), actual)
+			assert.NotEqual(t, actual, MustSecureRandom(64, tc.charset))
+		})
+	}
+
+	t.Run("case=empty string", func(t *testing.T) {
+		actual, err := SecureRandom(0, CharsetHex)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("case=invalid arguments", func(t *testing.T) {
+		_, err := SecureRandom(-1, CharsetHex)
+		assert.Error(t, err)
+		_, err = SecureRandom(10, "")
+		assert.Error(t, err)
+		assert.Panics(t, func() { MustSecureRandom(10, "") })
+	})
+}
@@ -66,6 +66,9 @@ func (r *RegisteredPrefixes) ToUnknownPrefixErr() error {
 }
 
 func (e errUnknownCase) Error() string {
+	if c, ok := Closest(e.actual, e.cases, suggestionDistance(e.actual)); ok {
+		return fmt.Sprintf("expected one of %s but got %s, did you mean %s?", e.String(), e.actual, c)
+	}
 	return fmt.Sprintf("expected one of %s but got %s", e.String(), e.actual)
 }
 
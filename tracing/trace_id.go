@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceID returns the ID of the trace the span in the context belongs to, or an empty string if there is no
+// span or its tracer does not expose the trace ID. OpenTelemetry spans and spans of the Jaeger tracer are
+// supported.
+func TraceID(ctx context.Context) string {
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		return spanCtx.TraceID().String()
+	}
+
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	if spanCtx, ok := span.Context().(jaeger.SpanContext); ok && spanCtx.IsValid() {
+		return spanCtx.TraceID().String()
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ory/x/tracing"
+)
+
+func TestTraceID(t *testing.T) {
+	t.Run("case=no span", func(t *testing.T) {
+		assert.Empty(t, tracing.TraceID(context.Background()))
+	})
+
+	t.Run("case=opentelemetry", func(t *testing.T) {
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		}))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tracing.TraceID(ctx))
+	})
+
+	t.Run("case=jaeger", func(t *testing.T) {
+		tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+		defer closer.Close()
+
+		span := tracer.StartSpan("test")
+		defer span.Finish()
+
+		ctx := opentracing.ContextWithSpan(context.Background(), span)
+		assert.Equal(t, span.Context().(jaeger.SpanContext).TraceID().String(), tracing.TraceID(ctx))
+		assert.NotEmpty(t, tracing.TraceID(ctx))
+	})
+
+	t.Run("case=unsupported tracer", func(t *testing.T) {
+		span := mocktracer.New().StartSpan("test")
+		defer span.Finish()
+
+		assert.Empty(t, tracing.TraceID(opentracing.ContextWithSpan(context.Background(), span)))
+	})
+}
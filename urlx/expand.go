@@ -0,0 +1,136 @@
+package urlx
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type urlComponent int
+
+const (
+	componentScheme urlComponent = iota
+	componentHost
+	componentPath
+	componentQuery
+	componentFragment
+)
+
+// Expand replaces the variables in a URL template, such as `https://{host}/users/{id}?tab={tab}`,
+// with the given values and parses the result.
+//
+// The values are escaped for the component of the URL they appear in, so they can not alter the
+// structure of the URL: a value in the path can not add path segments, and a value in the query
+// can not add query parameters. Values in the host must be host names or IP addresses, optionally
+// with a port. Variables are not allowed in the scheme.
+//
+// An error is returned if a variable has no value, or if the template or the result is not a valid URL.
+// Like SafeJoin, ErrPathTraversal is returned if the path of the result contains the segments "."
+// or "..", for example because a value in the path is "..".
+func Expand(template string, vars map[string]string) (*url.URL, error) {
+	var (
+		b         strings.Builder
+		component = componentPath
+	)
+	absolute := false
+	if i := strings.Index(template, "://"); i >= 0 && !strings.ContainsAny(template[:i], "/?#") {
+		component, absolute = componentScheme, true
+	}
+
+	for rest := template; len(rest) > 0; {
+		start := strings.IndexByte(rest, '{')
+		literal := rest
+		if start >= 0 {
+			literal = rest[:start]
+		}
+		if strings.IndexByte(literal, '}') >= 0 {
+			return nil, errors.Errorf("unable to expand URL template %s: unexpected }", template)
+		}
+		b.WriteString(literal)
+		component = advanceComponent(component, literal)
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, errors.Errorf("unable to expand URL template %s: missing }", template)
+		}
+		name := rest[start+1 : start+end]
+		rest = rest[start+end+1:]
+
+		value, ok := vars[name]
+		if !ok {
+			return nil, errors.Errorf("unable to expand URL template %s: no value for variable %s", template, name)
+		}
+		escaped, err := escapeComponent(component, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to expand URL template %s: invalid value for variable %s", template, name)
+		}
+		b.WriteString(escaped)
+	}
+
+	u, err := url.Parse(b.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to expand URL template %s", template)
+	}
+	if absolute && (u.Scheme == "http" || u.Scheme == "https") && u.Host == "" {
+		return nil, errors.Errorf("unable to expand URL template %s: the URL has no host", template)
+	}
+	for _, segment := range strings.Split(u.EscapedPath(), "/") {
+		if segment == "." || segment == ".." {
+			return nil, errors.Wrapf(ErrPathTraversal, "unable to expand URL template %s", template)
+		}
+	}
+	return u, nil
+}
+
+// MustExpand is like Expand but panics if the template can not be expanded. Use it for templates and
+// values which are known to be valid, e.g. because they were validated by the configuration schema.
+func MustExpand(template string, vars map[string]string) *url.URL {
+	u, err := Expand(template, vars)
+	if err != nil {
+		panic(err.Error())
+	}
+	return u
+}
+
+// advanceComponent returns the component of the URL following the literal text.
+func advanceComponent(c urlComponent, literal string) urlComponent {
+	for i := 0; i < len(literal); i++ {
+		switch {
+		case c == componentScheme && strings.HasPrefix(literal[i:], "://"):
+			c, i = componentHost, i+2
+		case c == componentHost && literal[i] == '/':
+			c = componentPath
+		case (c == componentHost || c == componentPath) && literal[i] == '?':
+			c = componentQuery
+		case c != componentScheme && c != componentFragment && literal[i] == '#':
+			c = componentFragment
+		}
+	}
+	return c
+}
+
+func escapeComponent(c urlComponent, value string) (string, error) {
+	switch c {
+	case componentScheme:
+		return "", errors.New("variables are not allowed in the scheme")
+	case componentHost:
+		if value == "" {
+			return "", errors.New("the host must not be empty")
+		}
+		for _, r := range value {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+				r == '.' || r == '-' || r == '_' || r == ':' || r == '[' || r == ']') {
+				return "", errors.Errorf("the host contains the invalid character %q", r)
+			}
+		}
+		return value, nil
+	case componentQuery:
+		return url.QueryEscape(value), nil
+	}
+	// Path and fragment.
+	return url.PathEscape(value), nil
+}
@@ -0,0 +1,90 @@
+package urlx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		vars     map[string]string
+		expected string
+	}{
+		{
+			template: "https://{host}/users/{id}",
+			vars:     map[string]string{"host": "example.com", "id": "1234"},
+			expected: "https://example.com/users/1234",
+		},
+		{
+			template: "https://{host}:{port}/users/{id}",
+			vars:     map[string]string{"host": "127.0.0.1", "port": "4455", "id": "1"},
+			expected: "https://127.0.0.1:4455/users/1",
+		},
+		{
+			template: "https://{host}/users/{id}",
+			vars:     map[string]string{"host": "[::1]:4455", "id": "1"},
+			expected: "https://[::1]:4455/users/1",
+		},
+		{
+			template: "https://example.com/users/{id}/settings",
+			vars:     map[string]string{"id": "../../admin?x=y#z"},
+			expected: "https://example.com/users/..%2F..%2Fadmin%3Fx=y%23z/settings",
+		},
+		{
+			template: "https://example.com/callback?state={state}&return_to={return_to}#{fragment}",
+			vars:     map[string]string{"state": "a&b=c", "return_to": "https://example.org/?a=b#c", "fragment": "foo bar"},
+			expected: "https://example.com/callback?state=a%26b%3Dc&return_to=https%3A%2F%2Fexample.org%2F%3Fa%3Db%23c#foo%20bar",
+		},
+		{
+			template: "/users/{id}",
+			vars:     map[string]string{"id": "a b", "unused": "foo"},
+			expected: "/users/a%20b",
+		},
+		{
+			template: "file:///etc/{name}",
+			vars:     map[string]string{"name": "config.yaml"},
+			expected: "file:///etc/config.yaml",
+		},
+	} {
+		t.Run("case="+tc.template, func(t *testing.T) {
+			u, err := Expand(tc.template, tc.vars)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, u.String())
+			assert.Equal(t, tc.expected, MustExpand(tc.template, tc.vars).String())
+		})
+	}
+
+	for _, tc := range []struct {
+		name     string
+		template string
+		vars     map[string]string
+	}{
+		{name: "missing variable", template: "https://example.com/users/{id}"},
+		{name: "unclosed variable", template: "https://example.com/users/{id", vars: map[string]string{"id": "1"}},
+		{name: "unopened variable", template: "https://example.com/users/id}", vars: map[string]string{"id": "1"}},
+		{name: "variable in scheme", template: "{scheme}://example.com", vars: map[string]string{"scheme": "https"}},
+		{name: "host with path", template: "https://{host}/users", vars: map[string]string{"host": "evil.com/foo"}},
+		{name: "host with user info", template: "https://{host}/users", vars: map[string]string{"host": "example.com@evil.com"}},
+		{name: "empty host", template: "https://{host}/users", vars: map[string]string{"host": ""}},
+		{name: "invalid port", template: "https://example.com:{port}/", vars: map[string]string{"port": "foo"}},
+		{name: "dot dot segment", template: "https://example.com/users/{id}/settings", vars: map[string]string{"id": ".."}},
+		{name: "dot segment", template: "https://example.com/users/{id}", vars: map[string]string{"id": "."}},
+		{name: "relative dot dot segment", template: "/users/{id}", vars: map[string]string{"id": ".."}},
+		{name: "dot segment combined with literal", template: "https://example.com/files/.{ext}", vars: map[string]string{"ext": "."}},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			_, err := Expand(tc.template, tc.vars)
+			assert.Error(t, err)
+			if strings.Contains(tc.name, "dot") {
+				assert.ErrorIs(t, err, ErrPathTraversal)
+			}
+			assert.Panics(t, func() {
+				MustExpand(tc.template, tc.vars)
+			})
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package urlx
+
+import (
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// FilePathToURL returns a file URL for a path of the runtime OS filesystem. It is the inverse of
+// GetURLFilePath, and the string representation of the URL is understood by Parse:
+//
+//   - `/home/user/file 1.json` becomes `file:///home/user/file%201.json`,
+//   - `C:\Users\file.json` becomes `file:///C:/Users/file.json` on Windows,
+//   - `\\host\share\file.json` becomes `file:////host/share/file.json` on Windows,
+//   - relative paths become URLs without a scheme, e.g. `config/file.json`.
+//
+// Characters which have a special meaning in URLs, such as `%`, `?`, or `#`, are escaped.
+func FilePathToURL(path string) *url.URL {
+	if runtime.GOOS == "windows" {
+		return windowsFilePathToURL(path)
+	}
+	return unixFilePathToURL(path)
+}
+
+func unixFilePathToURL(path string) *url.URL {
+	if strings.HasPrefix(path, "/") {
+		return &url.URL{Scheme: "file", Path: path}
+	}
+	return &url.URL{Path: path}
+}
+
+func windowsFilePathToURL(path string) *url.URL {
+	path = strings.ReplaceAll(path, "\\", "/")
+
+	// Long paths, see https://docs.microsoft.com/en-us/windows/win32/fileio/naming-a-file#win32-file-namespaces
+	if strings.HasPrefix(path, "//?/") {
+		path = strings.TrimPrefix(path, "//?/")
+		if strings.HasPrefix(strings.ToUpper(path), "UNC/") {
+			path = "//" + path[len("UNC/"):]
+		}
+	}
+
+	switch {
+	case winPathRegex.MatchString(path):
+		return &url.URL{Scheme: "file", Path: "/" + path}
+	case strings.HasPrefix(path, "/"):
+		// UNC paths keep their leading slashes, resulting in `file:////host/share`.
+		return &url.URL{Scheme: "file", Path: path}
+	}
+	return &url.URL{Path: path}
+}
@@ -0,0 +1,71 @@
+package urlx
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilePathToURL(t *testing.T) {
+	for _, tc := range []struct {
+		path    string
+		unix    string
+		windows string
+	}{
+		{path: "/home/test/file1.txt", unix: "file:///home/test/file1.txt", windows: "file:///home/test/file1.txt"},
+		{path: "/home/test/file 2#?.txt", unix: "file:///home/test/file%202%23%3F.txt", windows: "file:///home/test/file%202%23%3F.txt"},
+		{path: "/home/test/100%.txt", unix: "file:///home/test/100%25.txt", windows: "file:///home/test/100%25.txt"},
+		{path: "config/file3.txt", unix: "config/file3.txt", windows: "config/file3.txt"},
+		{path: "../file4.txt", unix: "../file4.txt", windows: "../file4.txt"},
+		{path: `C:\Users\test\file 5.txt`, unix: "./C:%5CUsers%5Ctest%5Cfile%205.txt", windows: "file:///C:/Users/test/file%205.txt"},
+		{path: `c:/Users/test/file6.txt`, unix: "./c:/Users/test/file6.txt", windows: "file:///c:/Users/test/file6.txt"},
+		{path: `\\hostname\share\file7.txt`, unix: "%5C%5Chostname%5Cshare%5Cfile7.txt", windows: "file:////hostname/share/file7.txt"},
+		{path: `\\?\C:\very\long\file8.txt`, unix: "./%5C%5C%3F%5CC:%5Cvery%5Clong%5Cfile8.txt", windows: "file:///C:/very/long/file8.txt"},
+		{path: `\\?\UNC\hostname\share\file9.txt`, unix: "%5C%5C%3F%5CUNC%5Chostname%5Cshare%5Cfile9.txt", windows: "file:////hostname/share/file9.txt"},
+		{path: `..\file10.txt`, unix: "..%5Cfile10.txt", windows: "../file10.txt"},
+	} {
+		t.Run("case="+tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.unix, unixFilePathToURL(tc.path).String())
+			assert.Equal(t, tc.windows, windowsFilePathToURL(tc.path).String())
+		})
+	}
+}
+
+func TestFilePathRoundTrip(t *testing.T) {
+	paths := []string{
+		"/home/test/file1.txt",
+		"/home/test/file 2#?.txt",
+		"/home/test/100%.txt",
+		"config/file3.txt",
+	}
+	if runtime.GOOS == "windows" {
+		paths = []string{
+			`C:\Users\test\file1.txt`,
+			`C:\Users\test\file 2#.txt`,
+			`C:\Users\test\100%.txt`,
+			`\\hostname\share\file3.txt`,
+			`\\hostname\share\file 4%20.txt`,
+			`config\file5.txt`,
+		}
+	}
+
+	for _, p := range paths {
+		t.Run("case="+p, func(t *testing.T) {
+			u := FilePathToURL(p)
+			assert.Equal(t, p, GetURLFilePath(u), "path to url")
+
+			parsed, err := Parse(u.String())
+			require.NoError(t, err)
+			assert.Equal(t, p, GetURLFilePath(parsed), "url string to path")
+
+			if runtime.GOOS == "windows" {
+				// Parse only treats Windows paths as plain paths, others are parsed as URLs.
+				parsed, err = Parse(p)
+				require.NoError(t, err)
+				assert.Equal(t, p, GetURLFilePath(parsed), "plain path to url to path")
+			}
+		})
+	}
+}
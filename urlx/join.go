@@ -2,7 +2,9 @@ package urlx
 
 import (
 	"net/url"
-	"path"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"github.com/ory/x/cmdx"
 )
@@ -16,21 +18,71 @@ func MustJoin(first string, parts ...string) string {
 	return AppendPaths(u, parts...).String()
 }
 
+// ErrPathTraversal is returned by SafeJoin if a path segment is "." or "..".
+var ErrPathTraversal = errors.New("path segments must not be \".\" or \"..\"")
+
 // AppendPaths appends the provided paths to the url.
+//
+// The paths are split into segments at "/" and each segment is escaped, so characters such as "?" or "#"
+// can not change the meaning of the URL. Empty segments are dropped, so duplicate slashes are removed. The
+// segments "." and ".." are resolved, but never above the path of the url. Use SafeJoin to reject them
+// instead, for example if the paths are user input.
+//
+// A trailing slash of the last path is kept.
 func AppendPaths(u *url.URL, paths ...string) (ep *url.URL) {
-	ep = Copy(u)
+	ep, _ = appendPaths(u, false, paths)
+	return ep
+}
+
+// SafeJoin is like AppendPaths but returns ErrPathTraversal if any path contains the segments "." or "..".
+// Use it when building redirect or callback URLs from untrusted input.
+func SafeJoin(u *url.URL, paths ...string) (*url.URL, error) {
+	return appendPaths(u, true, paths)
+}
+
+func appendPaths(u *url.URL, strict bool, paths []string) (*url.URL, error) {
+	ep := Copy(u)
 	if len(paths) == 0 {
-		return ep
+		return ep, nil
 	}
 
-	ep.Path = path.Join(append([]string{ep.Path}, paths...)...)
+	var segments []string
+	for _, p := range paths {
+		for _, segment := range strings.Split(p, "/") {
+			switch segment {
+			case "":
+				continue
+			case ".", "..":
+				if strict {
+					return nil, errors.Wrapf(ErrPathTraversal, "unable to join path %q", p)
+				}
+				if segment == ".." && len(segments) > 0 {
+					segments = segments[:len(segments)-1]
+				}
+				continue
+			}
+			segments = append(segments, url.PathEscape(segment))
+		}
+	}
 
-	last := paths[len(paths)-1]
-	if last[len(last)-1] == '/' {
-		ep.Path = ep.Path + "/"
+	escaped := strings.TrimRight(ep.EscapedPath(), "/")
+	for _, segment := range segments {
+		escaped += "/" + segment
+	}
+	if strings.HasSuffix(paths[len(paths)-1], "/") || (escaped == "" && ep.Host != "") {
+		escaped += "/"
 	}
 
-	return ep
+	unescaped, err := url.PathUnescape(escaped)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ep.Path, ep.RawPath = unescaped, ""
+	if ep.EscapedPath() != escaped {
+		// Keep escaped characters of the original path, such as "%2F", which are lost in ep.Path.
+		ep.RawPath = escaped
+	}
+	return ep, nil
 }
 
 // SetQuery appends the provided url values to the DSN's query string.
@@ -1,6 +1,7 @@
 package urlx
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"testing"
@@ -39,6 +40,30 @@ func TestAppendPaths(t *testing.T) {
 			give:   []string{"http://localhost/", "/home", "home/", "/home/"},
 			expect: "http://localhost/home/home/home/",
 		},
+		{
+			give:   []string{"http://localhost/api//v1/", "//users", "", "1"},
+			expect: "http://localhost/api//v1/users/1",
+		},
+		{
+			give:   []string{"http://localhost/", "a b", "c?d=e#f", "g;h"},
+			expect: "http://localhost/a%20b/c%3Fd=e%23f/g%3Bh",
+		},
+		{
+			give:   []string{"http://localhost/files/a%2Fb", "c"},
+			expect: "http://localhost/files/a%2Fb/c",
+		},
+		{
+			give:   []string{"http://localhost/api", "users/../../../admin", "./keys"},
+			expect: "http://localhost/api/admin/keys",
+		},
+		{
+			give:   []string{"http://localhost", ""},
+			expect: "http://localhost/",
+		},
+		{
+			give:   []string{"http://localhost/callback?foo=bar", "baz"},
+			expect: "http://localhost/callback/baz?foo=bar",
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			u, err := url.Parse(tc.give[0])
@@ -48,6 +73,26 @@ func TestAppendPaths(t *testing.T) {
 	}
 }
 
+func TestSafeJoin(t *testing.T) {
+	u, err := url.Parse("https://example.com/oauth2/")
+	require.NoError(t, err)
+
+	j, err := SafeJoin(u, "callback", "a b")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/oauth2/callback/a%20b", j.String())
+
+	for _, p := range []string{"..", "../admin", "foo/../../admin", "./foo", "foo/."} {
+		t.Run("case="+p, func(t *testing.T) {
+			_, err := SafeJoin(u, "callback", p)
+			assert.True(t, errors.Is(err, ErrPathTraversal), "%+v", err)
+		})
+	}
+
+	j, err = SafeJoin(u, "%2e%2e", "..%2f")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/oauth2/%252e%252e/..%252f", j.String(), "escaped dots are not decoded")
+}
+
 func TestAppendQuery(t *testing.T) {
 	u, err := url.Parse("http://localhost/home?foo=bar&baz=bar")
 	require.NoError(t, err)
@@ -30,10 +30,15 @@ func Parse(rawURL string) (*url.URL, error) {
 	// Normally the first part after file:// is a hostname, but since
 	// this is often misused we interpret the URL like a normal path
 	// by removing the "file://" from the beginning (if it exists)
+	isURL := strings.HasPrefix(lcRawURL, "file://")
 	rawURL = trimPrefixIC(rawURL, "file://")
 
 	if winPathRegex.MatchString(rawURL) {
 		// Windows path
+		if !isURL {
+			// A plain path is not URL-encoded, so a % must be kept as is.
+			return &url.URL{Scheme: "file", Path: "/" + rawURL}, nil
+		}
 		return url.Parse("file:///" + rawURL)
 	}
 
@@ -43,7 +48,7 @@ func Parse(rawURL string) (*url.URL, error) {
 		// based on the hostname and the path
 		host, path := extractUNCPathParts(rawURL)
 		// It is safe to replace the \ with / here because this is POSIX style path
-		return url.Parse("file://" + host + strings.ReplaceAll(path, "\\", "/"))
+		return &url.URL{Scheme: "file", Host: host, Path: strings.ReplaceAll(path, "\\", "/")}, nil
 	}
 
 	return url.Parse(rawURL)
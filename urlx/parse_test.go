@@ -32,6 +32,10 @@ func TestParseURL(t *testing.T) {
 		{"..\\file10.txt", "..\\file10.txt", "..%5Cfile10.txt"},
 		{"C:\\file11.txt", "/C:\\file11.txt", "file:///C:%5Cfile11.txt"},
 		{"\\\\hostname\\share\\file12.txt", "/share/file12.txt", "file://hostname/share/file12.txt"},
+		{"\\\\hostname\\share\\file%2012.txt", "/share/file%2012.txt", "file://hostname/share/file%252012.txt"},
+		{"C:\\100%\\file11b.txt", "/C:\\100%\\file11b.txt", "file:///C:%5C100%25%5Cfile11b.txt"},
+		{"file://C:/100%25/file11c.txt", "/C:/100%/file11c.txt", "file:///C:/100%25/file11c.txt"},
+		{"file:////hostname/share/file12b.txt", "//hostname/share/file12b.txt", "file:////hostname/share/file12b.txt"},
 		{"\\\\", "/", "file:///"},
 		{"\\\\hostname", "/", "file://hostname/"},
 		{"\\\\hostname\\", "/", "file://hostname/"},
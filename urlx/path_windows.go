@@ -19,7 +19,7 @@ func GetURLFilePath(u *url.URL) string {
 	}
 
 	fPath := u.Path
-	if u.Host != "" {
+	if u.Host != "" && !strings.EqualFold(u.Host, "localhost") {
 		// Make UNC Path
 		fPath = "\\\\" + u.Host + filepath.FromSlash(fPath)
 		return fPath